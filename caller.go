@@ -0,0 +1,21 @@
+package ultralogger
+
+import (
+    "path/filepath"
+    "strconv"
+)
+
+// Caller is the location in the source code that emitted a log line.
+type Caller struct {
+    // Function is the fully qualified name of the function, e.g. "github.com/org/pkg.(*Server).handle".
+    Function string
+    // File is the absolute path of the source file.
+    File string
+    // Line is the line number in the source file.
+    Line int
+}
+
+// String returns the caller as "file.go:line", using only the base name of the file.
+func (c Caller) String() string {
+    return filepath.Base(c.File) + ":" + strconv.Itoa(c.Line)
+}
@@ -0,0 +1,281 @@
+package ultralogger
+
+import (
+    "encoding"
+    "encoding/json"
+    "fmt"
+    "reflect"
+    "slices"
+    "strings"
+    "sync"
+    "time"
+)
+
+// maxBinaryDepth is the maximum nesting depth of the values written by the binary encoders. It guards against cyclic
+// data.
+const maxBinaryDepth = 64
+
+// binaryAppender appends the values of a binary encoding to a buffer. It is implemented by the CBOR and MessagePack
+// encoders, which share appendBinaryValue to walk the data.
+type binaryAppender interface {
+    appendNil(b []byte) []byte
+    appendBool(b []byte, v bool) []byte
+    appendInt(b []byte, v int64) []byte
+    appendUint(b []byte, v uint64) []byte
+    appendFloat32(b []byte, v float32) []byte
+    appendFloat64(b []byte, v float64) []byte
+    appendString(b []byte, v string) []byte
+    appendBytes(b []byte, v []byte) []byte
+    appendTime(b []byte, v time.Time) []byte
+    appendDuration(b []byte, v time.Duration) []byte
+    appendArrayHeader(b []byte, n int) []byte
+    appendMapHeader(b []byte, n int) []byte
+}
+
+// appendBinaryObject appends the results as a map, keeping the order of the results.
+func appendBinaryObject(enc binaryAppender, b []byte, results []FieldResult) ([]byte, error) {
+    b = enc.appendMapHeader(b, len(results))
+
+    var err error
+    for _, result := range results {
+        b = enc.appendString(b, result.Name)
+        if b, err = appendBinaryValue(enc, b, result.Data, 0); err != nil {
+            return nil, err
+        }
+    }
+
+    return b, nil
+}
+
+// appendBinaryValue appends the data in the binary encoding. time.Time and time.Duration keep their native types,
+// errors are written as their message, and types that implement json.Marshaler or encoding.TextMarshaler are written
+// as they would be in JSON. Structs are written as maps, with the names and omitempty options of their json tags.
+func appendBinaryValue(enc binaryAppender, b []byte, data any, depth int) ([]byte, error) {
+    if depth > maxBinaryDepth {
+        return nil, ErrorBinaryDepthExceeded
+    }
+
+    switch v := data.(type) {
+    case nil:
+        return enc.appendNil(b), nil
+    case bool:
+        return enc.appendBool(b, v), nil
+    case string:
+        return enc.appendString(b, v), nil
+    case []byte:
+        return enc.appendBytes(b, v), nil
+    case int:
+        return enc.appendInt(b, int64(v)), nil
+    case int8:
+        return enc.appendInt(b, int64(v)), nil
+    case int16:
+        return enc.appendInt(b, int64(v)), nil
+    case int32:
+        return enc.appendInt(b, int64(v)), nil
+    case int64:
+        return enc.appendInt(b, v), nil
+    case uint:
+        return enc.appendUint(b, uint64(v)), nil
+    case uint8:
+        return enc.appendUint(b, uint64(v)), nil
+    case uint16:
+        return enc.appendUint(b, uint64(v)), nil
+    case uint32:
+        return enc.appendUint(b, uint64(v)), nil
+    case uint64:
+        return enc.appendUint(b, v), nil
+    case float32:
+        return enc.appendFloat32(b, v), nil
+    case float64:
+        return enc.appendFloat64(b, v), nil
+    case time.Time:
+        return enc.appendTime(b, v), nil
+    case time.Duration:
+        return enc.appendDuration(b, v), nil
+    case structObject:
+        return appendBinaryObject(enc, b, v)
+    case error:
+        return enc.appendString(b, v.Error()), nil
+    case json.Marshaler:
+        return appendBinaryValue(enc, b, jsonGeneric(v), depth+1)
+    case encoding.TextMarshaler:
+        text, err := v.MarshalText()
+        if err != nil {
+            return nil, err
+        }
+        return enc.appendString(b, string(text)), nil
+    }
+
+    return appendBinaryReflectValue(enc, b, reflect.ValueOf(data), depth)
+}
+
+func appendBinaryReflectValue(enc binaryAppender, b []byte, rv reflect.Value, depth int) ([]byte, error) {
+    var err error
+
+    switch rv.Kind() {
+    case reflect.Pointer, reflect.Interface:
+        if rv.IsNil() {
+            return enc.appendNil(b), nil
+        }
+        return appendBinaryValue(enc, b, rv.Elem().Interface(), depth+1)
+    case reflect.Bool:
+        return enc.appendBool(b, rv.Bool()), nil
+    case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+        return enc.appendInt(b, rv.Int()), nil
+    case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+        return enc.appendUint(b, rv.Uint()), nil
+    case reflect.Float32:
+        return enc.appendFloat32(b, float32(rv.Float())), nil
+    case reflect.Float64:
+        return enc.appendFloat64(b, rv.Float()), nil
+    case reflect.String:
+        return enc.appendString(b, rv.String()), nil
+    case reflect.Slice, reflect.Array:
+        if rv.Kind() == reflect.Slice {
+            if rv.IsNil() {
+                return enc.appendNil(b), nil
+            }
+            if rv.Type().Elem().Kind() == reflect.Uint8 {
+                return enc.appendBytes(b, rv.Bytes()), nil
+            }
+        }
+
+        b = enc.appendArrayHeader(b, rv.Len())
+        for i := range rv.Len() {
+            if b, err = appendBinaryValue(enc, b, rv.Index(i).Interface(), depth+1); err != nil {
+                return nil, err
+            }
+        }
+        return b, nil
+    case reflect.Map:
+        if rv.IsNil() {
+            return enc.appendNil(b), nil
+        }
+
+        // Keys are written as strings, sorted so that the output is deterministic.
+        keys := make([]string, 0, rv.Len())
+        values := make(map[string]reflect.Value, rv.Len())
+        for iter := rv.MapRange(); iter.Next(); {
+            key := binaryMapKey(iter.Key())
+            keys = append(keys, key)
+            values[key] = iter.Value()
+        }
+        slices.Sort(keys)
+
+        b = enc.appendMapHeader(b, len(keys))
+        for _, key := range keys {
+            b = enc.appendString(b, key)
+            if b, err = appendBinaryValue(enc, b, values[key].Interface(), depth+1); err != nil {
+                return nil, err
+            }
+        }
+        return b, nil
+    case reflect.Struct:
+        fields := binaryStructFieldsOf(rv.Type())
+
+        present := make([]reflect.Value, 0, len(fields))
+        names := make([]string, 0, len(fields))
+        for _, field := range fields {
+            fv, ok := binaryFieldByIndex(rv, field.index)
+            if !ok || field.omitEmpty && fv.IsZero() {
+                continue
+            }
+            present = append(present, fv)
+            names = append(names, field.name)
+        }
+
+        b = enc.appendMapHeader(b, len(present))
+        for i, fv := range present {
+            b = enc.appendString(b, names[i])
+            if b, err = appendBinaryValue(enc, b, fv.Interface(), depth+1); err != nil {
+                return nil, err
+            }
+        }
+        return b, nil
+    }
+
+    return nil, &ErrorUnsupportedBinaryType{dataType: rv.Type().String()}
+}
+
+func binaryMapKey(key reflect.Value) string {
+    if key.Kind() == reflect.String {
+        return key.String()
+    }
+    return fmt.Sprint(key.Interface())
+}
+
+// binaryFieldByIndex returns the field of the struct, or false if it is promoted through a nil embedded pointer.
+func binaryFieldByIndex(rv reflect.Value, index []int) (reflect.Value, bool) {
+    for i, x := range index {
+        if i > 0 && rv.Kind() == reflect.Pointer {
+            if rv.IsNil() {
+                return reflect.Value{}, false
+            }
+            rv = rv.Elem()
+        }
+        rv = rv.Field(x)
+    }
+    return rv, true
+}
+
+type binaryStructField struct {
+    name      string
+    index     []int
+    omitEmpty bool
+}
+
+var binaryStructFieldCache sync.Map
+
+// binaryStructFieldsOf returns the fields of the struct type that are written, following the json tags of the fields.
+// Embedded structs without a tag are inlined.
+func binaryStructFieldsOf(t reflect.Type) []binaryStructField {
+    if cached, ok := binaryStructFieldCache.Load(t); ok {
+        return cached.([]binaryStructField)
+    }
+
+    fields := binaryStructFields(t, nil)
+    binaryStructFieldCache.Store(t, fields)
+    return fields
+}
+
+func binaryStructFields(t reflect.Type, index []int) []binaryStructField {
+    var fields []binaryStructField
+
+    for i := range t.NumField() {
+        sf := t.Field(i)
+        tag := sf.Tag.Get("json")
+        if tag == "-" {
+            continue
+        }
+
+        name, options, _ := strings.Cut(tag, ",")
+        fieldIndex := append(slices.Clone(index), i)
+
+        if sf.Anonymous && name == "" {
+            ft := sf.Type
+            if ft.Kind() == reflect.Pointer {
+                ft = ft.Elem()
+            }
+            if ft.Kind() == reflect.Struct {
+                fields = append(fields, binaryStructFields(ft, fieldIndex)...)
+                continue
+            }
+        }
+
+        if !sf.IsExported() {
+            continue
+        }
+
+        if name == "" {
+            name = sf.Name
+        }
+
+        fields = append(fields, binaryStructField{
+            name:      name,
+            index:     fieldIndex,
+            omitEmpty: slices.Contains(strings.Split(options, ","), "omitempty"),
+        })
+    }
+
+    return fields
+}
@@ -0,0 +1,381 @@
+package ultralogger
+
+import (
+    "encoding/binary"
+    "fmt"
+    "math"
+    "time"
+)
+
+// CBOR major types, RFC 8949 section 3.1.
+const (
+    cborMajorUint   byte = 0
+    cborMajorNegInt byte = 1
+    cborMajorBytes  byte = 2
+    cborMajorText   byte = 3
+    cborMajorArray  byte = 4
+    cborMajorMap    byte = 5
+    cborMajorTag    byte = 6
+)
+
+const (
+    cborFalse     byte = 0xf4
+    cborTrue      byte = 0xf5
+    cborNull      byte = 0xf6
+    cborUndefined byte = 0xf7
+    cborFloat16   byte = 0xf9
+    cborFloat32   byte = 0xfa
+    cborFloat64   byte = 0xfb
+)
+
+const (
+    // cborTagEpochTime is the tag of an epoch-based date/time, RFC 8949 section 3.4.2.
+    cborTagEpochTime = 1
+    // cborTagDecimalFraction is the tag of a decimal fraction, RFC 8949 section 3.4.4. The content is an array of the
+    // base 10 exponent and the mantissa.
+    cborTagDecimalFraction = 4
+    // cborTagDuration is the tag of a duration, RFC 9581 section 4. The content is a map with the whole seconds at key
+    // 1 and the remaining nanoseconds at key -9, both with the sign of the duration.
+    cborTagDuration = 1002
+
+    cborDurationSeconds     = 1
+    cborDurationNanoseconds = -9
+)
+
+// cborAppender appends values encoded as CBOR (RFC 8949). Integers, lengths and tags use the shortest form.
+type cborAppender struct{}
+
+func cborHead(b []byte, major byte, n uint64) []byte {
+    m := major << 5
+
+    switch {
+    case n < 24:
+        return append(b, m|byte(n))
+    case n <= math.MaxUint8:
+        return append(b, m|24, byte(n))
+    case n <= math.MaxUint16:
+        return binary.BigEndian.AppendUint16(append(b, m|25), uint16(n))
+    case n <= math.MaxUint32:
+        return binary.BigEndian.AppendUint32(append(b, m|26), uint32(n))
+    default:
+        return binary.BigEndian.AppendUint64(append(b, m|27), n)
+    }
+}
+
+func (cborAppender) appendNil(b []byte) []byte {
+    return append(b, cborNull)
+}
+
+func (cborAppender) appendBool(b []byte, v bool) []byte {
+    if v {
+        return append(b, cborTrue)
+    }
+    return append(b, cborFalse)
+}
+
+func (cborAppender) appendInt(b []byte, v int64) []byte {
+    if v < 0 {
+        // -1-v, which can't overflow for negative v.
+        return cborHead(b, cborMajorNegInt, uint64(^v))
+    }
+    return cborHead(b, cborMajorUint, uint64(v))
+}
+
+func (cborAppender) appendUint(b []byte, v uint64) []byte {
+    return cborHead(b, cborMajorUint, v)
+}
+
+func (cborAppender) appendFloat32(b []byte, v float32) []byte {
+    return binary.BigEndian.AppendUint32(append(b, cborFloat32), math.Float32bits(v))
+}
+
+func (cborAppender) appendFloat64(b []byte, v float64) []byte {
+    return binary.BigEndian.AppendUint64(append(b, cborFloat64), math.Float64bits(v))
+}
+
+func (cborAppender) appendString(b []byte, v string) []byte {
+    return append(cborHead(b, cborMajorText, uint64(len(v))), v...)
+}
+
+func (cborAppender) appendBytes(b []byte, v []byte) []byte {
+    return append(cborHead(b, cborMajorBytes, uint64(len(v))), v...)
+}
+
+// appendTime appends the time with tag 1, as integer seconds if it has no fractional seconds, and as a decimal
+// fraction of seconds otherwise, which keeps the nanoseconds. Times whose decimal fraction doesn't fit in an int64
+// mantissa, more than about 292 years away from 1970, are appended as floating-point seconds.
+func (c cborAppender) appendTime(b []byte, v time.Time) []byte {
+    b = cborHead(b, cborMajorTag, cborTagEpochTime)
+    if v.Nanosecond() == 0 {
+        return c.appendInt(b, v.Unix())
+    }
+
+    nanoseconds, exponent, scale := int64(v.Nanosecond()), int64(-9), int64(1e9)
+    for nanoseconds%10 == 0 {
+        nanoseconds /= 10
+        exponent++
+        scale /= 10
+    }
+
+    seconds := v.Unix()
+    if seconds > (math.MaxInt64-nanoseconds)/scale || seconds < math.MinInt64/scale {
+        return c.appendFloat64(b, float64(seconds)+float64(v.Nanosecond())/1e9)
+    }
+
+    b = cborHead(b, cborMajorTag, cborTagDecimalFraction)
+    b = c.appendArrayHeader(b, 2)
+    b = c.appendInt(b, exponent)
+    return c.appendInt(b, seconds*scale+nanoseconds)
+}
+
+func (c cborAppender) appendDuration(b []byte, v time.Duration) []byte {
+    seconds := int64(v / time.Second)
+    nanoseconds := int64(v % time.Second)
+
+    b = cborHead(b, cborMajorTag, cborTagDuration)
+    if nanoseconds == 0 {
+        b = c.appendMapHeader(b, 1)
+    } else {
+        b = c.appendMapHeader(b, 2)
+    }
+
+    b = c.appendInt(b, cborDurationSeconds)
+    b = c.appendInt(b, seconds)
+    if nanoseconds != 0 {
+        b = c.appendInt(b, cborDurationNanoseconds)
+        b = c.appendInt(b, nanoseconds)
+    }
+    return b
+}
+
+func (cborAppender) appendArrayHeader(b []byte, n int) []byte {
+    return cborHead(b, cborMajorArray, uint64(n))
+}
+
+func (cborAppender) appendMapHeader(b []byte, n int) []byte {
+    return cborHead(b, cborMajorMap, uint64(n))
+}
+
+// cborDecoder decodes a single CBOR data item. Integers are decoded as int64, or as uint64 if they don't fit, maps as
+// map[string]any with non-string keys formatted with %v, tag 1 as time.Time and tag 1002 as time.Duration. The content
+// of other tags is returned without the tag, e.g. a decimal fraction outside of tag 1 is decoded as the array of its
+// exponent and mantissa. Indefinite-length items are not supported.
+type cborDecoder struct {
+    b   []byte
+    pos int
+}
+
+// decodeCBOR decodes the data item in b, which must contain exactly one data item.
+func decodeCBOR(b []byte) (any, error) {
+    d := &cborDecoder{b: b}
+
+    v, err := d.decode(0)
+    if err != nil {
+        return nil, err
+    }
+    if d.pos != len(d.b) {
+        return nil, d.errorf("%d trailing bytes", len(d.b)-d.pos)
+    }
+    return v, nil
+}
+
+func (d *cborDecoder) errorf(format string, args ...any) error {
+    return &ErrorInvalidBinaryData{format: OutputFormatCBOR, reason: fmt.Sprintf(format, args...)}
+}
+
+func (d *cborDecoder) next(n uint64) ([]byte, error) {
+    if n > uint64(len(d.b)-d.pos) {
+        return nil, d.errorf("unexpected end of data")
+    }
+    b := d.b[d.pos : d.pos+int(n)]
+    d.pos += int(n)
+    return b, nil
+}
+
+// head reads the initial byte and the argument of a data item.
+func (d *cborDecoder) head() (byte, byte, uint64, error) {
+    ib, err := d.next(1)
+    if err != nil {
+        return 0, 0, 0, err
+    }
+
+    major, info := ib[0]>>5, ib[0]&0x1f
+    switch {
+    case info < 24:
+        return major, info, uint64(info), nil
+    case info <= 27:
+        arg, err := d.next(1 << (info - 24))
+        if err != nil {
+            return 0, 0, 0, err
+        }
+        var n uint64
+        for _, c := range arg {
+            n = n<<8 | uint64(c)
+        }
+        return major, info, n, nil
+    case info == 31:
+        return 0, 0, 0, d.errorf("indefinite-length items are not supported")
+    default:
+        return 0, 0, 0, d.errorf("reserved additional information %d", info)
+    }
+}
+
+func (d *cborDecoder) decode(depth int) (any, error) {
+    if depth > maxBinaryDepth {
+        return nil, ErrorBinaryDepthExceeded
+    }
+
+    major, info, n, err := d.head()
+    if err != nil {
+        return nil, err
+    }
+
+    switch major {
+    case cborMajorUint:
+        if n > math.MaxInt64 {
+            return n, nil
+        }
+        return int64(n), nil
+    case cborMajorNegInt:
+        if n > math.MaxInt64 {
+            return nil, d.errorf("negative integer out of range")
+        }
+        return -1 - int64(n), nil
+    case cborMajorBytes:
+        b, err := d.next(n)
+        if err != nil {
+            return nil, err
+        }
+        return append([]byte(nil), b...), nil
+    case cborMajorText:
+        b, err := d.next(n)
+        if err != nil {
+            return nil, err
+        }
+        return string(b), nil
+    case cborMajorArray:
+        if n > uint64(len(d.b)-d.pos) {
+            return nil, d.errorf("unexpected end of data")
+        }
+        items := make([]any, 0, n)
+        for range n {
+            item, err := d.decode(depth + 1)
+            if err != nil {
+                return nil, err
+            }
+            items = append(items, item)
+        }
+        return items, nil
+    case cborMajorMap:
+        if n > uint64(len(d.b)-d.pos) {
+            return nil, d.errorf("unexpected end of data")
+        }
+        m := make(map[string]any, n)
+        for range n {
+            key, err := d.decode(depth + 1)
+            if err != nil {
+                return nil, err
+            }
+            value, err := d.decode(depth + 1)
+            if err != nil {
+                return nil, err
+            }
+            m[binaryDecodedKey(key)] = value
+        }
+        return m, nil
+    case cborMajorTag:
+        content, err := d.decode(depth + 1)
+        if err != nil {
+            return nil, err
+        }
+        switch n {
+        case cborTagEpochTime:
+            return cborEpochTime(content)
+        case cborTagDuration:
+            return cborDuration(content)
+        }
+        return content, nil
+    }
+
+    switch info {
+    case cborFalse & 0x1f:
+        return false, nil
+    case cborTrue & 0x1f:
+        return true, nil
+    case cborNull & 0x1f, cborUndefined & 0x1f:
+        return nil, nil
+    case cborFloat16 & 0x1f:
+        return float16ToFloat64(uint16(n)), nil
+    case cborFloat32 & 0x1f:
+        return float64(math.Float32frombits(uint32(n))), nil
+    case cborFloat64 & 0x1f:
+        return math.Float64frombits(n), nil
+    }
+
+    return nil, d.errorf("unsupported simple value %d", n)
+}
+
+func cborEpochTime(content any) (any, error) {
+    switch v := content.(type) {
+    case int64:
+        return time.Unix(v, 0).UTC(), nil
+    case float64:
+        seconds := math.Floor(v)
+        return time.Unix(int64(seconds), int64(math.Round((v-seconds)*1e9))).UTC(), nil
+    case []any:
+        // A decimal fraction of seconds, with at most nanosecond precision.
+        if len(v) == 2 {
+            exponent, exponentOk := v[0].(int64)
+            mantissa, mantissaOk := v[1].(int64)
+            if exponentOk && mantissaOk && exponent >= -9 && exponent <= 0 {
+                scale := int64(math.Pow10(int(-exponent)))
+                seconds, fraction := mantissa/scale, mantissa%scale
+                if fraction < 0 {
+                    seconds, fraction = seconds-1, fraction+scale
+                }
+                return time.Unix(seconds, fraction*int64(math.Pow10(int(9+exponent)))).UTC(), nil
+            }
+        }
+    }
+    return nil, &ErrorInvalidBinaryData{format: OutputFormatCBOR, reason: fmt.Sprintf("invalid epoch time %v", content)}
+}
+
+func cborDuration(content any) (any, error) {
+    m, ok := content.(map[string]any)
+    if !ok {
+        return nil, &ErrorInvalidBinaryData{format: OutputFormatCBOR, reason: fmt.Sprintf("invalid duration %v", content)}
+    }
+
+    seconds, _ := m[fmt.Sprint(cborDurationSeconds)].(int64)
+    nanoseconds, _ := m[fmt.Sprint(cborDurationNanoseconds)].(int64)
+    return time.Duration(seconds)*time.Second + time.Duration(nanoseconds), nil
+}
+
+// float16ToFloat64 converts an IEEE 754 half-precision float.
+func float16ToFloat64(h uint16) float64 {
+    sign := 1.0
+    if h&0x8000 != 0 {
+        sign = -1
+    }
+    exponent := int(h>>10) & 0x1f
+    mantissa := float64(h & 0x3ff)
+
+    switch exponent {
+    case 0:
+        return sign * math.Ldexp(mantissa, -24)
+    case 0x1f:
+        if mantissa == 0 {
+            return math.Inf(int(sign))
+        }
+        return math.NaN()
+    }
+    return sign * math.Ldexp(mantissa+1024, exponent-25)
+}
+
+// binaryDecodedKey returns the decoded map key as a string.
+func binaryDecodedKey(key any) string {
+    if s, ok := key.(string); ok {
+        return s
+    }
+    return fmt.Sprint(key)
+}
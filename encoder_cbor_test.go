@@ -0,0 +1,137 @@
+package ultralogger
+
+import (
+    "encoding/hex"
+    "errors"
+    "reflect"
+    "testing"
+    "time"
+)
+
+func TestCBORAppender(t *testing.T) {
+    // The expected encodings are from RFC 8949, Appendix A.
+    tests := []struct {
+        name string
+        data any
+        want string
+    }{
+        {name: "0", data: 0, want: "00"},
+        {name: "23", data: 23, want: "17"},
+        {name: "24", data: 24, want: "1818"},
+        {name: "100", data: 100, want: "1864"},
+        {name: "1000", data: 1000, want: "1903e8"},
+        {name: "1000000", data: 1000000, want: "1a000f4240"},
+        {name: "1000000000000", data: int64(1000000000000), want: "1b000000e8d4a51000"},
+        {name: "Max uint64", data: uint64(18446744073709551615), want: "1bffffffffffffffff"},
+        {name: "-1", data: -1, want: "20"},
+        {name: "-100", data: -100, want: "3863"},
+        {name: "-1000", data: -1000, want: "3903e7"},
+        {name: "1.1", data: 1.1, want: "fb3ff199999999999a"},
+        {name: "Float32", data: float32(100000.0), want: "fa47c35000"},
+        {name: "False", data: false, want: "f4"},
+        {name: "True", data: true, want: "f5"},
+        {name: "Null", data: nil, want: "f6"},
+        {name: "Empty string", data: "", want: "60"},
+        {name: "String", data: "IETF", want: "6449455446"},
+        {name: "Unicode string", data: "ü", want: "62c3bc"},
+        {name: "Bytes", data: []byte{1, 2, 3, 4}, want: "4401020304"},
+        {name: "Array", data: []int{1, 2, 3}, want: "83010203"},
+        {name: "Nested array", data: []any{1, []int{2, 3}, []int{4, 5}}, want: "8301820203820405"},
+        {name: "Map", data: map[string]any{"a": 1, "b": []int{2, 3}}, want: "a26161016162820203"},
+        {name: "Epoch time", data: time.Unix(1363896240, 0), want: "c11a514b67b0"},
+        {name: "Epoch time fraction", data: time.Unix(1363896240, 500000000), want: "c1c482201b000000032cf20ce5"},
+        {name: "Epoch time nanoseconds", data: time.Unix(1, 1), want: "c1c482281a3b9aca01"},
+        {name: "Epoch time before 1970", data: time.Unix(-2, 500000000), want: "c1c482202e"},
+        {name: "Duration", data: 90 * time.Second, want: "d903eaa101185a"},
+        {name: "Duration fraction", data: 1500 * time.Millisecond, want: "d903eaa20101281a1dcd6500"},
+        {name: "Error", data: errors.New("boom"), want: "64626f6f6d"},
+        {name: "Struct", data: struct {
+            A int    `json:"a"`
+            B string `json:"b,omitempty"`
+            C bool   `json:"-"`
+        }{A: 1}, want: "a1616101"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            b, err := appendBinaryValue(cborAppender{}, nil, tt.data, 0)
+            if err != nil {
+                t.Fatalf("appendBinaryValue() error = %v", err)
+            }
+
+            if got := hex.EncodeToString(b); got != tt.want {
+                t.Errorf("appendBinaryValue() = %s, want %s", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestDecodeCBOR(t *testing.T) {
+    tests := []struct {
+        name    string
+        data    string
+        want    any
+        wantErr bool
+    }{
+        {name: "Uint", data: "1903e8", want: int64(1000)},
+        {name: "Large uint", data: "1bffffffffffffffff", want: uint64(18446744073709551615)},
+        {name: "Negative int", data: "3903e7", want: int64(-1000)},
+        {name: "Half float", data: "f93c00", want: 1.0},
+        {name: "Float32", data: "fa47c35000", want: 100000.0},
+        {name: "Float64", data: "fb3ff199999999999a", want: 1.1},
+        {name: "Undefined", data: "f7", want: nil},
+        {name: "String", data: "6449455446", want: "IETF"},
+        {name: "Bytes", data: "4401020304", want: []byte{1, 2, 3, 4}},
+        {name: "Map with int keys", data: "a201020304", want: map[string]any{"1": int64(2), "3": int64(4)}},
+        {name: "Epoch time", data: "c11a514b67b0", want: time.Unix(1363896240, 0).UTC()},
+        {name: "Epoch time float", data: "c1fb41d452d9ec200000", want: time.Unix(1363896240, 500000000).UTC()},
+        {name: "Epoch time fraction", data: "c1c482201b000000032cf20ce5", want: time.Unix(1363896240, 500000000).UTC()},
+        {name: "Epoch time before 1970", data: "c1c482202e", want: time.Unix(-2, 500000000).UTC()},
+        {name: "Epoch time invalid fraction", data: "c1c482010a", wantErr: true},
+        {name: "Decimal fraction", data: "c48221196ab3", want: []any{int64(-2), int64(27315)}},
+        {name: "Duration", data: "d903eaa20101281a1dcd6500", want: 1500 * time.Millisecond},
+        {name: "Unknown tag", data: "d82076687474703a2f2f7777772e6578616d706c652e636f6d", want: "http://www.example.com"},
+        {name: "Truncated", data: "1903", wantErr: true},
+        {name: "Array", data: "83010203", want: []any{int64(1), int64(2), int64(3)}},
+        {name: "Array longer than data", data: "9a7fffffff", wantErr: true},
+        {name: "Trailing bytes", data: "0000", wantErr: true},
+        {name: "Indefinite length", data: "9f01ff", wantErr: true},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            b, _ := hex.DecodeString(tt.data)
+
+            got, err := decodeCBOR(b)
+            if (err != nil) != tt.wantErr {
+                t.Fatalf("decodeCBOR() error = %v, wantErr %v", err, tt.wantErr)
+            }
+
+            if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("decodeCBOR() = %#v, want %#v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestCBORTimeRoundTrip(t *testing.T) {
+    tests := []time.Time{
+        time.Date(2024, 11, 7, 19, 30, 0, 123456789, time.UTC),
+        time.Date(2024, 11, 7, 19, 30, 0, 1, time.UTC),
+        time.Date(1969, 12, 31, 23, 59, 59, 999999999, time.UTC),
+    }
+    for _, want := range tests {
+        t.Run(want.Format(time.RFC3339Nano), func(t *testing.T) {
+            b, err := appendBinaryValue(cborAppender{}, nil, want, 0)
+            if err != nil {
+                t.Fatalf("appendBinaryValue() error = %v", err)
+            }
+
+            got, err := decodeCBOR(b)
+            if err != nil {
+                t.Fatalf("decodeCBOR() error = %v", err)
+            }
+            if got != want {
+                t.Errorf("decodeCBOR() = %v, want %v", got, want)
+            }
+        })
+    }
+}
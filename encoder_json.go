@@ -0,0 +1,335 @@
+package ultralogger
+
+import (
+    "encoding/json"
+    "maps"
+    "math"
+    "slices"
+    "strconv"
+    "sync"
+    "time"
+    "unicode/utf8"
+)
+
+// maxPooledJSONBufferSize is the largest buffer that is returned to the pool. Larger buffers, e.g. from a one-off
+// huge log line, are left to the garbage collector so the pool doesn't pin their memory.
+const maxPooledJSONBufferSize = 64 << 10
+
+var jsonEncoderPool = sync.Pool{
+    New: func() any {
+        return &jsonEncoder{buf: make([]byte, 0, 1024)}
+    },
+}
+
+// jsonEncoder is a streaming JSON encoder with fast paths for the types that are common in log lines. Values of any
+// other type are encoded with encoding/json, so the output is identical to json.Marshal for every value.
+//
+// Encoders are pooled, use getJSONEncoder and putJSONEncoder.
+type jsonEncoder struct {
+    buf []byte
+    // results is scratch space for the field results of the line that is being encoded.
+    results []FieldResult
+
+    // timeEncoding, timeLocation and durationEncoding are the encodings of the times and durations of the line.
+    timeEncoding     TimeEncoding
+    timeLocation     *time.Location
+    durationEncoding DurationEncoding
+}
+
+func getJSONEncoder() *jsonEncoder {
+    enc := jsonEncoderPool.Get().(*jsonEncoder)
+    enc.buf = enc.buf[:0]
+    enc.results = enc.results[:0]
+    enc.timeEncoding = TimeEncodingRFC3339Nano
+    enc.timeLocation = nil
+    enc.durationEncoding = DurationEncodingNanoseconds
+    return enc
+}
+
+func putJSONEncoder(enc *jsonEncoder) {
+    if cap(enc.buf) > maxPooledJSONBufferSize {
+        return
+    }
+
+    // Drop the references to the data of the line, so that it can be collected.
+    clear(enc.results)
+    jsonEncoderPool.Put(enc)
+}
+
+// line returns a copy of the encoded bytes. The copy has room for a trailing newline, so that appending it when the
+// line is written doesn't copy the line again.
+func (enc *jsonEncoder) line() []byte {
+    b := make([]byte, len(enc.buf), len(enc.buf)+1)
+    copy(b, enc.buf)
+    return b
+}
+
+// encodeObject encodes the results as a JSON object, keeping the order of the results.
+func (enc *jsonEncoder) encodeObject(results []FieldResult) error {
+    enc.buf = append(enc.buf, '{')
+
+    for i, result := range results {
+        if i > 0 {
+            enc.buf = append(enc.buf, ',')
+        }
+
+        enc.buf = appendJSONString(enc.buf, result.Name)
+        enc.buf = append(enc.buf, ':')
+
+        if err := enc.encodeValue(result.Data); err != nil {
+            return err
+        }
+    }
+
+    enc.buf = append(enc.buf, '}')
+    return nil
+}
+
+// logObjectMarshaler is implemented by the entries of the built-in fields that carry times or durations, so that they
+// are encoded with the time and duration encodings of the line rather than with their json.Marshaler encoding.
+type logObjectMarshaler interface {
+    logObject() structObject
+}
+
+// encodeValue encodes the value. Only exact types take the fast paths, so named types, e.g. ones that implement
+// json.Marshaler, keep their own encoding.
+func (enc *jsonEncoder) encodeValue(data any) error {
+    switch v := data.(type) {
+    case nil:
+        enc.buf = append(enc.buf, "null"...)
+    case string:
+        enc.buf = appendJSONString(enc.buf, v)
+    case bool:
+        enc.buf = strconv.AppendBool(enc.buf, v)
+    case int:
+        enc.buf = strconv.AppendInt(enc.buf, int64(v), 10)
+    case int8:
+        enc.buf = strconv.AppendInt(enc.buf, int64(v), 10)
+    case int16:
+        enc.buf = strconv.AppendInt(enc.buf, int64(v), 10)
+    case int32:
+        enc.buf = strconv.AppendInt(enc.buf, int64(v), 10)
+    case int64:
+        enc.buf = strconv.AppendInt(enc.buf, v, 10)
+    case uint:
+        enc.buf = strconv.AppendUint(enc.buf, uint64(v), 10)
+    case uint8:
+        enc.buf = strconv.AppendUint(enc.buf, uint64(v), 10)
+    case uint16:
+        enc.buf = strconv.AppendUint(enc.buf, uint64(v), 10)
+    case uint32:
+        enc.buf = strconv.AppendUint(enc.buf, uint64(v), 10)
+    case uint64:
+        enc.buf = strconv.AppendUint(enc.buf, v, 10)
+    case float32:
+        return enc.encodeFloat(float64(v), 32)
+    case float64:
+        return enc.encodeFloat(v, 64)
+    case time.Duration:
+        return enc.encodeDuration(v)
+    case time.Time:
+        return enc.encodeTime(v)
+    case structObject:
+        return enc.encodeObject(v)
+    case logObjectMarshaler:
+        return enc.encodeObject(v.logObject())
+    case []string:
+        if v == nil {
+            enc.buf = append(enc.buf, "null"...)
+            return nil
+        }
+        enc.buf = append(enc.buf, '[')
+        for i, s := range v {
+            if i > 0 {
+                enc.buf = append(enc.buf, ',')
+            }
+            enc.buf = appendJSONString(enc.buf, s)
+        }
+        enc.buf = append(enc.buf, ']')
+    case []any:
+        if v == nil {
+            enc.buf = append(enc.buf, "null"...)
+            return nil
+        }
+        enc.buf = append(enc.buf, '[')
+        for i, item := range v {
+            if i > 0 {
+                enc.buf = append(enc.buf, ',')
+            }
+            if err := enc.encodeValue(item); err != nil {
+                return err
+            }
+        }
+        enc.buf = append(enc.buf, ']')
+    case map[string]any:
+        if v == nil {
+            enc.buf = append(enc.buf, "null"...)
+            return nil
+        }
+        // The keys are sorted like encoding/json sorts them, and the values recurse so that the times and durations
+        // in the map use the encodings of the line.
+        enc.buf = append(enc.buf, '{')
+        for i, key := range slices.Sorted(maps.Keys(v)) {
+            if i > 0 {
+                enc.buf = append(enc.buf, ',')
+            }
+            enc.buf = appendJSONString(enc.buf, key)
+            enc.buf = append(enc.buf, ':')
+            if err := enc.encodeValue(v[key]); err != nil {
+                return err
+            }
+        }
+        enc.buf = append(enc.buf, '}')
+    default:
+        return enc.encodeFallback(v)
+    }
+
+    return nil
+}
+
+// encodeTime encodes the time with the time encoding and location of the encoder.
+func (enc *jsonEncoder) encodeTime(t time.Time) error {
+    if enc.timeLocation != nil {
+        t = t.In(enc.timeLocation)
+    }
+
+    layout := time.RFC3339Nano
+    switch enc.timeEncoding {
+    case TimeEncodingUnix:
+        enc.buf = strconv.AppendInt(enc.buf, t.Unix(), 10)
+        return nil
+    case TimeEncodingUnixMilli:
+        enc.buf = strconv.AppendInt(enc.buf, t.UnixMilli(), 10)
+        return nil
+    case TimeEncodingUnixNano:
+        enc.buf = strconv.AppendInt(enc.buf, t.UnixNano(), 10)
+        return nil
+    case TimeEncodingRFC3339:
+        layout = time.RFC3339
+    }
+
+    // Years outside of [0,9999] can't be encoded as RFC 3339, let encoding/json report the error.
+    if y := t.Year(); y < 0 || y > 9999 {
+        return enc.encodeFallback(t)
+    }
+    enc.buf = append(enc.buf, '"')
+    enc.buf = t.AppendFormat(enc.buf, layout)
+    enc.buf = append(enc.buf, '"')
+    return nil
+}
+
+// encodeDuration encodes the duration with the duration encoding of the encoder. By default, a time.Duration is
+// encoded as its integer nanoseconds, the same as encoding/json does.
+func (enc *jsonEncoder) encodeDuration(d time.Duration) error {
+    switch enc.durationEncoding {
+    case DurationEncodingMilliseconds:
+        enc.buf = strconv.AppendInt(enc.buf, d.Milliseconds(), 10)
+    case DurationEncodingSeconds:
+        // A single division is correctly rounded, unlike Seconds, e.g. 1.5007s is written as 1.5007.
+        return enc.encodeFloat(float64(d)/float64(time.Second), 64)
+    case DurationEncodingString:
+        enc.buf = appendJSONString(enc.buf, d.String())
+    default:
+        enc.buf = strconv.AppendInt(enc.buf, int64(d), 10)
+    }
+    return nil
+}
+
+// encodeFloat encodes the float the same way encoding/json does.
+func (enc *jsonEncoder) encodeFloat(f float64, bits int) error {
+    if math.IsInf(f, 0) || math.IsNaN(f) {
+        return enc.encodeFallback(f)
+    }
+
+    format := byte('f')
+    if abs := math.Abs(f); abs != 0 {
+        if bits == 64 && (abs < 1e-6 || abs >= 1e21) || bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+            format = 'e'
+        }
+    }
+
+    enc.buf = strconv.AppendFloat(enc.buf, f, format, -1, bits)
+
+    // Clean up e-09 to e-9.
+    if format == 'e' {
+        n := len(enc.buf)
+        if n >= 4 && enc.buf[n-4] == 'e' && enc.buf[n-3] == '-' && enc.buf[n-2] == '0' {
+            enc.buf[n-2] = enc.buf[n-1]
+            enc.buf = enc.buf[:n-1]
+        }
+    }
+
+    return nil
+}
+
+func (enc *jsonEncoder) encodeFallback(data any) error {
+    jBytes, err := json.Marshal(data)
+    if err != nil {
+        return err
+    }
+
+    enc.buf = append(enc.buf, jBytes...)
+    return nil
+}
+
+const jsonHex = "0123456789abcdef"
+
+// appendJSONString appends the quoted string to the buffer, escaped the same way encoding/json escapes it, including
+// the HTML characters <, > and &.
+func appendJSONString(buf []byte, s string) []byte {
+    buf = append(buf, '"')
+
+    start := 0
+    for i := 0; i < len(s); {
+        if c := s[i]; c < utf8.RuneSelf {
+            if c >= 0x20 && c != '"' && c != '\\' && c != '<' && c != '>' && c != '&' {
+                i++
+                continue
+            }
+
+            buf = append(buf, s[start:i]...)
+            switch c {
+            case '"', '\\':
+                buf = append(buf, '\\', c)
+            case '\n':
+                buf = append(buf, '\\', 'n')
+            case '\r':
+                buf = append(buf, '\\', 'r')
+            case '\t':
+                buf = append(buf, '\\', 't')
+            case '\b':
+                buf = append(buf, '\\', 'b')
+            case '\f':
+                buf = append(buf, '\\', 'f')
+            default:
+                buf = append(buf, '\\', 'u', '0', '0', jsonHex[c>>4], jsonHex[c&0xf])
+            }
+            i++
+            start = i
+            continue
+        }
+
+        r, size := utf8.DecodeRuneInString(s[i:])
+        if r == utf8.RuneError && size == 1 {
+            buf = append(buf, s[start:i]...)
+            buf = utf8.AppendRune(buf, utf8.RuneError)
+            i += size
+            start = i
+            continue
+        }
+
+        // U+2028 and U+2029 are valid JSON, but break JavaScript, encoding/json escapes them.
+        if r == '\u2028' || r == '\u2029' {
+            buf = append(buf, s[start:i]...)
+            buf = append(buf, '\\', 'u', '2', '0', '2', jsonHex[r&0xf])
+            i += size
+            start = i
+            continue
+        }
+
+        i += size
+    }
+
+    buf = append(buf, s[start:]...)
+    return append(buf, '"')
+}
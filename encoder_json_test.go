@@ -0,0 +1,134 @@
+package ultralogger
+
+import (
+    "encoding/json"
+    "errors"
+    "math"
+    "testing"
+    "time"
+)
+
+type jsonEncoderMarshaler string
+
+func (m jsonEncoderMarshaler) MarshalJSON() ([]byte, error) {
+    return []byte(`"marshaled"`), nil
+}
+
+func TestJSONEncoder_encodeValue(t *testing.T) {
+    type person struct {
+        Name string `json:"name"`
+        Age  int    `json:"age"`
+    }
+
+    tests := []struct {
+        name string
+        data any
+    }{
+        {name: "Nil", data: nil},
+        {name: "String", data: "hello"},
+        {name: "Empty string", data: ""},
+        {name: "Escaped string", data: "quote\" backslash\\ newline\n tab\t cr\r bs\b ff\f nul\x00 esc\x1b"},
+        {name: "HTML string", data: "<script>&</script>"},
+        {name: "Unicode string", data: "héllo, 世界 🌍"},
+        {name: "Line separators", data: "a\u2028b\u2029c"},
+        {name: "Invalid UTF-8", data: "a\xffb\xc3"},
+        {name: "True", data: true},
+        {name: "False", data: false},
+        {name: "Int", data: -42},
+        {name: "Int8", data: int8(math.MinInt8)},
+        {name: "Int16", data: int16(math.MaxInt16)},
+        {name: "Int32", data: int32(math.MinInt32)},
+        {name: "Int64", data: int64(math.MaxInt64)},
+        {name: "Uint", data: uint(42)},
+        {name: "Uint8", data: uint8(math.MaxUint8)},
+        {name: "Uint16", data: uint16(math.MaxUint16)},
+        {name: "Uint32", data: uint32(math.MaxUint32)},
+        {name: "Uint64", data: uint64(math.MaxUint64)},
+        {name: "Float64", data: 3.14159},
+        {name: "Float64 zero", data: 0.0},
+        {name: "Float64 small", data: 1e-7},
+        {name: "Float64 large", data: 1e21},
+        {name: "Float64 negative", data: -2.5e-9},
+        {name: "Float32", data: float32(3.14159)},
+        {name: "Float32 small", data: float32(1e-7)},
+        {name: "Duration", data: 1500 * time.Millisecond},
+        {name: "Time", data: time.Date(2024, 11, 7, 19, 30, 0, 123456789, time.UTC)},
+        {name: "Time with zone", data: time.Date(2024, 11, 7, 19, 30, 0, 0, time.FixedZone("CET", 3600))},
+        {name: "String slice", data: []string{"a", "<b>"}},
+        {name: "Nil string slice", data: []string(nil)},
+        {name: "Any slice", data: []any{"a", 1, 2.5, nil, []any{true}}},
+        {name: "Nil any slice", data: []any(nil)},
+        {name: "Map", data: map[string]any{"b": 1, "a": "x"}},
+        {name: "Nested map", data: map[string]any{"b": map[string]any{"d": []any{1}, "c": nil}, "a": 2.5}},
+        {name: "Escaped map keys", data: map[string]any{"<b>": 1, "a\n": 2, "é": 3, "B": 4}},
+        {name: "Nil map", data: map[string]any(nil)},
+        {name: "Struct", data: person{Name: "john", Age: 42}},
+        {name: "Marshaler", data: jsonEncoderMarshaler("plain")},
+        {name: "Error", data: errors.New("boom")},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            want, err := json.Marshal(tt.data)
+            if err != nil {
+                t.Fatalf("json.Marshal() error = %v", err)
+            }
+
+            enc := getJSONEncoder()
+            defer putJSONEncoder(enc)
+
+            if err := enc.encodeValue(tt.data); err != nil {
+                t.Fatalf("encodeValue() error = %v", err)
+            }
+
+            if string(enc.buf) != string(want) {
+                t.Errorf("encodeValue() = %s, want %s", enc.buf, want)
+            }
+        })
+    }
+}
+
+func TestJSONEncoder_encodeValueError(t *testing.T) {
+    tests := []struct {
+        name string
+        data any
+    }{
+        {name: "NaN", data: math.NaN()},
+        {name: "Inf", data: math.Inf(1)},
+        {name: "Year out of range", data: time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC)},
+        {name: "Channel", data: make(chan int)},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            enc := getJSONEncoder()
+            defer putJSONEncoder(enc)
+
+            if err := enc.encodeValue(tt.data); err == nil {
+                t.Errorf("encodeValue() = %s, want error", enc.buf)
+            }
+        })
+    }
+}
+
+func TestJSONEncoder_line(t *testing.T) {
+    enc := getJSONEncoder()
+    defer putJSONEncoder(enc)
+
+    if err := enc.encodeObject([]FieldResult{{Name: "a", Data: 1}}); err != nil {
+        t.Fatalf("encodeObject() error = %v", err)
+    }
+
+    line := enc.line()
+    if string(line) != `{"a":1}` {
+        t.Errorf("line() = %s, want %s", line, `{"a":1}`)
+    }
+
+    if cap(line) <= len(line) {
+        t.Errorf("line() cap = %d, want room for a newline", cap(line))
+    }
+
+    // The line must not share memory with the pooled buffer.
+    enc.buf[1] = 'x'
+    if string(line) != `{"a":1}` {
+        t.Errorf("line() = %s, changed after the buffer was reused", line)
+    }
+}
@@ -0,0 +1,404 @@
+package ultralogger
+
+import (
+    "encoding/binary"
+    "fmt"
+    "math"
+    "time"
+)
+
+const (
+    msgpackNil      byte = 0xc0
+    msgpackFalse    byte = 0xc2
+    msgpackTrue     byte = 0xc3
+    msgpackBin8     byte = 0xc4
+    msgpackBin16    byte = 0xc5
+    msgpackBin32    byte = 0xc6
+    msgpackExt8     byte = 0xc7
+    msgpackExt16    byte = 0xc8
+    msgpackExt32    byte = 0xc9
+    msgpackFloat32  byte = 0xca
+    msgpackFloat64  byte = 0xcb
+    msgpackUint8    byte = 0xcc
+    msgpackUint16   byte = 0xcd
+    msgpackUint32   byte = 0xce
+    msgpackUint64   byte = 0xcf
+    msgpackInt8     byte = 0xd0
+    msgpackInt16    byte = 0xd1
+    msgpackInt32    byte = 0xd2
+    msgpackInt64    byte = 0xd3
+    msgpackFixExt1  byte = 0xd4
+    msgpackFixExt2  byte = 0xd5
+    msgpackFixExt4  byte = 0xd6
+    msgpackFixExt8  byte = 0xd7
+    msgpackFixExt16 byte = 0xd8
+    msgpackStr8     byte = 0xd9
+    msgpackStr16    byte = 0xda
+    msgpackStr32    byte = 0xdb
+    msgpackArray16  byte = 0xdc
+    msgpackArray32  byte = 0xdd
+    msgpackMap16    byte = 0xde
+    msgpackMap32    byte = 0xdf
+)
+
+const (
+    // msgpackExtTimestamp is the extension type -1, the timestamp extension of the MessagePack specification.
+    msgpackExtTimestamp byte = 0xff
+    // msgpackExtDuration is the application extension type 1 of a time.Duration, written as a fixext 8 with the
+    // big-endian int64 nanoseconds.
+    msgpackExtDuration byte = 0x01
+)
+
+// msgpackAppender appends values encoded as MessagePack. Integers, strings, arrays and maps use the shortest form.
+type msgpackAppender struct{}
+
+func (msgpackAppender) appendNil(b []byte) []byte {
+    return append(b, msgpackNil)
+}
+
+func (msgpackAppender) appendBool(b []byte, v bool) []byte {
+    if v {
+        return append(b, msgpackTrue)
+    }
+    return append(b, msgpackFalse)
+}
+
+func (m msgpackAppender) appendInt(b []byte, v int64) []byte {
+    switch {
+    case v >= 0:
+        return m.appendUint(b, uint64(v))
+    case v >= -32:
+        return append(b, byte(v))
+    case v >= math.MinInt8:
+        return append(b, msgpackInt8, byte(v))
+    case v >= math.MinInt16:
+        return binary.BigEndian.AppendUint16(append(b, msgpackInt16), uint16(v))
+    case v >= math.MinInt32:
+        return binary.BigEndian.AppendUint32(append(b, msgpackInt32), uint32(v))
+    default:
+        return binary.BigEndian.AppendUint64(append(b, msgpackInt64), uint64(v))
+    }
+}
+
+func (msgpackAppender) appendUint(b []byte, v uint64) []byte {
+    switch {
+    case v <= 0x7f:
+        return append(b, byte(v))
+    case v <= math.MaxUint8:
+        return append(b, msgpackUint8, byte(v))
+    case v <= math.MaxUint16:
+        return binary.BigEndian.AppendUint16(append(b, msgpackUint16), uint16(v))
+    case v <= math.MaxUint32:
+        return binary.BigEndian.AppendUint32(append(b, msgpackUint32), uint32(v))
+    default:
+        return binary.BigEndian.AppendUint64(append(b, msgpackUint64), v)
+    }
+}
+
+func (msgpackAppender) appendFloat32(b []byte, v float32) []byte {
+    return binary.BigEndian.AppendUint32(append(b, msgpackFloat32), math.Float32bits(v))
+}
+
+func (msgpackAppender) appendFloat64(b []byte, v float64) []byte {
+    return binary.BigEndian.AppendUint64(append(b, msgpackFloat64), math.Float64bits(v))
+}
+
+func (msgpackAppender) appendString(b []byte, v string) []byte {
+    n := len(v)
+    switch {
+    case n < 32:
+        b = append(b, 0xa0|byte(n))
+    case n <= math.MaxUint8:
+        b = append(b, msgpackStr8, byte(n))
+    case n <= math.MaxUint16:
+        b = binary.BigEndian.AppendUint16(append(b, msgpackStr16), uint16(n))
+    default:
+        b = binary.BigEndian.AppendUint32(append(b, msgpackStr32), uint32(n))
+    }
+    return append(b, v...)
+}
+
+func (msgpackAppender) appendBytes(b []byte, v []byte) []byte {
+    n := len(v)
+    switch {
+    case n <= math.MaxUint8:
+        b = append(b, msgpackBin8, byte(n))
+    case n <= math.MaxUint16:
+        b = binary.BigEndian.AppendUint16(append(b, msgpackBin16), uint16(n))
+    default:
+        b = binary.BigEndian.AppendUint32(append(b, msgpackBin32), uint32(n))
+    }
+    return append(b, v...)
+}
+
+// appendTime appends the time with the timestamp extension, in the smallest of the 32, 64 and 96 bit formats that
+// holds it.
+func (msgpackAppender) appendTime(b []byte, v time.Time) []byte {
+    seconds, nanoseconds := v.Unix(), uint64(v.Nanosecond())
+
+    switch {
+    case seconds >= 0 && seconds <= math.MaxUint32 && nanoseconds == 0:
+        b = append(b, msgpackFixExt4, msgpackExtTimestamp)
+        return binary.BigEndian.AppendUint32(b, uint32(seconds))
+    case seconds >= 0 && seconds < 1<<34:
+        b = append(b, msgpackFixExt8, msgpackExtTimestamp)
+        return binary.BigEndian.AppendUint64(b, nanoseconds<<34|uint64(seconds))
+    default:
+        b = append(b, msgpackExt8, 12, msgpackExtTimestamp)
+        b = binary.BigEndian.AppendUint32(b, uint32(nanoseconds))
+        return binary.BigEndian.AppendUint64(b, uint64(seconds))
+    }
+}
+
+func (msgpackAppender) appendDuration(b []byte, v time.Duration) []byte {
+    b = append(b, msgpackFixExt8, msgpackExtDuration)
+    return binary.BigEndian.AppendUint64(b, uint64(v))
+}
+
+func (msgpackAppender) appendArrayHeader(b []byte, n int) []byte {
+    switch {
+    case n < 16:
+        return append(b, 0x90|byte(n))
+    case n <= math.MaxUint16:
+        return binary.BigEndian.AppendUint16(append(b, msgpackArray16), uint16(n))
+    default:
+        return binary.BigEndian.AppendUint32(append(b, msgpackArray32), uint32(n))
+    }
+}
+
+func (msgpackAppender) appendMapHeader(b []byte, n int) []byte {
+    switch {
+    case n < 16:
+        return append(b, 0x80|byte(n))
+    case n <= math.MaxUint16:
+        return binary.BigEndian.AppendUint16(append(b, msgpackMap16), uint16(n))
+    default:
+        return binary.BigEndian.AppendUint32(append(b, msgpackMap32), uint32(n))
+    }
+}
+
+// msgpackDecoder decodes a single MessagePack object. Integers are decoded as int64, or as uint64 if they don't fit,
+// maps as map[string]any with non-string keys formatted with %v, timestamps as time.Time and the duration extension
+// as time.Duration. Other extensions are returned as their data bytes.
+type msgpackDecoder struct {
+    b   []byte
+    pos int
+}
+
+// decodeMsgPack decodes the object in b, which must contain exactly one object.
+func decodeMsgPack(b []byte) (any, error) {
+    d := &msgpackDecoder{b: b}
+
+    v, err := d.decode(0)
+    if err != nil {
+        return nil, err
+    }
+    if d.pos != len(d.b) {
+        return nil, d.errorf("%d trailing bytes", len(d.b)-d.pos)
+    }
+    return v, nil
+}
+
+func (d *msgpackDecoder) errorf(format string, args ...any) error {
+    return &ErrorInvalidBinaryData{format: OutputFormatMsgPack, reason: fmt.Sprintf(format, args...)}
+}
+
+func (d *msgpackDecoder) next(n uint64) ([]byte, error) {
+    if n > uint64(len(d.b)-d.pos) {
+        return nil, d.errorf("unexpected end of data")
+    }
+    b := d.b[d.pos : d.pos+int(n)]
+    d.pos += int(n)
+    return b, nil
+}
+
+// uint reads a big-endian unsigned integer of n bytes.
+func (d *msgpackDecoder) uint(n uint64) (uint64, error) {
+    b, err := d.next(n)
+    if err != nil {
+        return 0, err
+    }
+    var v uint64
+    for _, c := range b {
+        v = v<<8 | uint64(c)
+    }
+    return v, nil
+}
+
+func (d *msgpackDecoder) decode(depth int) (any, error) {
+    if depth > maxBinaryDepth {
+        return nil, ErrorBinaryDepthExceeded
+    }
+
+    tb, err := d.next(1)
+    if err != nil {
+        return nil, err
+    }
+    t := tb[0]
+
+    switch {
+    case t <= 0x7f:
+        return int64(t), nil
+    case t >= 0xe0:
+        return int64(int8(t)), nil
+    case t&0xe0 == 0xa0:
+        return d.str(uint64(t & 0x1f))
+    case t&0xf0 == 0x90:
+        return d.array(uint64(t&0x0f), depth)
+    case t&0xf0 == 0x80:
+        return d.mapping(uint64(t&0x0f), depth)
+    }
+
+    switch t {
+    case msgpackNil:
+        return nil, nil
+    case msgpackFalse:
+        return false, nil
+    case msgpackTrue:
+        return true, nil
+    case msgpackUint8, msgpackUint16, msgpackUint32, msgpackUint64:
+        v, err := d.uint(1 << (t - msgpackUint8))
+        if err != nil {
+            return nil, err
+        }
+        if v > math.MaxInt64 {
+            return v, nil
+        }
+        return int64(v), nil
+    case msgpackInt8:
+        v, err := d.uint(1)
+        return int64(int8(v)), err
+    case msgpackInt16:
+        v, err := d.uint(2)
+        return int64(int16(v)), err
+    case msgpackInt32:
+        v, err := d.uint(4)
+        return int64(int32(v)), err
+    case msgpackInt64:
+        v, err := d.uint(8)
+        return int64(v), err
+    case msgpackFloat32:
+        v, err := d.uint(4)
+        return float64(math.Float32frombits(uint32(v))), err
+    case msgpackFloat64:
+        v, err := d.uint(8)
+        return math.Float64frombits(v), err
+    case msgpackStr8, msgpackStr16, msgpackStr32:
+        n, err := d.uint(1 << (t - msgpackStr8))
+        if err != nil {
+            return nil, err
+        }
+        return d.str(n)
+    case msgpackBin8, msgpackBin16, msgpackBin32:
+        n, err := d.uint(1 << (t - msgpackBin8))
+        if err != nil {
+            return nil, err
+        }
+        b, err := d.next(n)
+        if err != nil {
+            return nil, err
+        }
+        return append([]byte(nil), b...), nil
+    case msgpackArray16, msgpackArray32:
+        n, err := d.uint(2 << (t - msgpackArray16))
+        if err != nil {
+            return nil, err
+        }
+        return d.array(n, depth)
+    case msgpackMap16, msgpackMap32:
+        n, err := d.uint(2 << (t - msgpackMap16))
+        if err != nil {
+            return nil, err
+        }
+        return d.mapping(n, depth)
+    case msgpackFixExt1, msgpackFixExt2, msgpackFixExt4, msgpackFixExt8, msgpackFixExt16:
+        return d.ext(1 << (t - msgpackFixExt1))
+    case msgpackExt8, msgpackExt16, msgpackExt32:
+        n, err := d.uint(1 << (t - msgpackExt8))
+        if err != nil {
+            return nil, err
+        }
+        return d.ext(n)
+    }
+
+    return nil, d.errorf("unsupported type byte 0x%02x", t)
+}
+
+func (d *msgpackDecoder) str(n uint64) (any, error) {
+    b, err := d.next(n)
+    if err != nil {
+        return nil, err
+    }
+    return string(b), nil
+}
+
+func (d *msgpackDecoder) array(n uint64, depth int) (any, error) {
+    if n > uint64(len(d.b)-d.pos) {
+        return nil, d.errorf("unexpected end of data")
+    }
+
+    items := make([]any, 0, n)
+    for range n {
+        item, err := d.decode(depth + 1)
+        if err != nil {
+            return nil, err
+        }
+        items = append(items, item)
+    }
+    return items, nil
+}
+
+func (d *msgpackDecoder) mapping(n uint64, depth int) (any, error) {
+    if n > uint64(len(d.b)-d.pos) {
+        return nil, d.errorf("unexpected end of data")
+    }
+
+    m := make(map[string]any, n)
+    for range n {
+        key, err := d.decode(depth + 1)
+        if err != nil {
+            return nil, err
+        }
+        value, err := d.decode(depth + 1)
+        if err != nil {
+            return nil, err
+        }
+        m[binaryDecodedKey(key)] = value
+    }
+    return m, nil
+}
+
+// ext reads the type and n data bytes of an extension.
+func (d *msgpackDecoder) ext(n uint64) (any, error) {
+    tb, err := d.next(1)
+    if err != nil {
+        return nil, err
+    }
+    data, err := d.next(n)
+    if err != nil {
+        return nil, err
+    }
+
+    switch tb[0] {
+    case msgpackExtTimestamp:
+        switch len(data) {
+        case 4:
+            return time.Unix(int64(binary.BigEndian.Uint32(data)), 0).UTC(), nil
+        case 8:
+            v := binary.BigEndian.Uint64(data)
+            return time.Unix(int64(v&(1<<34-1)), int64(v>>34)).UTC(), nil
+        case 12:
+            nanoseconds := binary.BigEndian.Uint32(data)
+            seconds := int64(binary.BigEndian.Uint64(data[4:]))
+            return time.Unix(seconds, int64(nanoseconds)).UTC(), nil
+        }
+        return nil, d.errorf("invalid timestamp length %d", len(data))
+    case msgpackExtDuration:
+        if len(data) != 8 {
+            return nil, d.errorf("invalid duration length %d", len(data))
+        }
+        return time.Duration(binary.BigEndian.Uint64(data)), nil
+    }
+
+    return append([]byte(nil), data...), nil
+}
@@ -0,0 +1,121 @@
+package ultralogger
+
+import (
+    "encoding/hex"
+    "reflect"
+    "strings"
+    "testing"
+    "time"
+)
+
+func TestMsgPackAppender(t *testing.T) {
+    tests := []struct {
+        name string
+        data any
+        want string
+    }{
+        {name: "Positive fixint", data: 127, want: "7f"},
+        {name: "Uint8", data: 200, want: "ccc8"},
+        {name: "Uint16", data: 1000, want: "cd03e8"},
+        {name: "Uint32", data: 1000000, want: "ce000f4240"},
+        {name: "Uint64", data: uint64(1) << 40, want: "cf0000010000000000"},
+        {name: "Negative fixint", data: -32, want: "e0"},
+        {name: "Int8", data: -100, want: "d09c"},
+        {name: "Int16", data: -1000, want: "d1fc18"},
+        {name: "Int32", data: -100000, want: "d2fffe7960"},
+        {name: "Int64", data: int64(-1) << 40, want: "d3ffffff0000000000"},
+        {name: "Float32", data: float32(1.5), want: "ca3fc00000"},
+        {name: "Float64", data: 1.1, want: "cb3ff199999999999a"},
+        {name: "Nil", data: nil, want: "c0"},
+        {name: "False", data: false, want: "c2"},
+        {name: "True", data: true, want: "c3"},
+        {name: "Fixstr", data: "abc", want: "a3616263"},
+        {name: "Str8", data: strings.Repeat("a", 32), want: "d920" + strings.Repeat("61", 32)},
+        {name: "Bin8", data: []byte{1, 2}, want: "c4020102"},
+        {name: "Fixarray", data: []int{1, 2}, want: "920102"},
+        {name: "Fixmap", data: map[string]int{"a": 1}, want: "81a16101"},
+        {name: "Timestamp32", data: time.Unix(1363896240, 0), want: "d6ff514b67b0"},
+        {name: "Timestamp64", data: time.Unix(1363896240, 500000000), want: "d7ff77359400514b67b0"},
+        {name: "Timestamp96", data: time.Unix(-1, 0), want: "c70cff00000000ffffffffffffffff"},
+        {name: "Duration", data: 1500 * time.Millisecond, want: "d7010000000059682f00"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            b, err := appendBinaryValue(msgpackAppender{}, nil, tt.data, 0)
+            if err != nil {
+                t.Fatalf("appendBinaryValue() error = %v", err)
+            }
+
+            if got := hex.EncodeToString(b); got != tt.want {
+                t.Errorf("appendBinaryValue() = %s, want %s", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestDecodeMsgPack(t *testing.T) {
+    tests := []struct {
+        name    string
+        data    any
+        want    any
+        wantErr bool
+    }{
+        {name: "Ints", data: []any{127, 200, 1000, 1000000, -32, -100, -1000, -100000, int64(-1) << 40}, want: []any{
+            int64(127), int64(200), int64(1000), int64(1000000), int64(-32), int64(-100), int64(-1000), int64(-100000),
+            int64(-1) << 40,
+        }},
+        {name: "Large uint", data: uint64(18446744073709551615), want: uint64(18446744073709551615)},
+        {name: "Floats", data: []any{float32(1.5), 1.1}, want: []any{1.5, 1.1}},
+        {name: "Strings", data: []string{"abc", strings.Repeat("a", 300)}, want: []any{"abc", strings.Repeat("a", 300)}},
+        {name: "Bytes", data: []byte{1, 2}, want: []byte{1, 2}},
+        {name: "Map", data: map[string]any{"a": nil, "b": true}, want: map[string]any{"a": nil, "b": true}},
+        {name: "Large map", data: map[int]int{1: 1, 2: 2, 3: 3, 4: 4, 5: 5, 6: 6, 7: 7, 8: 8, 9: 9, 10: 10, 11: 11, 12: 12,
+            13: 13, 14: 14, 15: 15, 16: 16}, want: map[string]any{"1": int64(1), "2": int64(2), "3": int64(3),
+            "4": int64(4), "5": int64(5), "6": int64(6), "7": int64(7), "8": int64(8), "9": int64(9), "10": int64(10),
+            "11": int64(11), "12": int64(12), "13": int64(13), "14": int64(14), "15": int64(15), "16": int64(16)}},
+        {name: "Timestamp32", data: time.Unix(1363896240, 0), want: time.Unix(1363896240, 0).UTC()},
+        {name: "Timestamp64", data: time.Unix(1363896240, 123456789), want: time.Unix(1363896240, 123456789).UTC()},
+        {name: "Timestamp96", data: time.Unix(-1, 5), want: time.Unix(-1, 5).UTC()},
+        {name: "Duration", data: -1500 * time.Millisecond, want: -1500 * time.Millisecond},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            b, err := appendBinaryValue(msgpackAppender{}, nil, tt.data, 0)
+            if err != nil {
+                t.Fatalf("appendBinaryValue() error = %v", err)
+            }
+
+            got, err := decodeMsgPack(b)
+            if err != nil {
+                t.Fatalf("decodeMsgPack() error = %v", err)
+            }
+
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("decodeMsgPack() = %#v, want %#v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestDecodeMsgPackError(t *testing.T) {
+    tests := []struct {
+        name string
+        data string
+    }{
+        {name: "Empty", data: ""},
+        {name: "Truncated string", data: "a361"},
+        {name: "Array longer than data", data: "dd7fffffff"},
+        {name: "Trailing bytes", data: "c0c0"},
+        {name: "Reserved type", data: "c1"},
+        {name: "Invalid timestamp", data: "d5ff0000"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            b, _ := hex.DecodeString(tt.data)
+
+            if got, err := decodeMsgPack(b); err == nil {
+                t.Errorf("decodeMsgPack() = %#v, want error", got)
+            }
+        })
+    }
+}
@@ -81,3 +81,108 @@ func (e *ErrorInvalidFieldDataType) Error() string {
 var ErrorEmptyFieldName = errors.New("field name cannot be empty")
 
 var ErrorNilFormatter = errors.New("formatter cannot be nil")
+
+var ErrorNilTemplate = errors.New("template cannot be nil, create the TemplateFormatter with NewTemplateFormatter")
+
+type ErrorInvalidGELFChunkSize struct {
+    chunkSize int
+}
+
+func (e *ErrorInvalidGELFChunkSize) Error() string {
+    return fmt.Sprintf("invalid GELF chunk size: %d", e.chunkSize)
+}
+
+type ErrorGELFMessageTooLarge struct {
+    size    int
+    maxSize int
+}
+
+func (e *ErrorGELFMessageTooLarge) Error() string {
+    return fmt.Sprintf("GELF message too large: size=%d, max=%d", e.size, e.maxSize)
+}
+
+type ErrorInvalidMetricSettings struct {
+    reason string
+}
+
+func (e *ErrorInvalidMetricSettings) Error() string {
+    return fmt.Sprintf("invalid metric settings: %s", e.reason)
+}
+
+type ErrorMissingMetricDimension struct {
+    dimension string
+}
+
+func (e *ErrorMissingMetricDimension) Error() string {
+    return fmt.Sprintf("missing value for metric dimension: %s", e.dimension)
+}
+
+type ErrorInvalidPattern struct {
+    pattern string
+    reason  string
+}
+
+func (e *ErrorInvalidPattern) Error() string {
+    return fmt.Sprintf("invalid pattern %q: %s", e.pattern, e.reason)
+}
+
+type ErrorDuplicateFieldName struct {
+    name string
+}
+
+func (e *ErrorDuplicateFieldName) Error() string {
+    return fmt.Sprintf("duplicate field name: %s", e.name)
+}
+
+type ErrorUnsupportedBinaryType struct {
+    dataType string
+}
+
+func (e *ErrorUnsupportedBinaryType) Error() string {
+    return fmt.Sprintf("unsupported type for binary output: %s", e.dataType)
+}
+
+var ErrorBinaryDepthExceeded = errors.New("binary output exceeds the maximum nesting depth")
+
+type ErrorInvalidBinaryData struct {
+    format OutputFormat
+    reason string
+}
+
+func (e *ErrorInvalidBinaryData) Error() string {
+    return fmt.Sprintf("invalid %s data: %s", e.format, e.reason)
+}
+
+type ErrorInvalidStructType struct {
+    dataType string
+}
+
+func (e *ErrorInvalidStructType) Error() string {
+    return fmt.Sprintf("struct field type must be a struct or a pointer to a struct: %s", e.dataType)
+}
+
+type ErrorInvalidRedactionRule struct {
+    index  int
+    reason string
+}
+
+func (e *ErrorInvalidRedactionRule) Error() string {
+    return fmt.Sprintf("invalid redaction rule %d: %s", e.index, e.reason)
+}
+
+type ErrorInvalidPseudonymKey struct {
+    version string
+    reason  string
+}
+
+func (e *ErrorInvalidPseudonymKey) Error() string {
+    return fmt.Sprintf("invalid pseudonym key %q: %s", e.version, e.reason)
+}
+
+type ErrorUnsupportedPseudonymData struct {
+    dataType string
+}
+
+func (e *ErrorUnsupportedPseudonymData) Error() string {
+    return fmt.Sprintf("pseudonym keys can't be looked up in data of type %s", e.dataType)
+}
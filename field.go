@@ -1,8 +1,10 @@
 package ultralogger
 
 import (
+    "encoding/json"
     "fmt"
     "net/http"
+    "net/url"
     "strconv"
     "strings"
     "time"
@@ -16,6 +18,15 @@ type Field interface {
     NewFieldFormatter() (FieldFormatter, error)
 }
 
+// NamedField is implemented by fields that know the name of their results before formatting any data. Formatters use
+// the name to validate their fields up front, e.g. to detect duplicate names. All built-in fields implement
+// NamedField.
+type NamedField interface {
+    Field
+    // FieldName returns the name of the FieldResults produced by the field.
+    FieldName() string
+}
+
 // FieldResult is the result of formatting a field. It is used by the logger to annotate entries with metadata, such as
 // the name of the field.
 type FieldResult struct {
@@ -32,7 +43,9 @@ type FieldFormatter func(
 
 // ObjectField is a field that provides a formatter for a struct of type T.
 type ObjectField[T any] struct {
-    format FieldFormatter
+    name    string
+    format  FieldFormatter
+    ecsPath string
 }
 
 // ObjectFieldFormatter is a function that formats a struct of type T and returns the formatted data. Note that this
@@ -49,6 +62,23 @@ func (f ObjectField[T]) NewFieldFormatter() (FieldFormatter, error) {
     return f.format, nil
 }
 
+// FieldName returns the name of the ObjectField.
+func (f ObjectField[T]) FieldName() string {
+    return f.name
+}
+
+// WithECSPath returns a copy of the ObjectField that is placed at the provided dotted path (e.g. "user.name") by the
+// [ECSFormatter]. Other formatters ignore the path.
+func (f ObjectField[T]) WithECSPath(path string) ObjectField[T] {
+    f.ecsPath = path
+    return f
+}
+
+// ECSPath returns the ECS path of the ObjectField, or an empty string if no path was set with WithECSPath.
+func (f ObjectField[T]) ECSPath() string {
+    return f.ecsPath
+}
+
 // NewObjectField returns a new ObjectField with the specified name and formatter. If the name is empty, an error is
 // returned. If the formatter is nil, an error is returned.
 //
@@ -62,6 +92,7 @@ func NewObjectField[T any](name string, formatter ObjectFieldFormatter[T]) (Obje
         return ObjectField[T]{}, ErrorNilFormatter
     }
     return ObjectField[T]{
+        name: name,
         format: func(args LogLineArgs, data any) (FieldResult, error) {
             result := FieldResult{
                 Name: name,
@@ -126,7 +157,7 @@ func NewBoolField(name string) (Field, error) {
 //
 // OutputFormats:
 //  - OutputFormatText => time.Time is formatted as a string with the format provided in the format argument.
-//  - OutputFormatJSON => time.Time is formatted as a time.Time.
+//  - OutputFormatJSON => time.Time is formatted as a time.Time, written with the [TimeEncoding] of the formatter.
 func NewTimeField(name, format string) (Field, error) {
     return NewObjectField[time.Time](
         name,
@@ -183,7 +214,8 @@ func NewFloatField(name string) (Field, error) {
 //
 // OutputFormats:
 //  - OutputFormatText => time.Duration is formatted as a string with the format %s.
-//  - OutputFormatJSON => time.Duration is formatted as a time.Duration.
+//  - OutputFormatJSON => time.Duration is formatted as a time.Duration, written with the [DurationEncoding] of the
+//    formatter.
 func NewDurationField(name string) (Field, error) {
     return NewObjectField[time.Duration](
         name,
@@ -196,14 +228,14 @@ func NewDurationField(name string) (Field, error) {
     )
 }
 
-// NewErrorField returns a new Field that formats an error into a string. The field will format the error using the
-// Error() method of the error.
+// NewErrorField returns a new Field that formats an error. The field will format the error using the Error() method of
+// the error, and for structured output formats, with the errors it wraps.
 //
 // If the name is empty, an error is returned.
 //
 // OutputFormats:
 //  - OutputFormatText => error is formatted as a string with the format %v.
-//  - OutputFormatJSON => error is formatted as a error.
+//  - OutputFormatJSON => [ErrorLogEntry], e.g. {"message":"open x: no such file","type":"*fs.PathError","chain":[...]}.
 func NewErrorField(name string) (Field, error) {
     return NewObjectField[error](
         name,
@@ -211,7 +243,7 @@ func NewErrorField(name string) (Field, error) {
             if args.OutputFormat == OutputFormatText {
                 return data.Error()
             }
-            return data
+            return newErrorLogEntry(data)
         },
     )
 }
@@ -303,7 +335,7 @@ func NewMapField[K comparable, V any](name string, keyFormatter ObjectFieldForma
 //
 // OutputFormats:
 //  - OutputFormatText => time is formatted as a string with the format provided in the format argument.
-//  - OutputFormatJSON => time is formatted as a time.Time.
+//  - OutputFormatJSON => time is formatted as a time.Time, written with the [TimeEncoding] of the formatter.
 func NewCurrentTimeField(name, format string) (Field, error) {
     if name == "" {
         return &currentTimeField{}, ErrorEmptyFieldName
@@ -328,6 +360,10 @@ func (f *currentTimeField) NewFieldFormatter() (FieldFormatter, error) {
     return f.format, nil
 }
 
+func (f *currentTimeField) FieldName() string {
+    return f.name
+}
+
 func (f *currentTimeField) format(args LogLineArgs, _ any) (FieldResult, error) {
     result := FieldResult{
         Name: f.name,
@@ -336,10 +372,10 @@ func (f *currentTimeField) format(args LogLineArgs, _ any) (FieldResult, error)
     now := f.clock.Now()
 
     switch args.OutputFormat {
-    case OutputFormatJSON:
-        result.Data = now
     case OutputFormatText:
         result.Data = now.Format(f.fmtString)
+    default:
+        result.Data = now
     }
 
     return result, nil
@@ -382,6 +418,10 @@ func (f *levelField) NewFieldFormatter() (FieldFormatter, error) {
     return f.format, nil
 }
 
+func (f *levelField) FieldName() string {
+    return "level"
+}
+
 func (f *levelField) format(args LogLineArgs, _ any) (FieldResult, error) {
     if args.OutputFormat == OutputFormatText {
         return FieldResult{
@@ -414,6 +454,14 @@ func (f *fieldMessage) NewFieldFormatter() (FieldFormatter, error) {
     return f.format, nil
 }
 
+func (f *fieldMessage) FieldName() string {
+    return "message"
+}
+
+func (f *fieldMessage) zeroData() (any, bool) {
+    return "", true
+}
+
 func (f *fieldMessage) format(_ LogLineArgs, message any) (FieldResult, error) {
     result := FieldResult{
         Name: "message",
@@ -443,8 +491,10 @@ func (f *fieldMessage) format(_ LogLineArgs, message any) (FieldResult, error) {
 //    [RequestFieldSettings]. Included fields are returned as a space separated string with key=value elements. Returns
 //    an empty string if [RequestFieldSettings] has no true fields.
 //  - OutputFormatJSON => [RequestLogEntry].
+//
+// An *http.Response with a Request, e.g. the data of the lines of [HTTPMiddleware], is formatted as its Request.
 func NewRequestField(name string, settings RequestFieldSettings) (Field, error) {
-    return NewObjectField[*http.Request](
+    field, err := NewObjectField[*http.Request](
         name,
         func(args LogLineArgs, data *http.Request) any {
             logEntry := RequestLogEntry{}
@@ -457,20 +507,72 @@ func NewRequestField(name string, settings RequestFieldSettings) (Field, error)
                 logEntry.SourceIP = data.RemoteAddr
             }
 
+            if settings.LogClientIP {
+                logEntry.ClientIP = requestClientIP(data)
+            }
+
+            if settings.LogRequestID {
+                if id, ok := RequestIDFromContext(data.Context()); ok {
+                    logEntry.RequestID = id
+                } else {
+                    logEntry.RequestID = data.Header.Get(RequestIDHeader)
+                }
+            }
+
             if settings.LogMethod {
                 logEntry.Method = data.Method
             }
 
+            if settings.LogHost {
+                logEntry.Host = data.Host
+            }
+
             if settings.LogPath {
                 logEntry.Path = data.URL.Path
             }
 
+            if settings.LogQuery && data.URL.RawQuery != "" {
+                logEntry.Query = data.URL.Query()
+            }
+
+            if settings.LogProto {
+                logEntry.Proto = data.Proto
+            }
+
+            if settings.LogUserAgent {
+                logEntry.UserAgent = data.UserAgent()
+            }
+
+            if settings.LogContentLength && data.ContentLength > 0 {
+                logEntry.ContentLength = data.ContentLength
+            }
+
+            if settings.LogHeaders {
+                logEntry.Headers = filterHeaders(data.Header, settings.HeaderAllowlist, settings.HeaderDenylist)
+            }
+
+            if settings.MaxBodySize > 0 {
+                logEntry.Body, logEntry.BodyTruncated = requestBodyFromContext(data.Context(), settings.MaxBodySize)
+            }
+
             if args.OutputFormat == OutputFormatText {
                 return logEntry.String(settings.TimeFormat)
             }
             return logEntry
         },
     )
+    if err != nil {
+        return field, err
+    }
+
+    format := field.format
+    field.format = func(args LogLineArgs, data any) (FieldResult, error) {
+        if response, ok := data.(*http.Response); ok && response != nil && response.Request != nil {
+            data = response.Request
+        }
+        return format(args, data)
+    }
+    return field, nil
 }
 
 // RequestFieldSettings is a struct that contains settings for the RequestField.
@@ -491,14 +593,118 @@ type RequestFieldSettings struct {
     LogPath bool
     // LogSourceIP determines whether to include the SourceIP field in the formatted output.
     LogSourceIP bool
+    // LogClientIP determines whether to include the ClientIP field in the formatted output. The client IP is taken from
+    // the Forwarded and X-Forwarded-For headers, which are set by the client unless a proxy overwrites them, and falls
+    // back to the host of the RemoteAddr.
+    LogClientIP bool
+    // LogRequestID determines whether to include the RequestID field in the formatted output: the request ID carried
+    // by the context of the request, see [RequestIDMiddleware], or else its X-Request-ID header.
+    LogRequestID bool
+    // LogHost determines whether to include the Host field in the formatted output.
+    LogHost bool
+    // LogQuery determines whether to include the Query field in the formatted output.
+    LogQuery bool
+    // LogProto determines whether to include the Proto field in the formatted output, e.g. "HTTP/1.1".
+    LogProto bool
+    // LogUserAgent determines whether to include the UserAgent field in the formatted output.
+    LogUserAgent bool
+    // LogContentLength determines whether to include the ContentLength field in the formatted output. Requests with an
+    // unknown content length have no ContentLength.
+    LogContentLength bool
+
+    // LogHeaders determines whether to include the Headers field in the formatted output. The values of the
+    // Authorization, Proxy-Authorization, Cookie and Set-Cookie headers are always redacted.
+    LogHeaders bool
+    // HeaderAllowlist are the names of the headers to include, ignoring case. If it is empty, all headers are included.
+    HeaderAllowlist []string
+    // HeaderDenylist are the names of the headers to exclude, ignoring case, also when they are allowed.
+    HeaderDenylist []string
+
+    // MaxBodySize is the number of bytes of the body to include in the Body field. If it is 0, the body is not
+    // included. The body is read from the context of the request, so it must be captured on the request path with
+    // [CaptureRequestBody], e.g. by [HTTPMiddleware] with [WithRequestBody]; requests without a captured body have no
+    // Body.
+    MaxBodySize int
 }
 
 // RequestLogEntry is a struct that represents a formatted http.Request.
 type RequestLogEntry struct {
-    ReceivedAt time.Time
-    Method     string
-    Path       string
-    SourceIP   string
+    ReceivedAt    time.Time           `json:"received_at"`
+    RequestID     string              `json:"request_id,omitempty"`
+    Method        string              `json:"method,omitempty"`
+    Host          string              `json:"host,omitempty"`
+    Path          string              `json:"path,omitempty"`
+    Query         map[string][]string `json:"query,omitempty"`
+    Proto         string              `json:"proto,omitempty"`
+    SourceIP      string              `json:"source_ip,omitempty"`
+    ClientIP      string              `json:"client_ip,omitempty"`
+    UserAgent     string              `json:"user_agent,omitempty"`
+    ContentLength int64               `json:"content_length,omitempty"`
+    Headers       map[string][]string `json:"headers,omitempty"`
+    Body          string              `json:"body,omitempty"`
+    BodyTruncated bool                `json:"body_truncated,omitempty"`
+}
+
+// MarshalJSON marshals the entry with its JSON tags, and omits the ReceivedAt field if it is zero.
+func (r RequestLogEntry) MarshalJSON() ([]byte, error) {
+    type requestLogEntry RequestLogEntry
+
+    entry := struct {
+        requestLogEntry
+        ReceivedAt *time.Time `json:"received_at,omitempty"`
+    }{requestLogEntry: requestLogEntry(r)}
+    if !r.ReceivedAt.IsZero() {
+        entry.ReceivedAt = &r.ReceivedAt
+    }
+    return json.Marshal(entry)
+}
+
+// logObject returns the fields of the entry that MarshalJSON writes, in the same order.
+func (r RequestLogEntry) logObject() structObject {
+    obj := structObject{}
+    if !r.ReceivedAt.IsZero() {
+        obj = obj.append("received_at", r.ReceivedAt)
+    }
+    if r.RequestID != "" {
+        obj = obj.append("request_id", r.RequestID)
+    }
+    if r.Method != "" {
+        obj = obj.append("method", r.Method)
+    }
+    if r.Host != "" {
+        obj = obj.append("host", r.Host)
+    }
+    if r.Path != "" {
+        obj = obj.append("path", r.Path)
+    }
+    if len(r.Query) > 0 {
+        obj = obj.append("query", r.Query)
+    }
+    if r.Proto != "" {
+        obj = obj.append("proto", r.Proto)
+    }
+    if r.SourceIP != "" {
+        obj = obj.append("source_ip", r.SourceIP)
+    }
+    if r.ClientIP != "" {
+        obj = obj.append("client_ip", r.ClientIP)
+    }
+    if r.UserAgent != "" {
+        obj = obj.append("user_agent", r.UserAgent)
+    }
+    if r.ContentLength != 0 {
+        obj = obj.append("content_length", r.ContentLength)
+    }
+    if len(r.Headers) > 0 {
+        obj = obj.append("headers", r.Headers)
+    }
+    if r.Body != "" {
+        obj = obj.append("body", r.Body)
+    }
+    if r.BodyTruncated {
+        obj = obj.append("body_truncated", r.BodyTruncated)
+    }
+    return obj
 }
 
 func (r *RequestLogEntry) String(timeFmt string) string {
@@ -506,27 +712,55 @@ func (r *RequestLogEntry) String(timeFmt string) string {
     if !r.ReceivedAt.IsZero() {
         parts = append(parts, r.ReceivedAt.Format(timeFmt))
     }
+    if r.RequestID != "" {
+        parts = append(parts, r.RequestID)
+    }
     if r.Method != "" {
         parts = append(parts, r.Method)
     }
+    if r.Host != "" {
+        parts = append(parts, r.Host)
+    }
     if r.Path != "" {
         parts = append(parts, r.Path)
     }
+    if len(r.Query) > 0 {
+        parts = append(parts, "?"+url.Values(r.Query).Encode())
+    }
+    if r.Proto != "" {
+        parts = append(parts, r.Proto)
+    }
     if r.SourceIP != "" {
         parts = append(parts, r.SourceIP)
     }
+    if r.ClientIP != "" {
+        parts = append(parts, r.ClientIP)
+    }
+    if r.UserAgent != "" {
+        parts = append(parts, strconv.Quote(r.UserAgent))
+    }
+    if r.ContentLength != 0 {
+        parts = append(parts, strconv.FormatInt(r.ContentLength, 10))
+    }
+    parts = appendHeaderParts(parts, r.Headers)
+    if r.Body != "" {
+        parts = append(parts, strconv.Quote(r.Body))
+    }
     return strings.Join(parts, " ")
 }
 
 // NewResponseField returns a new Field that formats an http.Response into a string. The field will format the response
 // using the provided settings [ResponseFieldSettings].
 //
+// Responses without a Request are formatted without the Path and the Duration, and a nil response is formatted as an
+// empty entry.
+//
 // An error is returned if the name is empty or the settings are nil.
 //
 // OutputFormats:
 //  - OutputFormatText => response is formatted as a string. http.Response fields are included based on the settings
 //    [ResponseFieldSettings]. Included fields are returned as a space separated string with key=value elements. Returns
-//    an empty string if [RequestFieldSettings] has no true fields.
+//    an empty string if [ResponseFieldSettings] has no true fields.
 //  - OutputFormatJSON => [ResponseLogEntry].
 func NewResponseField(name string, settings ResponseFieldSettings) (Field, error) {
     return NewObjectField[*http.Response](
@@ -534,6 +768,13 @@ func NewResponseField(name string, settings ResponseFieldSettings) (Field, error
         func(args LogLineArgs, data *http.Response) any {
             logEntry := ResponseLogEntry{}
 
+            if data == nil {
+                if args.OutputFormat == OutputFormatText {
+                    return logEntry.String()
+                }
+                return logEntry
+            }
+
             if settings.LogStatus {
                 logEntry.Status = data.Status
             }
@@ -542,10 +783,22 @@ func NewResponseField(name string, settings ResponseFieldSettings) (Field, error
                 logEntry.StatusCode = data.StatusCode
             }
 
-            if settings.LogPath {
+            if settings.LogPath && data.Request != nil && data.Request.URL != nil {
                 logEntry.Path = data.Request.URL.Path
             }
 
+            if settings.LogContentLength && data.ContentLength > 0 {
+                logEntry.ContentLength = data.ContentLength
+            }
+
+            if settings.LogDuration && data.Request != nil {
+                logEntry.Duration = requestDuration(data.Request.Context())
+            }
+
+            if settings.LogHeaders {
+                logEntry.Headers = filterHeaders(data.Header, settings.HeaderAllowlist, settings.HeaderDenylist)
+            }
+
             if args.OutputFormat == OutputFormatText {
                 return logEntry.String()
             }
@@ -554,6 +807,9 @@ func NewResponseField(name string, settings ResponseFieldSettings) (Field, error
     )
 }
 
+// ResponseFieldSettings is a struct that contains settings for the ResponseField.
+//
+// The settings are used to determine which fields of the http.Response struct to include in the formatted output.
 type ResponseFieldSettings struct {
     // LogStatus determines whether to include the http.Response.Status field in the formatted output.
     LogStatus bool
@@ -561,18 +817,76 @@ type ResponseFieldSettings struct {
     LogStatusCode bool
     // LogPath determines whether to include the associated http.Request.URL.Path field in the formatted output.
     LogPath bool
+    // LogContentLength determines whether to include the http.Response.ContentLength field in the formatted output.
+    // Responses with an unknown content length have no ContentLength.
+    LogContentLength bool
+    // LogDuration determines whether to include the Duration field in the formatted output: the time since the
+    // associated http.Request was sent, as carried by its context. See [ContextWithRequestStart].
+    LogDuration bool
+
+    // LogHeaders determines whether to include the Headers field in the formatted output. The values of the
+    // Authorization, Proxy-Authorization, Cookie and Set-Cookie headers are always redacted.
+    LogHeaders bool
+    // HeaderAllowlist are the names of the headers to include, ignoring case. If it is empty, all headers are included.
+    HeaderAllowlist []string
+    // HeaderDenylist are the names of the headers to exclude, ignoring case, also when they are allowed.
+    HeaderDenylist []string
+}
+
+// ResponseLogEntry is a struct that represents a formatted http.Response.
+type ResponseLogEntry struct {
+    StatusCode    int                 `json:"status_code,omitempty"`
+    Status        string              `json:"status,omitempty"`
+    Path          string              `json:"path,omitempty"`
+    ContentLength int64               `json:"content_length,omitempty"`
+    Duration      time.Duration       `json:"duration,omitempty"`
+    Headers       map[string][]string `json:"headers,omitempty"`
 }
 
-type ResponseLogEntry struct {
-    StatusCode int
-    Status     string
-    Path       string
+// logObject returns the fields of the entry that encoding/json writes, in the same order.
+func (r ResponseLogEntry) logObject() structObject {
+    obj := structObject{}
+    if r.StatusCode != 0 {
+        obj = obj.append("status_code", r.StatusCode)
+    }
+    if r.Status != "" {
+        obj = obj.append("status", r.Status)
+    }
+    if r.Path != "" {
+        obj = obj.append("path", r.Path)
+    }
+    if r.ContentLength != 0 {
+        obj = obj.append("content_length", r.ContentLength)
+    }
+    if r.Duration != 0 {
+        obj = obj.append("duration", r.Duration)
+    }
+    if len(r.Headers) > 0 {
+        obj = obj.append("headers", r.Headers)
+    }
+    return obj
 }
 
 func (r *ResponseLogEntry) String() string {
     parts := []string{}
-    if r.StatusCode != 0 {
+    switch {
+    case r.Status != "":
+        if r.StatusCode != 0 && !strings.HasPrefix(r.Status, strconv.Itoa(r.StatusCode)) {
+            parts = append(parts, strconv.Itoa(r.StatusCode))
+        }
+        parts = append(parts, r.Status)
+    case r.StatusCode != 0:
         parts = append(parts, strconv.Itoa(r.StatusCode))
     }
+    if r.Path != "" {
+        parts = append(parts, r.Path)
+    }
+    if r.ContentLength != 0 {
+        parts = append(parts, strconv.FormatInt(r.ContentLength, 10))
+    }
+    if r.Duration != 0 {
+        parts = append(parts, r.Duration.String())
+    }
+    parts = appendHeaderParts(parts, r.Headers)
     return strings.Join(parts, " ")
 }
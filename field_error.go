@@ -0,0 +1,98 @@
+package ultralogger
+
+import (
+    "errors"
+    "fmt"
+)
+
+const (
+    // maxErrorChainLength is the largest number of wrapped errors that are added to the chain of an ErrorLogEntry.
+    maxErrorChainLength = 32
+    // maxErrorJoinDepth is the deepest level of joined errors that is added to an ErrorLogEntry.
+    maxErrorJoinDepth = 8
+)
+
+// LogFielder is implemented by errors that contribute their own attributes to their [ErrorLogEntry], e.g. the status
+// code of a failed request.
+type LogFielder interface {
+    // LogFields returns the attributes of the error.
+    LogFields() map[string]any
+}
+
+// LogMarshaler is implemented by errors that contribute their own attributes to their [ErrorLogEntry]. It is an
+// alternative to LogFielder, for errors that already implement MarshalLog.
+type LogMarshaler interface {
+    // MarshalLog returns the attributes of the error.
+    MarshalLog() map[string]any
+}
+
+// ErrorLogEntry is a struct that represents a formatted error.
+//
+// Message is the result of Error(), and Type is the concrete type of the error, e.g. "*fs.PathError". Chain holds the
+// errors returned by repeatedly calling errors.Unwrap, from the outermost to the innermost. Errors holds the errors
+// of an error that wraps several errors, e.g. one created with errors.Join, which can in turn have chains of their
+// own. Fields holds the attributes of errors that implement [LogFielder] or [LogMarshaler].
+type ErrorLogEntry struct {
+    Message string          `json:"message"`
+    Type    string          `json:"type"`
+    Chain   []ErrorLogEntry `json:"chain,omitempty"`
+    Errors  []ErrorLogEntry `json:"errors,omitempty"`
+    Fields  map[string]any  `json:"fields,omitempty"`
+
+    err error
+}
+
+// String returns the message of the error. Formatters that only support flat values write the message.
+func (e ErrorLogEntry) String() string {
+    return e.Message
+}
+
+// newErrorLogEntry returns the ErrorLogEntry of the error, with the chain of the errors it wraps.
+func newErrorLogEntry(err error) ErrorLogEntry {
+    return newErrorLogEntryDepth(err, 0)
+}
+
+func newErrorLogEntryDepth(err error, depth int) ErrorLogEntry {
+    entry := newErrorLogEntryLink(err, depth)
+
+    for next := errors.Unwrap(err); next != nil && len(entry.Chain) < maxErrorChainLength; next = errors.Unwrap(next) {
+        entry.Chain = append(entry.Chain, newErrorLogEntryLink(next, depth))
+    }
+
+    return entry
+}
+
+// newErrorLogEntryLink returns the ErrorLogEntry of the error without its chain. The errors of an error that wraps
+// several errors are added with their own chains.
+func newErrorLogEntryLink(err error, depth int) ErrorLogEntry {
+    entry := ErrorLogEntry{
+        Message: err.Error(),
+        Type:    fmt.Sprintf("%T", err),
+        Fields:  errorLogFields(err),
+        err:     err,
+    }
+
+    joinErr, ok := err.(interface{ Unwrap() []error })
+    if !ok || depth >= maxErrorJoinDepth {
+        return entry
+    }
+
+    for _, joined := range joinErr.Unwrap() {
+        if joined != nil {
+            entry.Errors = append(entry.Errors, newErrorLogEntryDepth(joined, depth+1))
+        }
+    }
+
+    return entry
+}
+
+// errorLogFields returns the attributes that the error contributes itself, or nil if it contributes none.
+func errorLogFields(err error) map[string]any {
+    switch e := err.(type) {
+    case LogFielder:
+        return e.LogFields()
+    case LogMarshaler:
+        return e.MarshalLog()
+    }
+    return nil
+}
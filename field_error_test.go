@@ -0,0 +1,122 @@
+package ultralogger
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io/fs"
+    "os"
+    "testing"
+)
+
+type statusError struct {
+    status int
+}
+
+func (e statusError) Error() string {
+    return fmt.Sprintf("status %d", e.status)
+}
+
+func (e statusError) LogFields() map[string]any {
+    return map[string]any{"status": e.status}
+}
+
+type retryError struct {
+    attempts int
+}
+
+func (e *retryError) Error() string {
+    return "retries exhausted"
+}
+
+func (e *retryError) MarshalLog() map[string]any {
+    return map[string]any{"attempts": e.attempts}
+}
+
+func ExampleNewErrorField() {
+    errField, _ := NewErrorField("error")
+
+    formatter, _ := NewFormatter(OutputFormatJSON, []Field{errField})
+
+    logger, _ := NewLoggerWithOptions(WithDestination(os.Stdout, formatter), WithAsync(false))
+
+    logger.Error(fmt.Errorf("loading config: %w", fs.ErrNotExist))
+    // Output: {"error":{"message":"loading config: file does not exist","type":"*fmt.wrapError","chain":[{"message":"file does not exist","type":"*errors.errorString"}]}}
+}
+
+func TestNewErrorLogEntry(t *testing.T) {
+    tests := []struct {
+        name string
+        err  error
+        want string
+    }{
+        {
+            name: "Plain",
+            err:  errors.New("boom"),
+            want: `{"message":"boom","type":"*errors.errorString"}`,
+        },
+        {
+            name: "Chain",
+            err:  fmt.Errorf("a: %w", fmt.Errorf("b: %w", errors.New("c"))),
+            want: `{"message":"a: b: c","type":"*fmt.wrapError","chain":[` +
+                `{"message":"b: c","type":"*fmt.wrapError"},` +
+                `{"message":"c","type":"*errors.errorString"}]}`,
+        },
+        {
+            name: "Join",
+            err:  errors.Join(errors.New("a"), nil, fmt.Errorf("b: %w", errors.New("c"))),
+            want: `{"message":"a\nb: c","type":"*errors.joinError","errors":[` +
+                `{"message":"a","type":"*errors.errorString"},` +
+                `{"message":"b: c","type":"*fmt.wrapError","chain":[{"message":"c","type":"*errors.errorString"}]}]}`,
+        },
+        {
+            name: "Wrapped join",
+            err:  fmt.Errorf("request: %w", errors.Join(errors.New("a"), errors.New("b"))),
+            want: `{"message":"request: a\nb","type":"*fmt.wrapError","chain":[` +
+                `{"message":"a\nb","type":"*errors.joinError","errors":[` +
+                `{"message":"a","type":"*errors.errorString"},{"message":"b","type":"*errors.errorString"}]}]}`,
+        },
+        {
+            name: "LogFields",
+            err:  fmt.Errorf("fetch: %w", statusError{status: 503}),
+            want: `{"message":"fetch: status 503","type":"*fmt.wrapError","chain":[` +
+                `{"message":"status 503","type":"ultralogger.statusError","fields":{"status":503}}]}`,
+        },
+        {
+            name: "MarshalLog",
+            err:  &retryError{attempts: 3},
+            want: `{"message":"retries exhausted","type":"*ultralogger.retryError","fields":{"attempts":3}}`,
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            jBytes, err := json.Marshal(newErrorLogEntry(tt.err))
+            if err != nil {
+                t.Fatalf("json.Marshal() error = %v", err)
+            }
+
+            if got := string(jBytes); got != tt.want {
+                t.Errorf("newErrorLogEntry() = %s, want %s", got, tt.want)
+            }
+        })
+    }
+}
+
+// cyclicError unwraps to itself, so that its chain never ends.
+type cyclicError struct{}
+
+func (e *cyclicError) Error() string {
+    return "cyclic"
+}
+
+func (e *cyclicError) Unwrap() error {
+    return e
+}
+
+func TestNewErrorLogEntry_cyclic(t *testing.T) {
+    entry := newErrorLogEntry(&cyclicError{})
+
+    if len(entry.Chain) != maxErrorChainLength {
+        t.Errorf("len(Chain) = %d, want %d", len(entry.Chain), maxErrorChainLength)
+    }
+}
@@ -0,0 +1,247 @@
+package ultralogger
+
+import (
+    "encoding/json"
+    "maps"
+    "slices"
+    "strconv"
+    "strings"
+)
+
+// MetricUnit is the unit of a CloudWatch metric.
+type MetricUnit string
+
+const (
+    MetricUnitNone               MetricUnit = "None"
+    MetricUnitSeconds            MetricUnit = "Seconds"
+    MetricUnitMicroseconds       MetricUnit = "Microseconds"
+    MetricUnitMilliseconds       MetricUnit = "Milliseconds"
+    MetricUnitBytes              MetricUnit = "Bytes"
+    MetricUnitKilobytes          MetricUnit = "Kilobytes"
+    MetricUnitMegabytes          MetricUnit = "Megabytes"
+    MetricUnitGigabytes          MetricUnit = "Gigabytes"
+    MetricUnitTerabytes          MetricUnit = "Terabytes"
+    MetricUnitBits               MetricUnit = "Bits"
+    MetricUnitKilobits           MetricUnit = "Kilobits"
+    MetricUnitMegabits           MetricUnit = "Megabits"
+    MetricUnitGigabits           MetricUnit = "Gigabits"
+    MetricUnitTerabits           MetricUnit = "Terabits"
+    MetricUnitPercent            MetricUnit = "Percent"
+    MetricUnitCount              MetricUnit = "Count"
+    MetricUnitBytesPerSecond     MetricUnit = "Bytes/Second"
+    MetricUnitKilobytesPerSecond MetricUnit = "Kilobytes/Second"
+    MetricUnitMegabytesPerSecond MetricUnit = "Megabytes/Second"
+    MetricUnitGigabytesPerSecond MetricUnit = "Gigabytes/Second"
+    MetricUnitTerabytesPerSecond MetricUnit = "Terabytes/Second"
+    MetricUnitBitsPerSecond      MetricUnit = "Bits/Second"
+    MetricUnitKilobitsPerSecond  MetricUnit = "Kilobits/Second"
+    MetricUnitMegabitsPerSecond  MetricUnit = "Megabits/Second"
+    MetricUnitGigabitsPerSecond  MetricUnit = "Gigabits/Second"
+    MetricUnitTerabitsPerSecond  MetricUnit = "Terabits/Second"
+    MetricUnitCountPerSecond     MetricUnit = "Count/Second"
+)
+
+const (
+    maxMetricsPerDirective   = 100
+    maxDimensionsPerSet      = 30
+    maxMetricNamespaceLength = 255
+)
+
+var validMetricUnits = []MetricUnit{
+    MetricUnitNone, MetricUnitSeconds, MetricUnitMicroseconds, MetricUnitMilliseconds, MetricUnitBytes,
+    MetricUnitKilobytes, MetricUnitMegabytes, MetricUnitGigabytes, MetricUnitTerabytes, MetricUnitBits,
+    MetricUnitKilobits, MetricUnitMegabits, MetricUnitGigabits, MetricUnitTerabits, MetricUnitPercent,
+    MetricUnitCount, MetricUnitBytesPerSecond, MetricUnitKilobytesPerSecond, MetricUnitMegabytesPerSecond,
+    MetricUnitGigabytesPerSecond, MetricUnitTerabytesPerSecond, MetricUnitBitsPerSecond, MetricUnitKilobitsPerSecond,
+    MetricUnitMegabitsPerSecond, MetricUnitGigabitsPerSecond, MetricUnitTerabitsPerSecond, MetricUnitCountPerSecond,
+}
+
+// MetricDefinition declares a single metric.
+type MetricDefinition struct {
+    // Name is the name of the metric. It is also the key of the metric value in the log line.
+    Name string `json:"Name"`
+    // Unit is the unit of the metric. If it is empty, MetricUnitNone is used.
+    Unit MetricUnit `json:"Unit,omitempty"`
+    // StorageResolution is either 60 (standard resolution) or 1 (high resolution). If it is 0, standard resolution
+    // is used.
+    StorageResolution int `json:"StorageResolution,omitempty"`
+}
+
+// MetricSettings declares the namespace, dimensions and metrics of a metric field.
+type MetricSettings struct {
+    // Namespace is the CloudWatch namespace of the metrics.
+    Namespace string
+    // Dimensions is a list of dimension sets. Each dimension set is a list of dimension names, and every dimension
+    // name must have a value in the logged MetricData. An empty list publishes the metrics without dimensions.
+    Dimensions [][]string
+    // Metrics are the metrics that can be logged through the field.
+    Metrics []MetricDefinition
+}
+
+// MetricData is the data logged through a metric field.
+type MetricData struct {
+    // Dimensions are the values of the dimensions declared in MetricSettings.
+    Dimensions map[string]string
+    // Values are the values of the metrics, by metric name. Values for metrics that were not declared in
+    // MetricSettings are ignored.
+    Values map[string]float64
+}
+
+// NewMetricField returns a new Field that formats MetricData as CloudWatch metrics. The settings are validated when
+// the field is created, and an ErrorInvalidMetricSettings is returned if they violate the limits of the CloudWatch
+// Embedded Metric Format.
+//
+// OutputFormats:
+//  - OutputFormatText => the dimensions and values, formatted as space separated name=value pairs sorted by name.
+//  - OutputFormatJSON => an object with the dimensions and values at the top level. The [EMFFormatter] additionally
+//    adds the metric declarations to the "_aws" metadata block.
+func NewMetricField(name string, settings MetricSettings) (Field, error) {
+    if err := settings.validate(); err != nil {
+        return ObjectField[MetricData]{}, err
+    }
+
+    return NewObjectField[MetricData](
+        name,
+        func(args LogLineArgs, data MetricData) any {
+            result := &metricResult{settings: settings, data: data}
+            if args.OutputFormat == OutputFormatText {
+                return result.String()
+            }
+            return result
+        },
+    )
+}
+
+func (s MetricSettings) validate() error {
+    if s.Namespace == "" || len(s.Namespace) > maxMetricNamespaceLength {
+        return &ErrorInvalidMetricSettings{reason: "namespace must be between 1 and 255 characters"}
+    }
+
+    if len(s.Metrics) == 0 || len(s.Metrics) > maxMetricsPerDirective {
+        return &ErrorInvalidMetricSettings{reason: "between 1 and 100 metrics must be declared"}
+    }
+
+    seen := map[string]bool{}
+    for _, metric := range s.Metrics {
+        if metric.Name == "" {
+            return &ErrorInvalidMetricSettings{reason: "metric name cannot be empty"}
+        }
+        if seen[metric.Name] {
+            return &ErrorInvalidMetricSettings{reason: "duplicate metric name " + metric.Name}
+        }
+        seen[metric.Name] = true
+
+        if metric.Unit != "" && !slices.Contains(validMetricUnits, metric.Unit) {
+            return &ErrorInvalidMetricSettings{reason: "invalid unit " + string(metric.Unit)}
+        }
+        if metric.StorageResolution != 0 && metric.StorageResolution != 1 && metric.StorageResolution != 60 {
+            return &ErrorInvalidMetricSettings{reason: "storage resolution must be 1 or 60"}
+        }
+    }
+
+    for _, dimensionSet := range s.Dimensions {
+        if len(dimensionSet) > maxDimensionsPerSet {
+            return &ErrorInvalidMetricSettings{reason: "a dimension set can have at most 30 dimensions"}
+        }
+        for _, dimension := range dimensionSet {
+            if seen[dimension] {
+                return &ErrorInvalidMetricSettings{reason: "dimension " + dimension + " collides with a metric name"}
+            }
+        }
+    }
+
+    return nil
+}
+
+// metricResult is the JSON result of a metric field. It keeps the settings around so that the EMFFormatter can build
+// the metric directive.
+type metricResult struct {
+    settings MetricSettings
+    data     MetricData
+}
+
+// metricDirective is a MetricDirective of the Embedded Metric Format.
+type metricDirective struct {
+    Namespace  string             `json:"Namespace"`
+    Dimensions [][]string         `json:"Dimensions"`
+    Metrics    []MetricDefinition `json:"Metrics"`
+}
+
+// directive returns the metric directive for the metrics that have a value, or nil if no metric has a value.
+func (r *metricResult) directive() *metricDirective {
+    d := &metricDirective{
+        Namespace:  r.settings.Namespace,
+        Dimensions: r.settings.Dimensions,
+        Metrics:    []MetricDefinition{},
+    }
+    if d.Dimensions == nil {
+        d.Dimensions = [][]string{}
+    }
+
+    for _, metric := range r.settings.Metrics {
+        if _, ok := r.data.Values[metric.Name]; !ok {
+            continue
+        }
+        if metric.Unit == "" {
+            metric.Unit = MetricUnitNone
+        }
+        d.Metrics = append(d.Metrics, metric)
+    }
+
+    if len(d.Metrics) == 0 {
+        return nil
+    }
+    return d
+}
+
+// members returns the dimension and metric values that belong at the top level of the log line. An
+// ErrorMissingMetricDimension is returned if a declared dimension has no value.
+func (r *metricResult) members() (map[string]any, error) {
+    members := map[string]any{}
+
+    for _, dimensionSet := range r.settings.Dimensions {
+        for _, dimension := range dimensionSet {
+            value, ok := r.data.Dimensions[dimension]
+            if !ok {
+                return nil, &ErrorMissingMetricDimension{dimension: dimension}
+            }
+            members[dimension] = value
+        }
+    }
+
+    for _, metric := range r.settings.Metrics {
+        if value, ok := r.data.Values[metric.Name]; ok {
+            members[metric.Name] = value
+        }
+    }
+
+    return members, nil
+}
+
+// MarshalJSON encodes the dimension and metric values as a flat object, which is how the metrics are rendered by
+// formatters that do not understand the Embedded Metric Format.
+func (r *metricResult) MarshalJSON() ([]byte, error) {
+    members, err := r.members()
+    if err != nil {
+        return nil, err
+    }
+    return json.Marshal(members)
+}
+
+func (r *metricResult) String() string {
+    members, err := r.members()
+    if err != nil {
+        return err.Error()
+    }
+
+    parts := make([]string, 0, len(members))
+    for _, name := range slices.Sorted(maps.Keys(members)) {
+        switch v := members[name].(type) {
+        case float64:
+            parts = append(parts, name+"="+strconv.FormatFloat(v, 'f', -1, 64))
+        case string:
+            parts = append(parts, name+"="+v)
+        }
+    }
+    return strings.Join(parts, " ")
+}
@@ -0,0 +1,92 @@
+package ultralogger
+
+import "sync"
+
+// PerLineField is implemented by fields whose FieldFormatter must be created for every log line, e.g. because the
+// FieldFormatter keeps state that must not be shared between lines. Formatters create the FieldFormatter of every other
+// field once, and reuse it for every line.
+type PerLineField interface {
+    Field
+    // PerLine reports whether a new FieldFormatter must be created for every log line.
+    PerLine() bool
+}
+
+// fieldPlan is the compiled form of the fields of a formatter, which holds the FieldFormatter of every field so that it
+// doesn't have to be created for every log line.
+type fieldPlan struct {
+    fields []Field
+    // formatters are the FieldFormatters of the fields, by index. Fields that are formatted per line have a nil entry.
+    formatters []FieldFormatter
+}
+
+// compileFieldPlan creates the FieldFormatters of the fields. An ErrorFieldFormatterInit is returned if any of them
+// can't be created.
+func compileFieldPlan(fields []Field) (*fieldPlan, error) {
+    plan := &fieldPlan{
+        fields:     fields,
+        formatters: make([]FieldFormatter, len(fields)),
+    }
+
+    for i, field := range fields {
+        if perLineField, ok := field.(PerLineField); ok && perLineField.PerLine() {
+            continue
+        }
+
+        fieldFormatter, err := field.NewFieldFormatter()
+        if err != nil {
+            return nil, &ErrorFieldFormatterInit{field: field, err: err}
+        }
+        plan.formatters[i] = fieldFormatter
+    }
+
+    return plan, nil
+}
+
+// result computes the result of the i-th field, with the same semantics as computeFieldResult. The result is redacted
+// if the line is formatted by a RedactingFormatter.
+func (p *fieldPlan) result(i int, args LogLineArgs, data any) (*FieldResult, error) {
+    results, err := args.redaction.resultsAhead(p, i, func(j int) ([]FieldResult, error) {
+        fieldResult, err := p.redactedResult(j, args, data)
+        if err != nil || fieldResult == nil {
+            return nil, err
+        }
+        return []FieldResult{*fieldResult}, nil
+    })
+    switch {
+    case err != nil:
+        return nil, err
+    case results == nil:
+        return p.redactedResult(i, args, data)
+    case len(results) == 0:
+        return nil, nil
+    }
+    return &results[0], nil
+}
+
+func (p *fieldPlan) redactedResult(i int, args LogLineArgs, data any) (*FieldResult, error) {
+    if p.formatters[i] == nil {
+        fieldResult, err := computeFieldResult(p.fields[i], args, data)
+        if err != nil {
+            return nil, err
+        }
+        return args.redaction.redactResult(fieldResult), nil
+    }
+    return args.redaction.redactResult(fieldFormatterResult(p.formatters[i], args, data)), nil
+}
+
+// fieldPlanCache holds the fieldPlan of a formatter. The plan is compiled by the constructor of the formatter, or on
+// the first log line for formatters that are created as struct literals. The fields of a formatter must not be changed
+// once its plan is compiled.
+type fieldPlanCache struct {
+    once sync.Once
+    plan *fieldPlan
+    err  error
+}
+
+// get returns the plan of the fields, compiling it on the first call.
+func (c *fieldPlanCache) get(fields []Field) (*fieldPlan, error) {
+    c.once.Do(func() {
+        c.plan, c.err = compileFieldPlan(fields)
+    })
+    return c.plan, c.err
+}
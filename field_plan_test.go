@@ -0,0 +1,123 @@
+package ultralogger
+
+import (
+    "errors"
+    "testing"
+)
+
+// countingField counts the FieldFormatters it creates.
+type countingField struct {
+    perLine bool
+    created int
+}
+
+func (f *countingField) NewFieldFormatter() (FieldFormatter, error) {
+    f.created++
+    return func(args LogLineArgs, data any) (FieldResult, error) {
+        return FieldResult{Name: "count", Data: f.created}, nil
+    }, nil
+}
+
+func (f *countingField) FieldName() string {
+    return "count"
+}
+
+func (f *countingField) PerLine() bool {
+    return f.perLine
+}
+
+func TestNewFormatter_FieldFormatterInit(t *testing.T) {
+    for _, outputFormat := range []OutputFormat{OutputFormatJSON, OutputFormatText} {
+        t.Run(string(outputFormat), func(t *testing.T) {
+            _, err := NewFormatter(outputFormat, []Field{NewMessageField(), invalidField{}})
+
+            var initErr *ErrorFieldFormatterInit
+            if !errors.As(err, &initErr) {
+                t.Errorf("NewFormatter() error = %v, want ErrorFieldFormatterInit", err)
+            }
+        })
+    }
+}
+
+func TestNewFormatters_FieldFormatterInit(t *testing.T) {
+    fields := []Field{NewMessageField(), invalidField{}}
+    constructorErr := func(_ any, err error) error { return err }
+
+    tests := []struct {
+        name string
+        err  error
+    }{
+        {name: "ECS", err: constructorErr(NewECSFormatter(fields))},
+        {name: "EMF", err: constructorErr(NewEMFFormatter(fields))},
+        {name: "GCP", err: constructorErr(NewGCPFormatter(fields, GCPFormatterSettings{}))},
+        {name: "OTLP", err: constructorErr(NewOTLPFormatter(fields, OTLPFormatterSettings{}))},
+        {name: "Pretty", err: constructorErr(NewPrettyFormatter(fields, PrettyFormatterSettings{}))},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            var initErr *ErrorFieldFormatterInit
+            if !errors.As(tt.err, &initErr) {
+                t.Errorf("New%sFormatter() error = %v, want ErrorFieldFormatterInit", tt.name, tt.err)
+            }
+        })
+    }
+}
+
+func TestFormatter_fieldPlan(t *testing.T) {
+    tests := []struct {
+        name        string
+        perLine     bool
+        lines       int
+        wantCreated int
+    }{
+        {
+            name:        "Cached",
+            perLine:     false,
+            lines:       3,
+            wantCreated: 1,
+        },
+        {
+            name:        "Per line",
+            perLine:     true,
+            lines:       3,
+            wantCreated: 3,
+        },
+    }
+    for _, tt := range tests {
+        for _, outputFormat := range []OutputFormat{OutputFormatJSON, OutputFormatText} {
+            t.Run(tt.name+" "+string(outputFormat), func(t *testing.T) {
+                field := &countingField{perLine: tt.perLine}
+
+                f, err := NewFormatter(outputFormat, []Field{field})
+                if err != nil {
+                    t.Fatalf("NewFormatter() error = %v", err)
+                }
+
+                for range tt.lines {
+                    if res := f.FormatLogLine(LogLineArgs{Level: Info}, "test"); res.err != nil {
+                        t.Fatalf("FormatLogLine() error = %v", res.err)
+                    }
+                }
+
+                if field.created != tt.wantCreated {
+                    t.Errorf("NewFieldFormatter() called %d times, want %d", field.created, tt.wantCreated)
+                }
+            })
+        }
+    }
+}
+
+func TestFormatter_fieldPlanStructLiteral(t *testing.T) {
+    field := &countingField{}
+    f := &JSONFormatter{Fields: []Field{field}}
+
+    for range 3 {
+        if res := f.FormatLogLine(LogLineArgs{Level: Info}, "test"); res.err != nil {
+            t.Fatalf("FormatLogLine() error = %v", res.err)
+        }
+    }
+
+    if field.created != 1 {
+        t.Errorf("NewFieldFormatter() called %d times, want 1", field.created)
+    }
+}
@@ -0,0 +1,313 @@
+package ultralogger
+
+import (
+    "errors"
+    "fmt"
+    "reflect"
+)
+
+const defaultNilPlaceholder = "<nil>"
+
+// NilPolicy defines how a field with nil data is written. Data is nil if the FieldFormatter of the field returns nil
+// data, or if nil is logged and the field rejects it.
+type NilPolicy int
+
+const (
+    // NilPolicyDefault keeps the behavior of the formatter: the JSONFormatter omits fields with nil data, while the
+    // TextFormatter writes "<nil>". A logged nil that the field rejects is handled by the MismatchPolicy. On a field,
+    // NilPolicyDefault uses the policy of the formatter.
+    NilPolicyDefault NilPolicy = iota
+    // NilPolicyOmit omits the field.
+    NilPolicyOmit
+    // NilPolicyNull writes null in JSON, and "null" in text.
+    NilPolicyNull
+    // NilPolicyZero writes the zero value of the type of the field, e.g. "", 0, false, [] or {}. Fields whose type
+    // is not known, or whose zero value is nil, are written as with NilPolicyNull.
+    NilPolicyZero
+    // NilPolicyPlaceholder writes the placeholder of the FieldPolicies, or "<nil>" if it is empty.
+    NilPolicyPlaceholder
+)
+
+// MismatchPolicy defines how a field is written when the logged data is not of the type of the field, i.e. when its
+// FieldFormatter returns an ErrorInvalidFieldDataType.
+type MismatchPolicy int
+
+const (
+    // MismatchPolicyDefault omits the field. On a field, MismatchPolicyDefault uses the policy of the formatter.
+    MismatchPolicyDefault MismatchPolicy = iota
+    // MismatchPolicyOmit omits the field.
+    MismatchPolicyOmit
+    // MismatchPolicyRender writes the logged data, formatted with %v.
+    MismatchPolicyRender
+    // MismatchPolicyAnnotate omits the field, and writes a "<name>_error" field with the type of the logged data
+    // instead.
+    //
+    // Note that log lines usually carry data for only some of the fields of a formatter, so annotating every field of
+    // a formatter is rarely useful; prefer annotating the fields that are expected on every line with
+    // [NewPolicyField].
+    MismatchPolicyAnnotate
+)
+
+// FieldPolicies are the policies for nil data and for data of the wrong type. They are set for all fields of a
+// formatter with [WithNilPolicy], [WithNilPlaceholder] and [WithMismatchPolicy], and can be overridden per field with
+// [NewPolicyField]. Zero values use the policies of the formatter.
+//
+// Policies are applied by the formatters returned by [NewFormatter].
+type FieldPolicies struct {
+    Nil            NilPolicy
+    NilPlaceholder string
+    Mismatch       MismatchPolicy
+}
+
+// override returns the policies, with the non-zero policies of the override taking precedence.
+func (p FieldPolicies) override(override FieldPolicies) FieldPolicies {
+    if override.Nil != NilPolicyDefault {
+        p.Nil = override.Nil
+    }
+    if override.NilPlaceholder != "" {
+        p.NilPlaceholder = override.NilPlaceholder
+    }
+    if override.Mismatch != MismatchPolicyDefault {
+        p.Mismatch = override.Mismatch
+    }
+    return p
+}
+
+// PolicyField is implemented by fields that override the FieldPolicies of the formatter.
+type PolicyField interface {
+    Field
+    // FieldPolicies returns the policies of the field. Zero values use the policies of the formatter.
+    FieldPolicies() FieldPolicies
+}
+
+// NewPolicyField returns a Field that formats like the provided field, with policies that override the policies of the
+// formatter.
+func NewPolicyField(field Field, policies FieldPolicies) Field {
+    return &policyField{Field: field, policies: policies}
+}
+
+type policyField struct {
+    Field
+    policies FieldPolicies
+}
+
+func (f *policyField) FieldPolicies() FieldPolicies {
+    return f.policies
+}
+
+func (f *policyField) FieldName() string {
+    name, _ := fieldName(f.Field)
+    return name
+}
+
+func (f *policyField) PerLine() bool {
+    perLineField, ok := f.Field.(PerLineField)
+    return ok && perLineField.PerLine()
+}
+
+func (f *policyField) zeroData() (any, bool) {
+    zeroField, ok := f.Field.(zeroDataField)
+    if !ok {
+        return nil, false
+    }
+    return zeroField.zeroData()
+}
+
+// zeroDataField is implemented by fields that know the type of their data, and can provide its zero value for
+// NilPolicyZero.
+type zeroDataField interface {
+    zeroData() (any, bool)
+}
+
+// zeroData returns the zero value of T. Slices and maps are returned empty rather than nil, so that they are written
+// as [] and {}. false is returned if the zero value of T is nil.
+func (f ObjectField[T]) zeroData() (any, bool) {
+    t := reflect.TypeFor[T]()
+
+    switch t.Kind() {
+    case reflect.Slice:
+        return reflect.MakeSlice(t, 0, 0).Interface(), true
+    case reflect.Map:
+        return reflect.MakeMap(t).Interface(), true
+    case reflect.Pointer, reflect.Interface, reflect.Func, reflect.Chan, reflect.UnsafePointer:
+        return nil, false
+    }
+
+    var zero T
+    return zero, true
+}
+
+// nullValue is written as null in JSON, and as "null" in text.
+type nullValue struct{}
+
+func (nullValue) MarshalJSON() ([]byte, error) {
+    return []byte("null"), nil
+}
+
+func (nullValue) String() string {
+    return "null"
+}
+
+// appendPolicyResults computes the result of the i-th field with the policies applied, and appends the results to
+// results. A field can produce no result, the result of the field, or an annotation of the field. The results are
+// redacted if the line is formatted by a RedactingFormatter.
+//
+// With NilPolicyDefault, results with nil data are appended as is, so that the formatter can apply its own handling.
+func (p *fieldPlan) appendPolicyResults(
+    results []FieldResult,
+    i int,
+    args LogLineArgs,
+    data any,
+    policies FieldPolicies,
+) ([]FieldResult, error) {
+    ahead, err := args.redaction.resultsAhead(p, i, func(j int) ([]FieldResult, error) {
+        return p.appendRedactedPolicyResults(nil, j, args, data, policies)
+    })
+    switch {
+    case err != nil:
+        return nil, err
+    case ahead != nil:
+        return append(results, ahead...), nil
+    }
+    return p.appendRedactedPolicyResults(results, i, args, data, policies)
+}
+
+func (p *fieldPlan) appendRedactedPolicyResults(
+    results []FieldResult,
+    i int,
+    args LogLineArgs,
+    data any,
+    policies FieldPolicies,
+) ([]FieldResult, error) {
+    n := len(results)
+
+    results, err := p.appendUnredactedPolicyResults(results, i, args, data, policies)
+    if err != nil {
+        return nil, err
+    }
+    return args.redaction.redactResults(results, n), nil
+}
+
+func (p *fieldPlan) appendUnredactedPolicyResults(
+    results []FieldResult,
+    i int,
+    args LogLineArgs,
+    data any,
+    policies FieldPolicies,
+) ([]FieldResult, error) {
+    field := p.fields[i]
+    if policyField, ok := field.(PolicyField); ok {
+        policies = policies.override(policyField.FieldPolicies())
+    }
+
+    fieldFormatter := p.formatters[i]
+    if fieldFormatter == nil {
+        var err error
+        fieldFormatter, err = field.NewFieldFormatter()
+        if err != nil {
+            return nil, &ErrorFieldFormatterInit{field: field, err: err}
+        }
+    }
+
+    fieldResult, err := fieldFormatter(args, data)
+    if err != nil {
+        var invalidDataTypeError *ErrorInvalidFieldDataType
+        if !errors.As(err, &invalidDataTypeError) {
+            // Other errors are ignored, the same as computeFieldResult does.
+            return append(results, fieldResult), nil
+        }
+
+        if data == nil && policies.Nil != NilPolicyDefault {
+            return appendNilResult(results, field, fieldResult.Name, policies), nil
+        }
+
+        switch policies.Mismatch {
+        case MismatchPolicyRender:
+            return append(results, FieldResult{Name: fieldResult.Name, Data: fmt.Sprintf("%v", data)}), nil
+        case MismatchPolicyAnnotate:
+            return append(results, FieldResult{
+                Name: fieldResult.Name + "_error",
+                Data: fmt.Sprintf("invalid data type %T", data),
+            }), nil
+        default:
+            return results, nil
+        }
+    }
+
+    if fieldResult.Data == nil && policies.Nil != NilPolicyDefault {
+        return appendNilResult(results, field, fieldResult.Name, policies), nil
+    }
+
+    return append(results, fieldResult), nil
+}
+
+// appendNilResult appends the result of a field with nil data, according to the nil policy.
+func appendNilResult(results []FieldResult, field Field, name string, policies FieldPolicies) []FieldResult {
+    switch policies.Nil {
+    case NilPolicyOmit:
+        return results
+    case NilPolicyZero:
+        if zeroField, ok := field.(zeroDataField); ok {
+            if zero, ok := zeroField.zeroData(); ok {
+                return append(results, FieldResult{Name: name, Data: zero})
+            }
+        }
+    case NilPolicyPlaceholder:
+        placeholder := policies.NilPlaceholder
+        if placeholder == "" {
+            placeholder = defaultNilPlaceholder
+        }
+        return append(results, FieldResult{Name: name, Data: placeholder})
+    }
+
+    return append(results, FieldResult{Name: name, Data: nullValue{}})
+}
+
+// WithNilPolicy sets the NilPolicy of the fields of the formatter. It applies to the formatters returned by
+// [NewFormatter], also when they are colorized.
+func WithNilPolicy(policy NilPolicy) FormatterOption {
+    return func(f LogLineFormatter) LogLineFormatter {
+        return withFieldPolicies(f, func(policies *FieldPolicies) {
+            policies.Nil = policy
+        })
+    }
+}
+
+// WithNilPlaceholder sets the NilPolicy of the fields of the formatter to NilPolicyPlaceholder, with the provided
+// placeholder.
+func WithNilPlaceholder(placeholder string) FormatterOption {
+    return func(f LogLineFormatter) LogLineFormatter {
+        return withFieldPolicies(f, func(policies *FieldPolicies) {
+            policies.Nil = NilPolicyPlaceholder
+            policies.NilPlaceholder = placeholder
+        })
+    }
+}
+
+// WithMismatchPolicy sets the MismatchPolicy of the fields of the formatter. It applies to the formatters returned by
+// [NewFormatter], also when they are colorized.
+func WithMismatchPolicy(policy MismatchPolicy) FormatterOption {
+    return func(f LogLineFormatter) LogLineFormatter {
+        return withFieldPolicies(f, func(policies *FieldPolicies) {
+            policies.Mismatch = policy
+        })
+    }
+}
+
+func withFieldPolicies(f LogLineFormatter, apply func(policies *FieldPolicies)) LogLineFormatter {
+    switch formatter := f.(type) {
+    case *JSONFormatter:
+        apply(&formatter.Policies)
+    case *TextFormatter:
+        apply(&formatter.Policies)
+    case *CBORFormatter:
+        apply(&formatter.Policies)
+    case *MsgPackFormatter:
+        apply(&formatter.Policies)
+    case *ColorizedFormatter:
+        withFieldPolicies(formatter.BaseFormatter, apply)
+    case *RedactingFormatter:
+        withFieldPolicies(formatter.BaseFormatter, apply)
+    }
+    return f
+}
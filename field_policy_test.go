@@ -0,0 +1,191 @@
+package ultralogger
+
+import (
+    "os"
+    "testing"
+)
+
+func ExampleWithMismatchPolicy() {
+    userField, _ := NewStringField("user")
+
+    formatter, _ := NewFormatter(
+        OutputFormatJSON,
+        []Field{NewMessageField(), NewPolicyField(userField, FieldPolicies{Mismatch: MismatchPolicyAnnotate})},
+        WithMismatchPolicy(MismatchPolicyRender),
+    )
+
+    logger, _ := NewLoggerWithOptions(WithDestination(os.Stdout, formatter), WithAsync(false))
+
+    logger.Info(42)
+    // Output: {"message":"42","user_error":"invalid data type int"}
+}
+
+func TestFieldPolicies(t *testing.T) {
+    type address struct {
+        City string `json:"city"`
+    }
+
+    nameField, _ := NewStringField("name")
+    countField, _ := NewIntField("count")
+    tagsField, _ := NewArrayField[string]("tags", func(args LogLineArgs, data string) any {
+        return data
+    })
+    addressField, _ := NewObjectField[*address]("address", func(args LogLineArgs, data *address) any {
+        return data
+    })
+    nilField, _ := NewObjectField[string]("nil", func(args LogLineArgs, data string) any {
+        return nil
+    })
+
+    tests := []struct {
+        name         string
+        outputFormat OutputFormat
+        fields       []Field
+        opts         []FormatterOption
+        data         any
+        want         string
+    }{
+        {
+            name:         "JSON default nil data",
+            outputFormat: OutputFormatJSON,
+            fields:       []Field{nilField, nameField},
+            data:         "john",
+            want:         `{"name":"john"}`,
+        },
+        {
+            name:         "Text default nil data",
+            outputFormat: OutputFormatText,
+            fields:       []Field{nilField, nameField},
+            data:         "john",
+            want:         `<nil> john`,
+        },
+        {
+            name:         "Text default nil logged",
+            outputFormat: OutputFormatText,
+            fields:       []Field{nameField, countField},
+            data:         nil,
+            want:         ``,
+        },
+        {
+            name:         "JSON null",
+            outputFormat: OutputFormatJSON,
+            fields:       []Field{nilField, nameField, countField},
+            opts:         []FormatterOption{WithNilPolicy(NilPolicyNull)},
+            data:         nil,
+            want:         `{"nil":null,"name":null,"count":null}`,
+        },
+        {
+            name:         "Text null",
+            outputFormat: OutputFormatText,
+            fields:       []Field{nilField, nameField},
+            opts:         []FormatterOption{WithNilPolicy(NilPolicyNull)},
+            data:         "john",
+            want:         `null john`,
+        },
+        {
+            name:         "Text omit",
+            outputFormat: OutputFormatText,
+            fields:       []Field{nilField, nameField},
+            opts:         []FormatterOption{WithNilPolicy(NilPolicyOmit)},
+            data:         "john",
+            want:         `john`,
+        },
+        {
+            name:         "JSON zero",
+            outputFormat: OutputFormatJSON,
+            fields:       []Field{nameField, countField, tagsField, addressField, NewMessageField()},
+            opts:         []FormatterOption{WithNilPolicy(NilPolicyZero)},
+            data:         nil,
+            want:         `{"message":"","name":"","count":0,"tags":[],"address":null}`,
+        },
+        {
+            name:         "JSON placeholder",
+            outputFormat: OutputFormatJSON,
+            fields:       []Field{nameField},
+            opts:         []FormatterOption{WithNilPlaceholder("-")},
+            data:         nil,
+            want:         `{"name":"-"}`,
+        },
+        {
+            name:         "JSON default placeholder",
+            outputFormat: OutputFormatJSON,
+            fields:       []Field{nameField},
+            opts:         []FormatterOption{WithNilPolicy(NilPolicyPlaceholder)},
+            data:         nil,
+            want:         `{"name":"\u003cnil\u003e"}`,
+        },
+        {
+            name:         "JSON mismatch default",
+            outputFormat: OutputFormatJSON,
+            fields:       []Field{nameField, countField},
+            data:         42,
+            want:         `{"count":42}`,
+        },
+        {
+            name:         "JSON mismatch render",
+            outputFormat: OutputFormatJSON,
+            fields:       []Field{nameField, countField},
+            opts:         []FormatterOption{WithMismatchPolicy(MismatchPolicyRender)},
+            data:         42,
+            want:         `{"name":"42","count":42}`,
+        },
+        {
+            name:         "Text mismatch annotate",
+            outputFormat: OutputFormatText,
+            fields:       []Field{nameField, countField},
+            opts:         []FormatterOption{WithMismatchPolicy(MismatchPolicyAnnotate)},
+            data:         42,
+            want:         `invalid data type int 42`,
+        },
+        {
+            name:         "Field overrides formatter",
+            outputFormat: OutputFormatJSON,
+            fields: []Field{
+                NewPolicyField(nameField, FieldPolicies{Nil: NilPolicyOmit}),
+                NewPolicyField(countField, FieldPolicies{NilPlaceholder: "none"}),
+            },
+            opts: []FormatterOption{WithNilPolicy(NilPolicyPlaceholder)},
+            data: nil,
+            want: `{"count":"none"}`,
+        },
+        {
+            name:         "Field mismatch override",
+            outputFormat: OutputFormatJSON,
+            fields: []Field{
+                NewPolicyField(nameField, FieldPolicies{Mismatch: MismatchPolicyOmit}),
+                countField,
+            },
+            opts: []FormatterOption{WithMismatchPolicy(MismatchPolicyAnnotate)},
+            data: true,
+            want: `{"count_error":"invalid data type bool"}`,
+        },
+        {
+            name:         "Colorized formatter",
+            outputFormat: OutputFormatText,
+            fields:       []Field{nameField},
+            opts: []FormatterOption{
+                WithColorization(map[Level]Color{Info: Colors.Default}),
+                WithNilPolicy(NilPolicyNull),
+            },
+            data: nil,
+            want: string(Colors.Default.Colorize([]byte("null"))),
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            f, err := NewFormatter(tt.outputFormat, tt.fields, tt.opts...)
+            if err != nil {
+                t.Fatalf("NewFormatter() error = %v", err)
+            }
+
+            res := f.FormatLogLine(LogLineArgs{Level: Info}, tt.data)
+            if res.err != nil {
+                t.Fatalf("FormatLogLine() error = %v", res.err)
+            }
+
+            if string(res.bytes) != tt.want {
+                t.Errorf("FormatLogLine() = %s, want %s", res.bytes, tt.want)
+            }
+        })
+    }
+}
@@ -0,0 +1,167 @@
+package ultralogger
+
+import (
+    "bytes"
+    "context"
+    "io"
+    "maps"
+    "net"
+    "net/http"
+    "slices"
+    "strconv"
+    "strings"
+)
+
+// sensitiveHeaders are the headers whose values are always redacted by [NewRequestField] and [NewResponseField], also
+// when they are allowed.
+var sensitiveHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization", "Set-Cookie"}
+
+// filterHeaders returns the headers that are in the allowlist, or every header if it is empty, and not in the
+// denylist, with the values of sensitive headers redacted. Returns nil if no header is allowed.
+func filterHeaders(header http.Header, allowlist, denylist []string) map[string][]string {
+    var headers map[string][]string
+    for name, values := range header {
+        name = http.CanonicalHeaderKey(name)
+        if !headerListed(allowlist, name, true) || headerListed(denylist, name, false) {
+            continue
+        }
+
+        if slices.Contains(sensitiveHeaders, name) {
+            redacted := make([]string, len(values))
+            for i := range values {
+                redacted[i] = redactedPlaceholder
+            }
+            values = redacted
+        }
+
+        if headers == nil {
+            headers = map[string][]string{}
+        }
+        headers[name] = values
+    }
+    return headers
+}
+
+// headerListed reports whether the header is in the list, ignoring case. An empty list returns ifEmpty.
+func headerListed(list []string, name string, ifEmpty bool) bool {
+    if len(list) == 0 {
+        return ifEmpty
+    }
+    return slices.ContainsFunc(list, func(listed string) bool {
+        return strings.EqualFold(listed, name)
+    })
+}
+
+// appendHeaderParts appends the headers as name="values" parts of a text entry, sorted by name.
+func appendHeaderParts(parts []string, headers map[string][]string) []string {
+    for _, name := range slices.Sorted(maps.Keys(headers)) {
+        parts = append(parts, name+"="+strconv.Quote(strings.Join(headers[name], ", ")))
+    }
+    return parts
+}
+
+// requestClientIP returns the IP of the client that issued the request: the first "for" parameter of the Forwarded
+// header, the first address of the X-Forwarded-For header, or the host of the RemoteAddr.
+//
+// See https://www.rfc-editor.org/rfc/rfc7239 for more information.
+func requestClientIP(r *http.Request) string {
+    if forwarded := r.Header.Get("Forwarded"); forwarded != "" {
+        element, _, _ := strings.Cut(forwarded, ",")
+        for _, pair := range strings.Split(element, ";") {
+            key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+            if ok && strings.EqualFold(key, "for") {
+                if ip := forwardedNodeIP(strings.Trim(value, `"`)); ip != "" {
+                    return ip
+                }
+            }
+        }
+    }
+
+    if forwardedFor := r.Header.Get("X-Forwarded-For"); forwardedFor != "" {
+        first, _, _ := strings.Cut(forwardedFor, ",")
+        if ip := forwardedNodeIP(strings.TrimSpace(first)); ip != "" {
+            return ip
+        }
+    }
+
+    if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+        return host
+    }
+    return r.RemoteAddr
+}
+
+// forwardedNodeIP returns the IP of a forwarded node, e.g. "192.0.2.60", "192.0.2.60:47011" or "[2001:db8::1]:4711".
+// Returns an empty string for obfuscated and unknown nodes.
+func forwardedNodeIP(node string) string {
+    if host, _, err := net.SplitHostPort(node); err == nil {
+        node = host
+    }
+    node = strings.TrimSuffix(strings.TrimPrefix(node, "["), "]")
+
+    if net.ParseIP(node) == nil {
+        return ""
+    }
+    return node
+}
+
+type requestBodyKey struct{}
+
+// requestBody is the start of the body of a request, captured by CaptureRequestBody.
+type requestBody struct {
+    body      string
+    truncated bool
+}
+
+// CaptureRequestBody reads up to maxSize bytes of the body of the request, and returns a copy of the request whose
+// context carries them, for the Body field of [NewRequestField]. The body of the copy replays the captured bytes, so
+// that the handler still reads the whole body. The request is returned as is if maxSize is 0 or it has no body.
+//
+// It must be called on the request path, before the handler reads the body, e.g. by [HTTPMiddleware] with
+// [WithRequestBody]; the fields never read the body of a request themselves.
+func CaptureRequestBody(r *http.Request, maxSize int) *http.Request {
+    if maxSize <= 0 || r.Body == nil || r.Body == http.NoBody {
+        return r
+    }
+
+    body, captured, truncated := captureBody(r.Body, maxSize)
+    ctx := context.WithValue(r.Context(), requestBodyKey{}, requestBody{body: captured, truncated: truncated})
+
+    r = r.WithContext(ctx)
+    r.Body = body
+    return r
+}
+
+// requestBodyFromContext returns the start of the body of the request carried by the context, truncated to maxSize
+// bytes, and reports whether the body is longer.
+func requestBodyFromContext(ctx context.Context, maxSize int) (string, bool) {
+    captured, ok := ctx.Value(requestBodyKey{}).(requestBody)
+    if !ok {
+        return "", false
+    }
+
+    if len(captured.body) > maxSize {
+        return captured.body[:maxSize], true
+    }
+    return captured.body, captured.truncated
+}
+
+// captureBody reads up to maxSize bytes of the body, and reports whether the body is longer. The returned body replays
+// the captured bytes before the rest of the body.
+func captureBody(body io.ReadCloser, maxSize int) (io.ReadCloser, string, bool) {
+    captured, _ := io.ReadAll(io.LimitReader(body, int64(maxSize)+1))
+    replayed := &replayedBody{
+        Reader: io.MultiReader(bytes.NewReader(captured), body),
+        Closer: body,
+    }
+
+    if len(captured) > maxSize {
+        return replayed, string(captured[:maxSize]), true
+    }
+    return replayed, string(captured), false
+}
+
+// replayedBody is a request body that replays the bytes that were captured before the rest of the original body.
+type replayedBody struct {
+    io.Reader
+    io.Closer
+}
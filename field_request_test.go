@@ -0,0 +1,318 @@
+package ultralogger
+
+import (
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "strings"
+    "testing"
+    "time"
+)
+
+func ExampleNewRequestField() {
+    requestField, _ := NewRequestField("request", RequestFieldSettings{
+        LogMethod:       true,
+        LogPath:         true,
+        LogQuery:        true,
+        LogClientIP:     true,
+        LogUserAgent:    true,
+        LogHeaders:      true,
+        HeaderAllowlist: []string{"Authorization", "Accept"},
+    })
+
+    formatter, _ := NewFormatter(OutputFormatJSON, []Field{requestField})
+
+    logger, _ := NewLoggerWithOptions(WithDestination(os.Stdout, formatter), WithAsync(false))
+
+    request := httptest.NewRequest(http.MethodGet, "/orders?page=2", nil)
+    request.Header.Set("Authorization", "Bearer secret")
+    request.Header.Set("Accept", "application/json")
+    request.Header.Set("User-Agent", "curl/8.5.0")
+    request.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.2")
+
+    logger.Info(request)
+    // Output: {"request":{"method":"GET","path":"/orders","query":{"page":["2"]},"client_ip":"203.0.113.7","user_agent":"curl/8.5.0","headers":{"Accept":["application/json"],"Authorization":["[REDACTED]"]}}}
+}
+
+func TestRequestField(t *testing.T) {
+    all := RequestFieldSettings{
+        LogMethod:        true,
+        LogHost:          true,
+        LogPath:          true,
+        LogQuery:         true,
+        LogProto:         true,
+        LogSourceIP:      true,
+        LogClientIP:      true,
+        LogUserAgent:     true,
+        LogContentLength: true,
+    }
+
+    newRequest := func() *http.Request {
+        request := httptest.NewRequest(http.MethodPost, "http://example.com/orders?id=1&id=2", strings.NewReader("{}"))
+        request.RemoteAddr = "10.0.0.1:1234"
+        request.Header.Set("User-Agent", "test agent")
+        request.Header.Set("Cookie", "session=abc")
+        request.Header.Set("Accept", "*/*")
+        request.Header.Set("X-Request-Id", "42")
+        return request
+    }
+
+    tests := []struct {
+        name         string
+        settings     RequestFieldSettings
+        outputFormat OutputFormat
+        want         string
+    }{
+        {
+            name:         "JSON",
+            settings:     all,
+            outputFormat: OutputFormatJSON,
+            want: `{"request":{"method":"POST","host":"example.com","path":"/orders","query":{"id":["1","2"]},` +
+                `"proto":"HTTP/1.1","source_ip":"10.0.0.1:1234","client_ip":"10.0.0.1",` +
+                `"user_agent":"test agent","content_length":2}}`,
+        },
+        {
+            name:         "Text",
+            settings:     all,
+            outputFormat: OutputFormatText,
+            want:         `POST example.com /orders ?id=1&id=2 HTTP/1.1 10.0.0.1:1234 10.0.0.1 "test agent" 2`,
+        },
+        {
+            name: "Headers",
+            settings: RequestFieldSettings{
+                LogHeaders:     true,
+                HeaderDenylist: []string{"user-agent", "x-request-id"},
+            },
+            outputFormat: OutputFormatJSON,
+            want:         `{"request":{"headers":{"Accept":["*/*"],"Cookie":["[REDACTED]"]}}}`,
+        },
+        {
+            name:         "Header allowlist",
+            settings:     RequestFieldSettings{LogHeaders: true, HeaderAllowlist: []string{"x-request-id"}},
+            outputFormat: OutputFormatText,
+            want:         `X-Request-Id="42"`,
+        },
+        {
+            name:         "Body",
+            settings:     RequestFieldSettings{MaxBodySize: 16},
+            outputFormat: OutputFormatJSON,
+            want:         `{"request":{"body":"{}"}}`,
+        },
+        {
+            name:         "No settings",
+            outputFormat: OutputFormatJSON,
+            want:         `{"request":{}}`,
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            requestField, _ := NewRequestField("request", tt.settings)
+            f, _ := NewFormatter(tt.outputFormat, []Field{requestField})
+
+            res := f.FormatLogLine(LogLineArgs{Level: Info}, CaptureRequestBody(newRequest(), 16))
+            if res.err != nil {
+                t.Fatalf("FormatLogLine() error = %v", res.err)
+            }
+
+            if string(res.bytes) != tt.want {
+                t.Errorf("FormatLogLine() = %s, want %s", res.bytes, tt.want)
+            }
+        })
+    }
+}
+
+func TestRequestField_body(t *testing.T) {
+    requestField, _ := NewRequestField("request", RequestFieldSettings{MaxBodySize: 4})
+    fieldFormatter, _ := requestField.NewFieldFormatter()
+
+    tests := []struct {
+        name          string
+        maxSize       int
+        wantBody      string
+        wantTruncated bool
+    }{
+        {name: "Truncated by the field", maxSize: 16, wantBody: "0123", wantTruncated: true},
+        {name: "Truncated by the capture", maxSize: 2, wantBody: "01", wantTruncated: true},
+        {name: "Not captured", maxSize: 0},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            request := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader("0123456789"))
+            request = CaptureRequestBody(request, tt.maxSize)
+            body := request.Body
+
+            result, err := fieldFormatter(LogLineArgs{OutputFormat: OutputFormatJSON}, request)
+            if err != nil {
+                t.Fatalf("FieldFormatter() error = %v", err)
+            }
+
+            entry := result.Data.(RequestLogEntry)
+            if entry.Body != tt.wantBody || entry.BodyTruncated != tt.wantTruncated {
+                t.Errorf("FieldFormatter() body = %q, truncated = %v, want %q, %v",
+                    entry.Body, entry.BodyTruncated, tt.wantBody, tt.wantTruncated)
+            }
+            if request.Body != body {
+                t.Errorf("FieldFormatter() replaced the body of the request")
+            }
+
+            read, _ := io.ReadAll(request.Body)
+            if string(read) != "0123456789" {
+                t.Errorf("ReadAll() = %s, want the whole body", read)
+            }
+            if err := request.Body.Close(); err != nil {
+                t.Errorf("Close() error = %v", err)
+            }
+        })
+    }
+}
+
+func TestRequestClientIP(t *testing.T) {
+    tests := []struct {
+        name    string
+        headers map[string]string
+        want    string
+    }{
+        {name: "Remote address", want: "10.0.0.1"},
+        {
+            name:    "X-Forwarded-For",
+            headers: map[string]string{"X-Forwarded-For": "203.0.113.7, 10.0.0.2"},
+            want:    "203.0.113.7",
+        },
+        {
+            name:    "Forwarded",
+            headers: map[string]string{"Forwarded": "for=192.0.2.60;proto=http, for=10.0.0.2"},
+            want:    "192.0.2.60",
+        },
+        {
+            name:    "Forwarded IPv6",
+            headers: map[string]string{"Forwarded": `For="[2001:db8::1]:4711"`},
+            want:    "2001:db8::1",
+        },
+        {
+            name: "Forwarded obfuscated",
+            headers: map[string]string{
+                "Forwarded":       "for=_hidden",
+                "X-Forwarded-For": "203.0.113.7",
+            },
+            want: "203.0.113.7",
+        },
+        {name: "Invalid", headers: map[string]string{"X-Forwarded-For": "unknown"}, want: "10.0.0.1"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            request := httptest.NewRequest(http.MethodGet, "/", nil)
+            request.RemoteAddr = "10.0.0.1:1234"
+            for name, value := range tt.headers {
+                request.Header.Set(name, value)
+            }
+
+            if got := requestClientIP(request); got != tt.want {
+                t.Errorf("requestClientIP() = %s, want %s", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestResponseField(t *testing.T) {
+    all := ResponseFieldSettings{
+        LogStatus:        true,
+        LogStatusCode:    true,
+        LogPath:          true,
+        LogContentLength: true,
+        LogHeaders:       true,
+        HeaderDenylist:   []string{"date"},
+    }
+
+    request := httptest.NewRequest(http.MethodGet, "/orders", nil)
+
+    response := &http.Response{
+        Status:        "404 Not Found",
+        StatusCode:    http.StatusNotFound,
+        ContentLength: 9,
+        Header: http.Header{
+            "Content-Type": {"text/plain"},
+            "Set-Cookie":   {"session=abc"},
+            "Date":         {"Thu, 07 Nov 2024 19:30:00 GMT"},
+        },
+        Request: request,
+    }
+
+    tests := []struct {
+        name         string
+        settings     ResponseFieldSettings
+        outputFormat OutputFormat
+        data         *http.Response
+        want         string
+    }{
+        {
+            name:         "JSON",
+            settings:     all,
+            outputFormat: OutputFormatJSON,
+            data:         response,
+            want: `{"response":{"status_code":404,"status":"404 Not Found","path":"/orders","content_length":9,` +
+                `"headers":{"Content-Type":["text/plain"],"Set-Cookie":["[REDACTED]"]}}}`,
+        },
+        {
+            name:         "Text",
+            settings:     all,
+            outputFormat: OutputFormatText,
+            data:         response,
+            want:         `404 Not Found /orders 9 Content-Type="text/plain" Set-Cookie="[REDACTED]"`,
+        },
+        {
+            name:         "Text status code",
+            settings:     ResponseFieldSettings{LogStatus: true, LogStatusCode: true},
+            outputFormat: OutputFormatText,
+            data:         &http.Response{Status: "Not Found", StatusCode: http.StatusNotFound},
+            want:         `404 Not Found`,
+        },
+        {
+            name:         "Without request",
+            settings:     ResponseFieldSettings{LogStatusCode: true, LogPath: true, LogDuration: true},
+            outputFormat: OutputFormatJSON,
+            data:         &http.Response{StatusCode: http.StatusOK},
+            want:         `{"response":{"status_code":200}}`,
+        },
+        {
+            name:         "Nil",
+            settings:     all,
+            outputFormat: OutputFormatJSON,
+            data:         nil,
+            want:         `{"response":{}}`,
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            responseField, _ := NewResponseField("response", tt.settings)
+            f, _ := NewFormatter(tt.outputFormat, []Field{responseField})
+
+            res := f.FormatLogLine(LogLineArgs{Level: Info}, tt.data)
+            if res.err != nil {
+                t.Fatalf("FormatLogLine() error = %v", res.err)
+            }
+
+            if string(res.bytes) != tt.want {
+                t.Errorf("FormatLogLine() = %s, want %s", res.bytes, tt.want)
+            }
+        })
+    }
+}
+
+func TestResponseField_duration(t *testing.T) {
+    responseField, _ := NewResponseField("response", ResponseFieldSettings{LogDuration: true})
+    fieldFormatter, _ := responseField.NewFieldFormatter()
+
+    start := time.Now().Add(-time.Second)
+    request := httptest.NewRequest(http.MethodGet, "/orders", nil)
+    request = request.WithContext(ContextWithRequestStart(request.Context(), start))
+
+    result, err := fieldFormatter(LogLineArgs{OutputFormat: OutputFormatJSON}, &http.Response{Request: request})
+    if err != nil {
+        t.Fatalf("FieldFormatter() error = %v", err)
+    }
+
+    if duration := result.Data.(ResponseLogEntry).Duration; duration < time.Second || duration > time.Minute {
+        t.Errorf("FieldFormatter() duration = %v, want the time since the start", duration)
+    }
+}
@@ -0,0 +1,320 @@
+package ultralogger
+
+import (
+    "encoding"
+    "encoding/json"
+    "fmt"
+    "reflect"
+    "slices"
+    "strings"
+    "sync"
+    "time"
+)
+
+const (
+    // maxStructFieldDepth is the deepest level of nested values that a struct field walks.
+    maxStructFieldDepth = 32
+    // structCyclePlaceholder is written instead of a value that refers back to one of the values containing it.
+    structCyclePlaceholder = "<cycle>"
+    // structDepthPlaceholder is written instead of a value that is nested deeper than maxStructFieldDepth.
+    structDepthPlaceholder = "<max depth>"
+    // redactedPlaceholder is written instead of a redacted value.
+    redactedPlaceholder = "[REDACTED]"
+)
+
+var (
+    errorType         = reflect.TypeFor[error]()
+    jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+    textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// NewStructField returns a new Field that formats a struct of type T, or a pointer to one, by walking its exported
+// fields with reflection. The fields of every struct type are looked up once, and cached.
+//
+// The fields of the struct are written in declaration order, and can be configured with `ulog` tags:
+//
+//  type User struct {
+//      ID       string    `ulog:"id"`            // Written as "id".
+//      Email    string    `ulog:",redact"`       // Written as "[REDACTED]".
+//      Nickname string    `ulog:",omitempty"`    // Omitted if it is the zero value, or an empty slice or map.
+//      Address  Address   `ulog:",inline"`       // The fields of Address are written as fields of User.
+//      Role     Role      `ulog:"role,string"`   // Written as a string, formatted with %v.
+//      Password string    `ulog:"-"`             // Never written.
+//  }
+//
+// Embedded structs without a tag name are inlined. If several fields have the same name, the first one is written.
+// Nested structs, pointers, slices, arrays and maps are walked; times, durations and types that implement
+// json.Marshaler or encoding.TextMarshaler are written as is, and errors are written as their message. Values nested
+// deeper than 32 levels are written as "<max depth>", and values that refer back to a value containing them are
+// written as "<cycle>", so that self-referential data can be logged.
+//
+// If the name is empty, or T is not a struct or a pointer to a struct, an error is returned.
+//
+// OutputFormats:
+//  - OutputFormatText => struct is formatted as space separated name=value pairs in curly brackets, e.g.
+//    {id=42 email=[REDACTED]}.
+//  - OutputFormatJSON => struct is formatted as an object, with the fields in declaration order.
+func NewStructField[T any](name string) (Field, error) {
+    structType := reflect.TypeFor[T]()
+    for structType.Kind() == reflect.Pointer {
+        structType = structType.Elem()
+    }
+    if structType.Kind() != reflect.Struct {
+        return ObjectField[T]{}, &ErrorInvalidStructType{dataType: reflect.TypeFor[T]().String()}
+    }
+
+    return NewObjectField[T](
+        name,
+        func(args LogLineArgs, data T) any {
+            w := structWalker{visiting: map[structVisit]bool{}}
+
+            v := w.value(reflect.ValueOf(&data).Elem(), 0)
+            if obj, ok := v.(structObject); ok && args.OutputFormat == OutputFormatText {
+                return obj.String()
+            }
+            return v
+        },
+    )
+}
+
+// structObject is the result of a struct field: the results of the fields of the struct, in declaration order.
+type structObject []FieldResult
+
+// MarshalJSON encodes the object, keeping the order of its fields.
+func (o structObject) MarshalJSON() ([]byte, error) {
+    enc := getJSONEncoder()
+    defer putJSONEncoder(enc)
+
+    if err := enc.encodeObject(o); err != nil {
+        return nil, err
+    }
+    return enc.line(), nil
+}
+
+// String returns the fields of the object as space separated name=value pairs in curly brackets.
+func (o structObject) String() string {
+    b := []byte{'{'}
+    for i, result := range o {
+        if i > 0 {
+            b = append(b, ' ')
+        }
+        b = append(b, result.Name...)
+        b = append(b, '=')
+
+        if nested, ok := result.Data.(structObject); ok {
+            b = append(b, nested.String()...)
+            continue
+        }
+        b = appendTextValue(b, fmt.Sprint(result.Data), " ")
+    }
+    return string(append(b, '}'))
+}
+
+// structVisit identifies a pointer, map or slice that is being walked, to detect cycles.
+type structVisit struct {
+    kind reflect.Kind
+    ptr  uintptr
+}
+
+// structWalker converts a value into structObjects, []any and map[string]any, for a single log line.
+type structWalker struct {
+    // visiting holds the pointers, maps and slices that contain the value that is being walked.
+    visiting map[structVisit]bool
+}
+
+func (w *structWalker) value(rv reflect.Value, depth int) any {
+    if !rv.IsValid() {
+        return nil
+    }
+    if depth > maxStructFieldDepth {
+        return structDepthPlaceholder
+    }
+
+    switch rv.Kind() {
+    case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice:
+        if rv.IsNil() {
+            return nil
+        }
+    }
+
+    // Pointers to leaves are dereferenced, so that e.g. a *time.Time is written the same as a time.Time.
+    t := rv.Type()
+    if structIsLeaf(t) && !(t.Kind() == reflect.Pointer && structIsLeaf(t.Elem())) {
+        if t.Implements(errorType) && rv.CanInterface() {
+            return rv.Interface().(error).Error()
+        }
+        return structInterface(rv)
+    }
+
+    switch rv.Kind() {
+    case reflect.Pointer, reflect.Map, reflect.Slice:
+        visit := structVisit{kind: rv.Kind(), ptr: rv.Pointer()}
+        if w.visiting[visit] {
+            return structCyclePlaceholder
+        }
+        w.visiting[visit] = true
+        defer delete(w.visiting, visit)
+    }
+
+    switch rv.Kind() {
+    case reflect.Pointer, reflect.Interface:
+        // Following a pointer doesn't nest the value, and cyclic pointers are caught above.
+        return w.value(rv.Elem(), depth)
+    case reflect.Struct:
+        return w.object(rv, depth)
+    case reflect.Slice, reflect.Array:
+        if t.Elem().Kind() == reflect.Uint8 {
+            return structInterface(rv)
+        }
+        values := make([]any, rv.Len())
+        for i := range values {
+            values[i] = w.value(rv.Index(i), depth+1)
+        }
+        return values
+    case reflect.Map:
+        values := make(map[string]any, rv.Len())
+        for iter := rv.MapRange(); iter.Next(); {
+            values[binaryMapKey(iter.Key())] = w.value(iter.Value(), depth+1)
+        }
+        return values
+    }
+
+    return structInterface(rv)
+}
+
+func (w *structWalker) object(rv reflect.Value, depth int) structObject {
+    fields := structFieldsOf(rv.Type())
+
+    obj := make(structObject, 0, len(fields))
+    for _, field := range fields {
+        fv := rv.Field(field.index)
+        if field.omitEmpty && structIsEmpty(fv) {
+            continue
+        }
+
+        if field.redact {
+            obj = obj.append(field.name, redactedPlaceholder)
+            continue
+        }
+
+        v := w.value(fv, depth+1)
+
+        if field.inline {
+            if inlined, ok := v.(structObject); ok {
+                for _, result := range inlined {
+                    obj = obj.append(result.Name, result.Data)
+                }
+                continue
+            }
+            if v == nil {
+                continue
+            }
+        }
+
+        if field.asString && v != nil {
+            v = fmt.Sprint(v)
+        }
+
+        obj = obj.append(field.name, v)
+    }
+
+    return obj
+}
+
+// append appends the field to the object, unless the object already has a field with the name.
+func (o structObject) append(name string, data any) structObject {
+    if slices.ContainsFunc(o, func(result FieldResult) bool { return result.Name == name }) {
+        return o
+    }
+    return append(o, FieldResult{Name: name, Data: data})
+}
+
+// structIsLeaf reports whether values of the type are written as is, rather than walked.
+func structIsLeaf(t reflect.Type) bool {
+    switch {
+    case t == reflect.TypeFor[time.Time](), t == reflect.TypeFor[time.Duration]():
+        return true
+    case t.Implements(errorType), t.Implements(jsonMarshalerType), t.Implements(textMarshalerType):
+        return true
+    }
+    return false
+}
+
+// structInterface returns the value as an interface, or nil if it was reached through an unexported field.
+func structInterface(rv reflect.Value) any {
+    if !rv.CanInterface() {
+        return nil
+    }
+    return rv.Interface()
+}
+
+// structIsEmpty reports whether the value is omitted by the omitempty option.
+func structIsEmpty(rv reflect.Value) bool {
+    switch rv.Kind() {
+    case reflect.Slice, reflect.Map:
+        return rv.Len() == 0
+    }
+    return rv.IsZero()
+}
+
+type structFieldInfo struct {
+    name      string
+    index     int
+    omitEmpty bool
+    redact    bool
+    inline    bool
+    asString  bool
+}
+
+var structFieldCache sync.Map
+
+// structFieldsOf returns the fields of the struct type that are written, following the ulog tags of the fields.
+func structFieldsOf(t reflect.Type) []structFieldInfo {
+    if cached, ok := structFieldCache.Load(t); ok {
+        return cached.([]structFieldInfo)
+    }
+
+    var fields []structFieldInfo
+    for i := range t.NumField() {
+        sf := t.Field(i)
+        tag := sf.Tag.Get("ulog")
+        if tag == "-" {
+            continue
+        }
+
+        name, options, _ := strings.Cut(tag, ",")
+        optionList := strings.Split(options, ",")
+
+        field := structFieldInfo{
+            name:      name,
+            index:     i,
+            omitEmpty: slices.Contains(optionList, "omitempty"),
+            redact:    slices.Contains(optionList, "redact"),
+            inline:    slices.Contains(optionList, "inline"),
+            asString:  slices.Contains(optionList, "string"),
+        }
+
+        ft := sf.Type
+        if ft.Kind() == reflect.Pointer {
+            ft = ft.Elem()
+        }
+        if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+            field.inline = true
+        } else if !sf.IsExported() {
+            continue
+        }
+
+        switch sf.Type.Kind() {
+        case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+            continue
+        }
+
+        if field.name == "" {
+            field.name = sf.Name
+        }
+        fields = append(fields, field)
+    }
+
+    structFieldCache.Store(t, fields)
+    return fields
+}
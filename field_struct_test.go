@@ -0,0 +1,222 @@
+package ultralogger
+
+import (
+    "bytes"
+    "encoding/json"
+    "errors"
+    "os"
+    "testing"
+    "time"
+)
+
+type structAddress struct {
+    City    string `ulog:"city"`
+    Country string `ulog:"country,omitempty"`
+}
+
+type structRole int
+
+func (r structRole) String() string {
+    return [...]string{"guest", "admin"}[r]
+}
+
+type structAudit struct {
+    CreatedBy string `ulog:"created_by"`
+}
+
+type structUser struct {
+    structAudit
+    ID       int               `ulog:"id"`
+    Email    string            `ulog:"email,redact"`
+    Nickname string            `ulog:"nickname,omitempty"`
+    Address  *structAddress    `ulog:"address,inline"`
+    Role     structRole        `ulog:"role,string"`
+    Password string            `ulog:"-"`
+    Tags     []string          `ulog:"tags,omitempty"`
+    Labels   map[string]string `ulog:"labels,omitempty"`
+    Err      error             `ulog:"err,omitempty"`
+    Since    *time.Time        `ulog:"since,omitempty"`
+    Callback func()
+    internal string
+}
+
+type structNode struct {
+    Name string      `ulog:"name"`
+    Next *structNode `ulog:"next"`
+}
+
+func ExampleNewStructField() {
+    type Address struct {
+        City string `ulog:"city"`
+    }
+    type User struct {
+        ID       int     `ulog:"id"`
+        Email    string  `ulog:"email,redact"`
+        Nickname string  `ulog:"nickname,omitempty"`
+        Address  Address `ulog:"address"`
+    }
+
+    userField, _ := NewStructField[User]("user")
+
+    formatter, _ := NewFormatter(OutputFormatJSON, []Field{userField})
+
+    logger, _ := NewLoggerWithOptions(WithDestination(os.Stdout, formatter), WithAsync(false))
+
+    logger.Info(User{ID: 42, Email: "john@example.com", Address: Address{City: "Lisbon"}})
+    // Output: {"user":{"id":42,"email":"[REDACTED]","address":{"city":"Lisbon"}}}
+}
+
+func TestStructField(t *testing.T) {
+    since := time.Date(2024, 11, 7, 19, 30, 0, 0, time.UTC)
+    user := structUser{
+        structAudit: structAudit{CreatedBy: "admin"},
+        ID:          42,
+        Email:       "john@example.com",
+        Address:     &structAddress{City: "Lisbon"},
+        Role:        1,
+        Password:    "secret",
+        Tags:        []string{"a", "b"},
+        Err:         errors.New("boom"),
+        Since:       &since,
+        internal:    "internal",
+    }
+
+    userField, _ := NewStructField[structUser]("user")
+    userPtrField, _ := NewStructField[*structUser]("user")
+
+    tests := []struct {
+        name         string
+        field        Field
+        outputFormat OutputFormat
+        data         any
+        want         string
+    }{
+        {
+            name:  "JSON",
+            field: userField,
+            data:  user,
+            want: `{"user":{"created_by":"admin","id":42,"email":"[REDACTED]","city":"Lisbon","role":"admin",` +
+                `"tags":["a","b"],"err":"boom","since":"2024-11-07T19:30:00Z"}}`,
+        },
+        {
+            name:  "JSON zero values",
+            field: userField,
+            data:  structUser{},
+            want:  `{"user":{"created_by":"","id":0,"email":"[REDACTED]","role":"guest"}}`,
+        },
+        {
+            name:  "JSON pointer",
+            field: userPtrField,
+            data:  &structUser{ID: 7, Labels: map[string]string{"team": "core"}},
+            want:  `{"user":{"created_by":"","id":7,"email":"[REDACTED]","role":"guest","labels":{"team":"core"}}}`,
+        },
+        {
+            name:  "JSON nil pointer",
+            field: userPtrField,
+            data:  (*structUser)(nil),
+            want:  `{}`,
+        },
+        {
+            name:         "Text",
+            field:        userField,
+            outputFormat: OutputFormatText,
+            data:         structUser{ID: 42, Address: &structAddress{City: "New York"}},
+            want:         `{created_by= id=42 email=[REDACTED] city="New York" role=guest}`,
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            var got []byte
+            if tt.outputFormat == OutputFormatText {
+                fieldFormatter, _ := tt.field.NewFieldFormatter()
+                result, err := fieldFormatter(LogLineArgs{OutputFormat: OutputFormatText}, tt.data)
+                if err != nil {
+                    t.Fatalf("FieldFormatter() error = %v", err)
+                }
+                got = []byte(result.Data.(string))
+            } else {
+                res := (&JSONFormatter{Fields: []Field{tt.field}}).FormatLogLine(LogLineArgs{Level: Info}, tt.data)
+                if res.err != nil {
+                    t.Fatalf("FormatLogLine() error = %v", res.err)
+                }
+                got = res.bytes
+            }
+
+            if string(got) != tt.want {
+                t.Errorf("got %s, want %s", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestStructField_cycle(t *testing.T) {
+    a := &structNode{Name: "a"}
+    b := &structNode{Name: "b", Next: a}
+    a.Next = b
+
+    nodeField, _ := NewStructField[*structNode]("node")
+
+    res := (&JSONFormatter{Fields: []Field{nodeField}}).FormatLogLine(LogLineArgs{Level: Info}, a)
+    if res.err != nil {
+        t.Fatalf("FormatLogLine() error = %v", res.err)
+    }
+
+    // The placeholder is HTML-escaped, the same as by encoding/json.
+    want := `{"node":{"name":"a","next":{"name":"b","next":"\u003ccycle\u003e"}}}`
+    if string(res.bytes) != want {
+        t.Errorf("FormatLogLine() = %s, want %s", res.bytes, want)
+    }
+}
+
+func TestStructField_depth(t *testing.T) {
+    // A long list that isn't cyclic is cut off at the maximum depth.
+    head := &structNode{Name: "0"}
+    for node, i := head, 1; i < 100; i++ {
+        node.Next = &structNode{Name: "n"}
+        node = node.Next
+    }
+
+    nodeField, _ := NewStructField[*structNode]("node")
+
+    res := (&JSONFormatter{Fields: []Field{nodeField}}).FormatLogLine(LogLineArgs{Level: Info}, head)
+    if res.err != nil {
+        t.Fatalf("FormatLogLine() error = %v", res.err)
+    }
+
+    if !bytes.Contains(res.bytes, []byte(`"\u003cmax depth\u003e"`)) {
+        t.Errorf("FormatLogLine() = %s, want a value cut off at the maximum depth", res.bytes)
+    }
+    if !json.Valid(res.bytes) {
+        t.Errorf("FormatLogLine() = %s, want valid JSON", res.bytes)
+    }
+}
+
+func TestStructField_binary(t *testing.T) {
+    userField, _ := NewStructField[structUser]("user")
+
+    res := (&CBORFormatter{Fields: []Field{userField}}).FormatLogLine(LogLineArgs{Level: Info}, structUser{ID: 42})
+    if res.err != nil {
+        t.Fatalf("FormatLogLine() error = %v", res.err)
+    }
+
+    line, err := NewCBORDecoder(bytes.NewReader(res.bytes)).Decode()
+    if err != nil {
+        t.Fatalf("Decode() error = %v", err)
+    }
+
+    user, ok := line["user"].(map[string]any)
+    if !ok || user["id"] != int64(42) || user["role"] != "guest" {
+        t.Errorf("Decode() = %v, want the user as a map", line)
+    }
+}
+
+func TestNewStructField_Error(t *testing.T) {
+    if _, err := NewStructField[structUser](""); !errors.Is(err, ErrorEmptyFieldName) {
+        t.Errorf("NewStructField() error = %v, want %v", err, ErrorEmptyFieldName)
+    }
+
+    var invalidType *ErrorInvalidStructType
+    if _, err := NewStructField[map[string]any]("user"); !errors.As(err, &invalidType) {
+        t.Errorf("NewStructField() error = %v, want ErrorInvalidStructType", err)
+    }
+}
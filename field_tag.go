@@ -55,6 +55,10 @@ func (f *tagField) NewFieldFormatter() (FieldFormatter, error) {
     return f.format, nil
 }
 
+func (f *tagField) FieldName() string {
+    return "tag"
+}
+
 func (f *tagField) format(args LogLineArgs, _ any) (FieldResult, error) {
     result := FieldResult{
         Name: "tag",
@@ -63,7 +67,7 @@ func (f *tagField) format(args LogLineArgs, _ any) (FieldResult, error) {
     switch args.OutputFormat {
     case OutputFormatText:
         result.Data = f.tagString(args.Tag)
-    case OutputFormatJSON:
+    default:
         result.Data = args.Tag
     }
 
@@ -0,0 +1,134 @@
+package ultralogger
+
+import (
+    "fmt"
+    "reflect"
+    "sync"
+)
+
+// UnionFieldCase is one of the typed formatters of a union field. Create one with [UnionCase].
+type UnionFieldCase struct {
+    dataType reflect.Type
+    format   func(args LogLineArgs, data any) any
+}
+
+// UnionCase returns a UnionFieldCase that formats data of type T with the formatter. If T is an interface, the case
+// matches every type that implements it; UnionCase[any] is the fallback of the union field.
+func UnionCase[T any](formatter ObjectFieldFormatter[T]) UnionFieldCase {
+    dataType := reflect.TypeFor[T]()
+    if formatter == nil {
+        return UnionFieldCase{dataType: dataType}
+    }
+
+    return UnionFieldCase{
+        dataType: dataType,
+        format: func(args LogLineArgs, data any) any {
+            v, ok := data.(T)
+            if !ok {
+                // The data is assignable to T, e.g. a named map type for a map case, but is not T.
+                v = reflect.ValueOf(data).Convert(dataType).Interface().(T)
+            }
+            return formatter(args, v)
+        },
+    }
+}
+
+// NewUnionField returns a new Field that dispatches the data to the first case that matches its type. A case of the
+// exact type of the data is preferred, then the first case that the data is assignable to, e.g. an interface that it
+// implements, in the order the cases are provided.
+//
+// If no case matches, the UnionCase[any] case is used. Without one, data that matches no case is formatted with %v for
+// OutputFormatText, and written as is for the structured output formats, so that no data is lost. Nil data matches no
+// case, and is handled by the nil policy of the formatter.
+//
+// If the name is empty, or there are no cases, or a case has a nil formatter, an error is returned.
+func NewUnionField(name string, cases ...UnionFieldCase) (Field, error) {
+    if name == "" {
+        return &unionField{}, ErrorEmptyFieldName
+    }
+    if len(cases) == 0 {
+        return &unionField{}, ErrorNilFormatter
+    }
+
+    f := &unionField{name: name}
+    for _, c := range cases {
+        if c.format == nil {
+            return &unionField{}, ErrorNilFormatter
+        }
+
+        if c.dataType == reflect.TypeFor[any]() {
+            f.fallback = c.format
+            continue
+        }
+        f.cases = append(f.cases, c)
+    }
+
+    return f, nil
+}
+
+type unionField struct {
+    name     string
+    cases    []UnionFieldCase
+    fallback func(args LogLineArgs, data any) any
+
+    // dispatch caches the index of the case for every type of data that has been formatted, or -1 if no case
+    // matches.
+    dispatch sync.Map
+}
+
+func (f *unionField) NewFieldFormatter() (FieldFormatter, error) {
+    return f.format, nil
+}
+
+func (f *unionField) FieldName() string {
+    return f.name
+}
+
+func (f *unionField) format(args LogLineArgs, data any) (FieldResult, error) {
+    result := FieldResult{
+        Name: f.name,
+    }
+
+    if data == nil {
+        return result, &ErrorInvalidFieldDataType{
+            field: f.name,
+        }
+    }
+
+    if i := f.caseIndex(reflect.TypeOf(data)); i >= 0 {
+        result.Data = f.cases[i].format(args, data)
+        return result, nil
+    }
+
+    switch {
+    case f.fallback != nil:
+        result.Data = f.fallback(args, data)
+    case args.OutputFormat == OutputFormatText:
+        result.Data = fmt.Sprintf("%v", data)
+    default:
+        result.Data = data
+    }
+
+    return result, nil
+}
+
+// caseIndex returns the index of the case for the type of data, or -1 if no case matches.
+func (f *unionField) caseIndex(dataType reflect.Type) int {
+    if i, ok := f.dispatch.Load(dataType); ok {
+        return i.(int)
+    }
+
+    index := -1
+    for i, c := range f.cases {
+        if c.dataType == dataType {
+            index = i
+            break
+        }
+        if index < 0 && dataType.AssignableTo(c.dataType) {
+            index = i
+        }
+    }
+
+    f.dispatch.Store(dataType, index)
+    return index
+}
@@ -0,0 +1,179 @@
+package ultralogger
+
+import (
+    "errors"
+    "fmt"
+    "os"
+    "testing"
+)
+
+type unionUser struct {
+    Name string `json:"name"`
+}
+
+type unionStringer struct{}
+
+func (unionStringer) String() string {
+    return "stringer"
+}
+
+type unionLabels map[string]string
+
+func ExampleNewUnionField() {
+    payloadField, _ := NewUnionField(
+        "payload",
+        UnionCase[string](func(args LogLineArgs, data string) any {
+            return data
+        }),
+        UnionCase[error](func(args LogLineArgs, data error) any {
+            return "error: " + data.Error()
+        }),
+    )
+
+    formatter, _ := NewFormatter(OutputFormatJSON, []Field{payloadField})
+
+    logger, _ := NewLoggerWithOptions(WithDestination(os.Stdout, formatter), WithAsync(false))
+
+    logger.Info("started")
+    logger.Error(errors.New("boom"))
+    logger.Info(unionUser{Name: "john"})
+    // Output:
+    // {"payload":"started"}
+    // {"payload":"error: boom"}
+    // {"payload":{"name":"john"}}
+}
+
+func TestUnionField(t *testing.T) {
+    stringCase := UnionCase[string](func(args LogLineArgs, data string) any {
+        return "string:" + data
+    })
+    stringerCase := UnionCase[fmt.Stringer](func(args LogLineArgs, data fmt.Stringer) any {
+        return "stringer:" + data.String()
+    })
+    exactStringerCase := UnionCase[unionStringer](func(args LogLineArgs, data unionStringer) any {
+        return "exact:" + data.String()
+    })
+    mapCase := UnionCase[map[string]string](func(args LogLineArgs, data map[string]string) any {
+        return fmt.Sprintf("map:%d", len(data))
+    })
+    anyCase := UnionCase[any](func(args LogLineArgs, data any) any {
+        return fmt.Sprintf("any:%T", data)
+    })
+
+    tests := []struct {
+        name         string
+        cases        []UnionFieldCase
+        outputFormat OutputFormat
+        data         any
+        want         any
+        wantErr      bool
+    }{
+        {
+            name:  "Exact",
+            cases: []UnionFieldCase{stringCase, stringerCase},
+            data:  "x",
+            want:  "string:x",
+        },
+        {
+            name:  "Interface",
+            cases: []UnionFieldCase{stringCase, stringerCase},
+            data:  unionStringer{},
+            want:  "stringer:stringer",
+        },
+        {
+            name:  "Exact preferred over interface",
+            cases: []UnionFieldCase{stringerCase, exactStringerCase},
+            data:  unionStringer{},
+            want:  "exact:stringer",
+        },
+        {
+            name:  "Assignable",
+            cases: []UnionFieldCase{mapCase},
+            data:  unionLabels{"a": "1"},
+            want:  "map:1",
+        },
+        {
+            name:  "Fallback",
+            cases: []UnionFieldCase{anyCase, stringCase},
+            data:  42,
+            want:  "any:int",
+        },
+        {
+            name:  "Fallback not preferred",
+            cases: []UnionFieldCase{anyCase, stringCase},
+            data:  "x",
+            want:  "string:x",
+        },
+        {
+            name:         "Default fallback text",
+            cases:        []UnionFieldCase{stringCase},
+            outputFormat: OutputFormatText,
+            data:         unionUser{Name: "john"},
+            want:         "{john}",
+        },
+        {
+            name:         "Default fallback JSON",
+            cases:        []UnionFieldCase{stringCase},
+            outputFormat: OutputFormatJSON,
+            data:         unionUser{Name: "john"},
+            want:         unionUser{Name: "john"},
+        },
+        {
+            name:    "Nil",
+            cases:   []UnionFieldCase{anyCase},
+            data:    nil,
+            wantErr: true,
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            field, err := NewUnionField("payload", tt.cases...)
+            if err != nil {
+                t.Fatalf("NewUnionField() error = %v", err)
+            }
+
+            fieldFormatter, _ := field.NewFieldFormatter()
+
+            // Format twice, so that the second result comes from the dispatch cache.
+            for range 2 {
+                got, err := fieldFormatter(LogLineArgs{OutputFormat: tt.outputFormat}, tt.data)
+                if (err != nil) != tt.wantErr {
+                    t.Fatalf("FieldFormatter() error = %v, wantErr %v", err, tt.wantErr)
+                }
+
+                if !tt.wantErr && got.Data != tt.want {
+                    t.Errorf("FieldFormatter() = %v, want %v", got.Data, tt.want)
+                }
+            }
+        })
+    }
+}
+
+func TestNewUnionField_Error(t *testing.T) {
+    stringCase := UnionCase[string](func(args LogLineArgs, data string) any {
+        return data
+    })
+
+    tests := []struct {
+        name      string
+        fieldName string
+        cases     []UnionFieldCase
+        wantErr   error
+    }{
+        {name: "Empty name", fieldName: "", cases: []UnionFieldCase{stringCase}, wantErr: ErrorEmptyFieldName},
+        {name: "No cases", fieldName: "payload", wantErr: ErrorNilFormatter},
+        {
+            name:      "Nil formatter",
+            fieldName: "payload",
+            cases:     []UnionFieldCase{stringCase, UnionCase[int](nil)},
+            wantErr:   ErrorNilFormatter,
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if _, err := NewUnionField(tt.fieldName, tt.cases...); !errors.Is(err, tt.wantErr) {
+                t.Errorf("NewUnionField() error = %v, want %v", err, tt.wantErr)
+            }
+        })
+    }
+}
@@ -1,12 +1,21 @@
 package ultralogger
 
-import "errors"
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "strconv"
+)
 
 // OutputFormat is a type representing the output format of a formatter.
 //
 // It can be one of the following:
 //   - JSON
 //   - Text
+//   - CBOR
+//   - MessagePack
 //
 // TODO: Add more output formats [YAML, XML, etc.]
 type OutputFormat string
@@ -14,6 +23,10 @@ type OutputFormat string
 const (
     OutputFormatJSON OutputFormat = "json"
     OutputFormatText OutputFormat = "text"
+    // OutputFormatCBOR is the binary CBOR format (RFC 8949). See [CBORFormatter].
+    OutputFormatCBOR OutputFormat = "cbor"
+    // OutputFormatMsgPack is the binary MessagePack format. See [MsgPackFormatter].
+    OutputFormatMsgPack OutputFormat = "msgpack"
 )
 
 // LogLineArgs are the arguments that are passed to the FormatLogLine function of a LogLineFormatter, and further to the
@@ -23,6 +36,13 @@ type LogLineArgs struct {
     Level        Level
     Tag          string
     OutputFormat OutputFormat
+    // Caller is the location that emitted the log line. It is nil unless the logger was created with [WithCaller].
+    Caller *Caller
+    // Context is the context passed to [ContextLogger.LogContext], or nil if the line was logged without a context.
+    Context context.Context
+
+    // redaction redacts the field results of the line, if it is formatted by a RedactingFormatter.
+    redaction *lineRedaction
 }
 
 // FormatResult is a struct that contains the formatted log line and any errors that may have occurred.
@@ -44,18 +64,45 @@ type LogLineFormatter interface {
 // applied to it. This is useful for creating custom formatters that have additional options.
 type FormatterOption func(f LogLineFormatter) LogLineFormatter
 
+// NewFormatter returns a new LogLineFormatter for the output format, with the provided fields and options applied.
+//
+// For the structured output formats (JSON, CBOR and MessagePack), an ErrorDuplicateFieldName is returned if two fields
+// implementing [NamedField] have the same name, since only one of them could be written to the object.
+//
+// The FieldFormatter of every field is created here, once, rather than for every log line; an ErrorFieldFormatterInit
+// is returned if any of them can't be created. Fields implementing [PerLineField] can opt out of this.
 func NewFormatter(outputFormat OutputFormat, fields []Field, opts ...FormatterOption) (LogLineFormatter, error) {
     var f LogLineFormatter
+    var plan *fieldPlanCache
+
+    switch outputFormat {
+    case OutputFormatJSON, OutputFormatCBOR, OutputFormatMsgPack:
+        if err := validateObjectFields(fields); err != nil {
+            return nil, err
+        }
+    }
 
     switch outputFormat {
     case OutputFormatJSON:
-        f = &JSONFormatter{Fields: fields}
+        jsonFormatter := &JSONFormatter{Fields: fields}
+        f, plan = jsonFormatter, &jsonFormatter.plan
+    case OutputFormatCBOR:
+        cborFormatter := &CBORFormatter{Fields: fields}
+        f, plan = cborFormatter, &cborFormatter.plan
+    case OutputFormatMsgPack:
+        msgPackFormatter := &MsgPackFormatter{Fields: fields}
+        f, plan = msgPackFormatter, &msgPackFormatter.plan
     case OutputFormatText:
-        f = &TextFormatter{Fields: fields}
+        textFormatter := &TextFormatter{Fields: fields}
+        f, plan = textFormatter, &textFormatter.plan
     default:
         return nil, &ErrorInvalidOutput{outputFormat: outputFormat}
     }
 
+    if _, err := plan.get(fields); err != nil {
+        return nil, err
+    }
+
     for _, opt := range opts {
         f = opt(f)
     }
@@ -95,15 +142,87 @@ func computeFieldResult(field Field, args LogLineArgs, data any) (*FieldResult,
         return nil, &ErrorFieldFormatterInit{field: field, err: err}
     }
 
+    return fieldFormatterResult(fieldFormatter, args, data), nil
+}
+
+// fieldFormatterResult formats the data with the FieldFormatter. A nil result is returned if the FieldFormatter
+// rejects the type of the data.
+func fieldFormatterResult(fieldFormatter FieldFormatter, args LogLineArgs, data any) *FieldResult {
     fieldResult, err := fieldFormatter(args, data)
 
     if err != nil {
         var invalidDataTypeError *ErrorInvalidFieldDataType
         if errors.As(err, &invalidDataTypeError) {
             // Purposefully ignore this error in the caller. This is equivalent to throwing field away.
-            return nil, nil
+            return nil
+        }
+    }
+
+    return &fieldResult
+}
+
+// fieldName returns the name of the results produced by the field. Fields that don't implement NamedField are asked
+// for their name by formatting nil data; every built-in FieldFormatter reports its name even when it rejects the
+// data. Fields that panic on nil data are reported with an empty name.
+func fieldName(field Field) (name string, err error) {
+    if namedField, ok := field.(NamedField); ok {
+        return namedField.FieldName(), nil
+    }
+
+    fieldFormatter, err := field.NewFieldFormatter()
+    if err != nil {
+        return "", &ErrorFieldFormatterInit{field: field, err: err}
+    }
+
+    defer func() {
+        if recover() != nil {
+            name = ""
         }
+    }()
+
+    result, _ := fieldFormatter(LogLineArgs{OutputFormat: OutputFormatText}, nil)
+    return result.Name, nil
+}
+
+// jsonGeneric round trips the data through encoding/json, which turns structs into maps. Numbers are decoded as int64
+// or uint64 if they are integers that fit, and as float64 otherwise, so that large integer IDs are kept exact. If the
+// data cannot be encoded, it is formatted with %v.
+func jsonGeneric(data any) any {
+    jBytes, err := json.Marshal(data)
+    if err != nil {
+        return fmt.Sprintf("%v", data)
     }
 
-    return &fieldResult, nil
+    decoder := json.NewDecoder(bytes.NewReader(jBytes))
+    decoder.UseNumber()
+
+    var generic any
+    if err := decoder.Decode(&generic); err != nil {
+        return string(jBytes)
+    }
+    return jsonGenericNumbers(generic)
+}
+
+// jsonGenericNumbers replaces the json.Numbers in the decoded value with int64, uint64 or float64 values.
+func jsonGenericNumbers(v any) any {
+    switch v := v.(type) {
+    case json.Number:
+        if i, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+            return i
+        }
+        if u, err := strconv.ParseUint(string(v), 10, 64); err == nil {
+            return u
+        }
+        f, _ := v.Float64()
+        return f
+    case map[string]any:
+        for key, value := range v {
+            v[key] = jsonGenericNumbers(value)
+        }
+    case []any:
+        for i, value := range v {
+            v[i] = jsonGenericNumbers(value)
+        }
+    }
+    return v
 }
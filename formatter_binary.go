@@ -0,0 +1,158 @@
+package ultralogger
+
+import (
+    "encoding/binary"
+    "fmt"
+    "io"
+)
+
+const (
+    // binaryFrameHeaderSize is the size of the big-endian uint32 length that precedes every binary log line.
+    binaryFrameHeaderSize = 4
+    // maxBinaryFrameSize is the largest frame that a BinaryDecoder reads.
+    maxBinaryFrameSize = 64 << 20
+)
+
+// CBORFormatter is a formatter that formats log lines as CBOR maps (RFC 8949), for compact shipping to collectors.
+//
+// Every line is framed with its length, as a big-endian uint32, and is not terminated by a newline, so that a stream
+// of lines can be decoded with [NewCBORDecoder]. The keys are written in the same order as by the JSONFormatter.
+// Times are written with tag 1 (epoch-based date/time), with fractional seconds as a decimal fraction (tag 4) so that
+// no precision is lost, and durations with tag 1002 (RFC 9581), as a map with the whole seconds at key 1 and the
+// remaining nanoseconds at key -9.
+type CBORFormatter struct {
+    Fields []Field
+    // Policies are the policies for nil data and data of the wrong type. By default, fields with nil data and fields
+    // that reject the data are omitted.
+    Policies FieldPolicies
+
+    plan fieldPlanCache
+}
+
+// FormatLogLine formats the log line using the provided data and returns a FormatResult which contains the formatted
+// log line and any errors that may have occurred.
+func (f *CBORFormatter) FormatLogLine(args LogLineArgs, data any) FormatResult {
+    args.OutputFormat = OutputFormatCBOR
+    return formatBinaryLine(&f.plan, f.Fields, f.Policies, cborAppender{}, args, data)
+}
+
+func (f *CBORFormatter) framed() bool {
+    return true
+}
+
+// MsgPackFormatter is a formatter that formats log lines as MessagePack maps, for compact shipping to collectors.
+//
+// Every line is framed with its length, as a big-endian uint32, and is not terminated by a newline, so that a stream
+// of lines can be decoded with [NewMsgPackDecoder]. The keys are written in the same order as by the JSONFormatter.
+// Times are written with the timestamp extension (type -1), and durations with the extension type 1, as a fixext 8
+// with the big-endian int64 nanoseconds.
+type MsgPackFormatter struct {
+    Fields []Field
+    // Policies are the policies for nil data and data of the wrong type. By default, fields with nil data and fields
+    // that reject the data are omitted.
+    Policies FieldPolicies
+
+    plan fieldPlanCache
+}
+
+// FormatLogLine formats the log line using the provided data and returns a FormatResult which contains the formatted
+// log line and any errors that may have occurred.
+func (f *MsgPackFormatter) FormatLogLine(args LogLineArgs, data any) FormatResult {
+    args.OutputFormat = OutputFormatMsgPack
+    return formatBinaryLine(&f.plan, f.Fields, f.Policies, msgpackAppender{}, args, data)
+}
+
+func (f *MsgPackFormatter) framed() bool {
+    return true
+}
+
+// framedFormatter is implemented by formatters that frame their log lines themselves, so that the lines are written
+// without a trailing newline.
+type framedFormatter interface {
+    framed() bool
+}
+
+func isFramed(f LogLineFormatter) bool {
+    framedFormatter, ok := f.(framedFormatter)
+    return ok && framedFormatter.framed()
+}
+
+func formatBinaryLine(
+    planCache *fieldPlanCache,
+    fields []Field,
+    policies FieldPolicies,
+    enc binaryAppender,
+    args LogLineArgs,
+    data any,
+) FormatResult {
+    plan, err := planCache.get(fields)
+    if err != nil {
+        return FormatResult{nil, err}
+    }
+
+    results, err := appendObjectResults(make([]FieldResult, 0, len(plan.fields)), plan, args, data, policies)
+    if err != nil {
+        return FormatResult{nil, err}
+    }
+
+    // Reserve the frame header, and fill it in once the length is known.
+    b := make([]byte, binaryFrameHeaderSize, 256)
+    if b, err = appendBinaryObject(enc, b, results); err != nil {
+        return FormatResult{nil, err}
+    }
+    binary.BigEndian.PutUint32(b, uint32(len(b)-binaryFrameHeaderSize))
+
+    return FormatResult{b, nil}
+}
+
+// BinaryDecoder reads the framed log lines written by a CBORFormatter or a MsgPackFormatter.
+type BinaryDecoder struct {
+    r      io.Reader
+    format OutputFormat
+    decode func(b []byte) (any, error)
+}
+
+// NewCBORDecoder returns a BinaryDecoder that reads the log lines written by a CBORFormatter from r.
+func NewCBORDecoder(r io.Reader) *BinaryDecoder {
+    return &BinaryDecoder{r: r, format: OutputFormatCBOR, decode: decodeCBOR}
+}
+
+// NewMsgPackDecoder returns a BinaryDecoder that reads the log lines written by a MsgPackFormatter from r.
+func NewMsgPackDecoder(r io.Reader) *BinaryDecoder {
+    return &BinaryDecoder{r: r, format: OutputFormatMsgPack, decode: decodeMsgPack}
+}
+
+// Decode reads the next log line. Integers are decoded as int64 (or uint64 if they don't fit), floats as float64,
+// nested maps as map[string]any, arrays as []any, times as time.Time and durations as time.Duration.
+//
+// io.EOF is returned when there are no more lines, and io.ErrUnexpectedEOF if the stream ends within a line.
+func (d *BinaryDecoder) Decode() (map[string]any, error) {
+    var header [binaryFrameHeaderSize]byte
+    if _, err := io.ReadFull(d.r, header[:]); err != nil {
+        return nil, err
+    }
+
+    size := binary.BigEndian.Uint32(header[:])
+    if size > maxBinaryFrameSize {
+        return nil, &ErrorInvalidBinaryData{format: d.format, reason: fmt.Sprintf("frame of %d bytes is too large", size)}
+    }
+
+    frame := make([]byte, size)
+    if _, err := io.ReadFull(d.r, frame); err != nil {
+        if err == io.EOF {
+            return nil, io.ErrUnexpectedEOF
+        }
+        return nil, err
+    }
+
+    v, err := d.decode(frame)
+    if err != nil {
+        return nil, err
+    }
+
+    line, ok := v.(map[string]any)
+    if !ok {
+        return nil, &ErrorInvalidBinaryData{format: d.format, reason: fmt.Sprintf("log line is a %T, not a map", v)}
+    }
+    return line, nil
+}
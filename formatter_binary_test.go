@@ -0,0 +1,153 @@
+package ultralogger
+
+import (
+    "bytes"
+    "errors"
+    "fmt"
+    "io"
+    "reflect"
+    "testing"
+    "time"
+)
+
+func ExampleNewCBORDecoder() {
+    elapsedField, _ := NewDurationField("elapsed")
+
+    formatter, _ := NewFormatter(OutputFormatCBOR, []Field{NewLevelField(Brackets.None), elapsedField})
+
+    buf := &bytes.Buffer{}
+    logger, _ := NewLoggerWithOptions(WithDestination(buf, formatter), WithAsync(false))
+
+    logger.Info(1500 * time.Millisecond)
+    logger.Warn(2 * time.Second)
+
+    decoder := NewCBORDecoder(buf)
+    for {
+        line, err := decoder.Decode()
+        if err != nil {
+            break
+        }
+        fmt.Println(line["level"], line["elapsed"])
+    }
+    // Output:
+    // INFO 1.5s
+    // WARN 2s
+}
+
+func TestBinaryFormatters(t *testing.T) {
+    timeField, _ := NewCurrentTimeField("time", "")
+    timeField.(*currentTimeField).clock = mockClock{}
+    userField, _ := NewObjectField[map[string]any]("user", func(args LogLineArgs, data map[string]any) any {
+        return data
+    })
+
+    tests := []struct {
+        name         string
+        outputFormat OutputFormat
+        newDecoder   func(r io.Reader) *BinaryDecoder
+    }{
+        {name: "CBOR", outputFormat: OutputFormatCBOR, newDecoder: NewCBORDecoder},
+        {name: "MessagePack", outputFormat: OutputFormatMsgPack, newDecoder: NewMsgPackDecoder},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            formatter, err := NewFormatter(
+                tt.outputFormat,
+                []Field{userField, NewTagField(Brackets.Square, nil), NewLevelField(Brackets.Angle), timeField},
+            )
+            if err != nil {
+                t.Fatalf("NewFormatter() error = %v", err)
+            }
+
+            buf := &bytes.Buffer{}
+            logger, _ := NewLoggerWithOptions(WithDestination(buf, formatter), WithTag("api"), WithAsync(false))
+
+            logger.Info(map[string]any{"name": "john", "age": 42, "tags": []string{"a"}})
+            logger.Error(map[string]any{"name": "jane"})
+
+            decoder := tt.newDecoder(bytes.NewReader(buf.Bytes()))
+
+            want := []map[string]any{
+                {
+                    "time":  time.Date(2024, 11, 7, 19, 30, 0, 0, time.UTC),
+                    "level": "INFO",
+                    "tag":   "api",
+                    "user":  map[string]any{"name": "john", "age": int64(42), "tags": []any{"a"}},
+                },
+                {
+                    "time":  time.Date(2024, 11, 7, 19, 30, 0, 0, time.UTC),
+                    "level": "ERROR",
+                    "tag":   "api",
+                    "user":  map[string]any{"name": "jane"},
+                },
+            }
+            for i, wantLine := range want {
+                line, err := decoder.Decode()
+                if err != nil {
+                    t.Fatalf("Decode() line %d error = %v", i, err)
+                }
+                if !reflect.DeepEqual(line, wantLine) {
+                    t.Errorf("Decode() line %d = %#v, want %#v", i, line, wantLine)
+                }
+            }
+
+            // Lines are not terminated by a newline, so the stream ends right after the last frame.
+            if _, err := decoder.Decode(); err != io.EOF {
+                t.Errorf("Decode() error = %v, want io.EOF", err)
+            }
+        })
+    }
+}
+
+func TestBinaryFormatter_keyOrder(t *testing.T) {
+    userField, _ := NewStringField("user")
+
+    formatter := &MsgPackFormatter{Fields: []Field{userField, NewMessageField(), NewLevelField(Brackets.None)}}
+
+    res := formatter.FormatLogLine(LogLineArgs{Level: Info}, "john")
+    if res.err != nil {
+        t.Fatalf("FormatLogLine() error = %v", res.err)
+    }
+
+    // The frame length, then a map of three keys: level, message and user.
+    want := []byte{0, 0, 0, 35, 0x83}
+    want = append(want, 0xa5, 'l', 'e', 'v', 'e', 'l', 0xa4, 'I', 'N', 'F', 'O')
+    want = append(want, 0xa7, 'm', 'e', 's', 's', 'a', 'g', 'e', 0xa4, 'j', 'o', 'h', 'n')
+    want = append(want, 0xa4, 'u', 's', 'e', 'r', 0xa4, 'j', 'o', 'h', 'n')
+
+    if !bytes.Equal(res.bytes, want) {
+        t.Errorf("FormatLogLine() = %x, want %x", res.bytes, want)
+    }
+}
+
+func TestBinaryDecoder_Decode(t *testing.T) {
+    tests := []struct {
+        name    string
+        data    []byte
+        wantErr error
+    }{
+        {name: "Empty", data: nil, wantErr: io.EOF},
+        {name: "Truncated header", data: []byte{0, 0}, wantErr: io.ErrUnexpectedEOF},
+        {name: "Truncated frame", data: []byte{0, 0, 0, 2, 0x80}, wantErr: io.ErrUnexpectedEOF},
+        {name: "Empty frame", data: []byte{0, 0, 0, 0}, wantErr: &ErrorInvalidBinaryData{}},
+        {name: "Not a map", data: []byte{0, 0, 0, 1, 0x01}, wantErr: &ErrorInvalidBinaryData{}},
+        {name: "Frame too large", data: []byte{0xff, 0xff, 0xff, 0xff}, wantErr: &ErrorInvalidBinaryData{}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            _, err := NewMsgPackDecoder(bytes.NewReader(tt.data)).Decode()
+
+            var invalidErr *ErrorInvalidBinaryData
+            if errors.As(tt.wantErr, &invalidErr) {
+                if !errors.As(err, &invalidErr) {
+                    t.Errorf("Decode() error = %v, want ErrorInvalidBinaryData", err)
+                }
+                return
+            }
+
+            if !errors.Is(err, tt.wantErr) {
+                t.Errorf("Decode() error = %v, want %v", err, tt.wantErr)
+            }
+        })
+    }
+}
@@ -0,0 +1,229 @@
+package ultralogger
+
+import (
+    "encoding/json"
+    "fmt"
+    "net"
+    "net/url"
+    "strings"
+    "time"
+)
+
+const ecsVersion = "8.11.0"
+
+// ECSField is implemented by fields that declare where their result should be placed in an [ECSFormatter] log line.
+// [ObjectField] implements ECSField; use [ObjectField.WithECSPath] to set the path.
+type ECSField interface {
+    Field
+    // ECSPath returns the dotted ECS path of the field, e.g. "user.name". An empty path means the field does not
+    // declare a path.
+    ECSPath() string
+}
+
+// ECSFormatter is a formatter that formats log lines as Elastic Common Schema (ECS) JSON documents.
+//
+// The formatter places well-known data at the matching ECS keys:
+//  - "@timestamp" => the time the line was formatted, in UTC.
+//  - "log.level" => the Level of the log line, lowercase.
+//  - "log.logger" => the Tag of the log line.
+//  - "message" => the result of the "message" field.
+//  - "error.message", "error.type" and "error.stack_trace" => results that are errors, or produced by [NewErrorField].
+//  - "http.request.id", "http.request.method", "url.path", "url.query", "url.domain", "http.version", "source.ip",
+//    "client.ip", "user_agent.original", "http.request.headers" and "http.request.body" => results produced by
+//    [NewRequestField].
+//  - "http.response.status_code", "http.response.body.bytes", "http.response.headers" and "event.duration" => results
+//    produced by [NewResponseField].
+//
+// Fields that implement [ECSField] are placed at their declared path. Every other field result is placed at its
+// name, where dots in the name are treated as nested objects.
+//
+// See https://www.elastic.co/guide/en/ecs/current/index.html for more information.
+type ECSFormatter struct {
+    Fields []Field
+
+    clock clock
+    plan  fieldPlanCache
+}
+
+// NewECSFormatter returns a new ECSFormatter with the provided fields.
+func NewECSFormatter(fields []Field) (*ECSFormatter, error) {
+    f := &ECSFormatter{
+        Fields: fields,
+        clock:  &realClock{},
+    }
+    if _, err := f.plan.get(fields); err != nil {
+        return nil, err
+    }
+
+    return f, nil
+}
+
+// FormatLogLine formats the log line using the provided data and returns a FormatResult which contains the formatted
+// log line and any errors that may have occurred.
+func (f *ECSFormatter) FormatLogLine(args LogLineArgs, data any) FormatResult {
+    plan, err := f.plan.get(f.Fields)
+    if err != nil {
+        return FormatResult{nil, err}
+    }
+
+    c := f.clock
+    if c == nil {
+        c = &realClock{}
+    }
+
+    args.OutputFormat = OutputFormatJSON
+
+    doc := map[string]any{
+        "@timestamp": c.Now().UTC().Format(time.RFC3339Nano),
+    }
+    ecsSet(doc, "ecs.version", ecsVersion)
+    ecsSet(doc, "log.level", strings.ToLower(args.Level.String()))
+    if args.Tag != "" {
+        ecsSet(doc, "log.logger", args.Tag)
+    }
+
+    for i, field := range plan.fields {
+        switch field.(type) {
+        case *levelField, *tagField, *currentTimeField:
+            // These are already covered by log.level, log.logger and @timestamp.
+            continue
+        }
+
+        fieldResult, err := plan.result(i, args, data)
+        if err != nil {
+            return FormatResult{nil, err}
+        }
+
+        if fieldResult == nil || fieldResult.Data == nil {
+            continue
+        }
+
+        if ecsField, ok := field.(ECSField); ok && ecsField.ECSPath() != "" {
+            ecsSet(doc, ecsField.ECSPath(), fieldResult.Data)
+            continue
+        }
+
+        ecsSetResult(doc, fieldResult)
+    }
+
+    jBytes, err := json.Marshal(doc)
+    return FormatResult{jBytes, err}
+}
+
+// ecsSetResult places the field result into the document based on the type of its data.
+func ecsSetResult(doc map[string]any, fieldResult *FieldResult) {
+    switch v := fieldResult.Data.(type) {
+    case ErrorLogEntry:
+        ecsSet(doc, "error.message", v.Message)
+        ecsSet(doc, "error.type", v.Type)
+        if stackTrace := ecsStackTrace(v.err); stackTrace != "" {
+            ecsSet(doc, "error.stack_trace", stackTrace)
+        }
+    case error:
+        ecsSet(doc, "error.message", v.Error())
+        ecsSet(doc, "error.type", fmt.Sprintf("%T", v))
+        if stackTrace := ecsStackTrace(v); stackTrace != "" {
+            ecsSet(doc, "error.stack_trace", stackTrace)
+        }
+    case RequestLogEntry:
+        if v.RequestID != "" {
+            ecsSet(doc, "http.request.id", v.RequestID)
+        }
+        if v.Method != "" {
+            ecsSet(doc, "http.request.method", v.Method)
+        }
+        if v.Path != "" {
+            ecsSet(doc, "url.path", v.Path)
+        }
+        if v.SourceIP != "" {
+            ecsSet(doc, "source.ip", ecsHost(v.SourceIP))
+        }
+        if v.ClientIP != "" {
+            ecsSet(doc, "client.ip", v.ClientIP)
+        }
+        if v.Host != "" {
+            ecsSet(doc, "url.domain", ecsHost(v.Host))
+        }
+        if len(v.Query) > 0 {
+            ecsSet(doc, "url.query", url.Values(v.Query).Encode())
+        }
+        if v.Proto != "" {
+            ecsSet(doc, "http.version", strings.TrimPrefix(v.Proto, "HTTP/"))
+        }
+        if v.UserAgent != "" {
+            ecsSet(doc, "user_agent.original", v.UserAgent)
+        }
+        if v.ContentLength != 0 {
+            ecsSet(doc, "http.request.body.bytes", v.ContentLength)
+        }
+        if len(v.Headers) > 0 {
+            ecsSet(doc, "http.request.headers", v.Headers)
+        }
+        if v.Body != "" {
+            ecsSet(doc, "http.request.body.content", v.Body)
+        }
+    case ResponseLogEntry:
+        if v.StatusCode != 0 {
+            ecsSet(doc, "http.response.status_code", v.StatusCode)
+        }
+        if v.Path != "" {
+            ecsSet(doc, "url.path", v.Path)
+        }
+        if v.ContentLength != 0 {
+            ecsSet(doc, "http.response.body.bytes", v.ContentLength)
+        }
+        if v.Duration != 0 {
+            ecsSet(doc, "event.duration", v.Duration.Nanoseconds())
+        }
+        if len(v.Headers) > 0 {
+            ecsSet(doc, "http.response.headers", v.Headers)
+        }
+    default:
+        if fieldResult.Name == "message" {
+            doc["message"] = fmt.Sprintf("%v", v)
+            return
+        }
+        ecsSet(doc, fieldResult.Name, v)
+    }
+}
+
+// ecsSet sets the value at the dotted path in the document, creating intermediate objects as needed. Intermediate
+// values that are not objects are overwritten.
+func ecsSet(doc map[string]any, path string, value any) {
+    keys := strings.Split(path, ".")
+
+    current := doc
+    for _, key := range keys[:len(keys)-1] {
+        next, ok := current[key].(map[string]any)
+        if !ok {
+            next = map[string]any{}
+            current[key] = next
+        }
+        current = next
+    }
+
+    current[keys[len(keys)-1]] = value
+}
+
+// ecsStackTrace returns the stack trace of errors that render one with the %+v verb (e.g. github.com/pkg/errors), or
+// an empty string if the error does not provide one.
+func ecsStackTrace(err error) string {
+    if _, ok := err.(fmt.Formatter); !ok {
+        return ""
+    }
+
+    verbose := fmt.Sprintf("%+v", err)
+    if verbose == err.Error() {
+        return ""
+    }
+    return verbose
+}
+
+// ecsHost strips the port from an address, since source.ip must be a bare IP address.
+func ecsHost(addr string) string {
+    host, _, err := net.SplitHostPort(addr)
+    if err != nil {
+        return addr
+    }
+    return host
+}
@@ -0,0 +1,155 @@
+package ultralogger
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "reflect"
+    "testing"
+    "time"
+)
+
+func ExampleNewECSFormatter() {
+    formatter, _ := NewECSFormatter([]Field{
+        NewLevelField(Brackets.None),
+        NewTagField(Brackets.None, nil),
+        NewMessageField(),
+    })
+    formatter.clock = mockClock{}
+
+    logger, _ := NewLoggerWithOptions(WithDestination(os.Stdout, formatter), WithTag("api"), WithAsync(false))
+
+    logger.Info("This is an info message.")
+    // Output: {"@timestamp":"2024-11-07T19:30:00Z","ecs":{"version":"8.11.0"},"log":{"level":"info","logger":"api"},"message":"This is an info message."}
+}
+
+type stackError struct{}
+
+func (e stackError) Error() string {
+    return "stack error"
+}
+
+func (e stackError) Format(s fmt.State, verb rune) {
+    if s.Flag('+') {
+        _, _ = fmt.Fprint(s, "stack error\nmain.go:12")
+        return
+    }
+    _, _ = fmt.Fprint(s, e.Error())
+}
+
+func TestECSFormatter_FormatLogLine(t *testing.T) {
+    type user struct {
+        Name string `json:"name"`
+    }
+
+    userField, _ := NewObjectField[user]("user", func(args LogLineArgs, data user) any {
+        return data.Name
+    })
+    requestField, _ := NewRequestField("request", RequestFieldSettings{LogMethod: true, LogPath: true, LogSourceIP: true})
+    errField, _ := NewErrorField("error")
+    durationField, _ := NewDurationField("event.duration")
+
+    request := httptest.NewRequest(http.MethodGet, "/users", nil)
+    request.RemoteAddr = "10.0.0.1:1234"
+
+    tests := []struct {
+        name   string
+        fields []Field
+        data   any
+        want   map[string]any
+    }{
+        {
+            name:   "ECS path",
+            fields: []Field{userField.WithECSPath("user.name")},
+            data:   user{Name: "john"},
+            want: map[string]any{
+                "user": map[string]any{"name": "john"},
+            },
+        },
+        {
+            name:   "Request",
+            fields: []Field{requestField},
+            data:   request,
+            want: map[string]any{
+                "http":   map[string]any{"request": map[string]any{"method": "GET"}},
+                "url":    map[string]any{"path": "/users"},
+                "source": map[string]any{"ip": "10.0.0.1"},
+            },
+        },
+        {
+            name:   "Error",
+            fields: []Field{errField},
+            data:   errors.New("boom"),
+            want: map[string]any{
+                "error": map[string]any{"message": "boom", "type": "*errors.errorString"},
+            },
+        },
+        {
+            name:   "Error with stack trace",
+            fields: []Field{errField},
+            data:   stackError{},
+            want: map[string]any{
+                "error": map[string]any{
+                    "message":     "stack error",
+                    "type":        "ultralogger.stackError",
+                    "stack_trace": "stack error\nmain.go:12",
+                },
+            },
+        },
+        {
+            name:   "Dotted field name",
+            fields: []Field{durationField},
+            data:   time.Duration(1500),
+            want: map[string]any{
+                "event": map[string]any{"duration": 1500.0},
+            },
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            f, err := NewECSFormatter(tt.fields)
+            if err != nil {
+                t.Fatalf("NewECSFormatter() error = %v", err)
+            }
+            f.clock = mockClock{}
+
+            res := f.FormatLogLine(LogLineArgs{Level: Warn}, tt.data)
+            if res.err != nil {
+                t.Fatalf("FormatLogLine() error = %v", res.err)
+            }
+
+            got := map[string]any{}
+            if err := json.Unmarshal(res.bytes, &got); err != nil {
+                t.Fatalf("FormatLogLine() returned invalid JSON: %v", err)
+            }
+
+            want := map[string]any{
+                "@timestamp": "2024-11-07T19:30:00Z",
+                "ecs":        map[string]any{"version": "8.11.0"},
+                "log":        map[string]any{"level": "warn"},
+            }
+            for k, v := range tt.want {
+                want[k] = v
+            }
+
+            if !reflect.DeepEqual(got, want) {
+                t.Errorf("FormatLogLine() = %v, want %v", got, want)
+            }
+        })
+    }
+}
+
+func TestECSSet(t *testing.T) {
+    doc := map[string]any{"event": "overwritten"}
+
+    ecsSet(doc, "event.duration", 10)
+    ecsSet(doc, "event.kind", "event")
+
+    want := map[string]any{"event": map[string]any{"duration": 10, "kind": "event"}}
+    if !reflect.DeepEqual(doc, want) {
+        t.Errorf("ecsSet() = %v, want %v", doc, want)
+    }
+}
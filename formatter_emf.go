@@ -0,0 +1,101 @@
+package ultralogger
+
+import (
+    "encoding/json"
+)
+
+// EMFFormatter is a formatter that formats log lines as AWS CloudWatch Embedded Metric Format (EMF) documents.
+//
+// Field results are written at the top level of the document, like the JSONFormatter. Results produced by
+// [NewMetricField] additionally write their dimension and metric values at the top level, and declare the metrics in
+// the "_aws" metadata block so that CloudWatch extracts them as metrics. Log lines without metric values are written
+// without the "_aws" block.
+//
+// See https://docs.aws.amazon.com/AmazonCloudWatch/latest/monitoring/CloudWatch_Embedded_Metric_Format_Specification.html
+// for more information.
+type EMFFormatter struct {
+    Fields []Field
+
+    clock clock
+    plan  fieldPlanCache
+}
+
+// NewEMFFormatter returns a new EMFFormatter with the provided fields.
+func NewEMFFormatter(fields []Field) (*EMFFormatter, error) {
+    f := &EMFFormatter{
+        Fields: fields,
+        clock:  &realClock{},
+    }
+    if _, err := f.plan.get(fields); err != nil {
+        return nil, err
+    }
+
+    return f, nil
+}
+
+// emfMetadata is the "_aws" metadata block of an EMF document.
+type emfMetadata struct {
+    Timestamp         int64              `json:"Timestamp"`
+    CloudWatchMetrics []*metricDirective `json:"CloudWatchMetrics"`
+}
+
+// FormatLogLine formats the log line using the provided data and returns a FormatResult which contains the formatted
+// log line and any errors that may have occurred.
+func (f *EMFFormatter) FormatLogLine(args LogLineArgs, data any) FormatResult {
+    plan, err := f.plan.get(f.Fields)
+    if err != nil {
+        return FormatResult{nil, err}
+    }
+
+    c := f.clock
+    if c == nil {
+        c = &realClock{}
+    }
+
+    args.OutputFormat = OutputFormatJSON
+
+    doc := make(map[string]any)
+    metadata := &emfMetadata{
+        Timestamp:         c.Now().UnixMilli(),
+        CloudWatchMetrics: []*metricDirective{},
+    }
+
+    for i := range plan.fields {
+        fieldResult, err := plan.result(i, args, data)
+        if err != nil {
+            return FormatResult{nil, err}
+        }
+
+        if fieldResult == nil || fieldResult.Data == nil {
+            continue
+        }
+
+        metrics, ok := fieldResult.Data.(*metricResult)
+        if !ok {
+            doc[fieldResult.Name] = fieldResult.Data
+            continue
+        }
+
+        directive := metrics.directive()
+        if directive == nil {
+            continue
+        }
+
+        members, err := metrics.members()
+        if err != nil {
+            return FormatResult{nil, err}
+        }
+
+        for name, value := range members {
+            doc[name] = value
+        }
+        metadata.CloudWatchMetrics = append(metadata.CloudWatchMetrics, directive)
+    }
+
+    if len(metadata.CloudWatchMetrics) > 0 {
+        doc["_aws"] = metadata
+    }
+
+    jBytes, err := json.Marshal(doc)
+    return FormatResult{jBytes, err}
+}
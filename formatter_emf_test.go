@@ -0,0 +1,271 @@
+package ultralogger
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "os"
+    "slices"
+    "testing"
+)
+
+func ExampleNewEMFFormatter() {
+    latencyField, _ := NewMetricField("metrics", MetricSettings{
+        Namespace:  "MyApp",
+        Dimensions: [][]string{{"Service"}},
+        Metrics:    []MetricDefinition{{Name: "Latency", Unit: MetricUnitMilliseconds}},
+    })
+
+    formatter, _ := NewEMFFormatter([]Field{NewLevelField(Brackets.None), latencyField})
+    formatter.clock = mockClock{}
+
+    logger, _ := NewLoggerWithOptions(WithDestination(os.Stdout, formatter), WithAsync(false))
+
+    logger.Info(MetricData{
+        Dimensions: map[string]string{"Service": "checkout"},
+        Values:     map[string]float64{"Latency": 12.5},
+    })
+    // Output: {"Latency":12.5,"Service":"checkout","_aws":{"Timestamp":1731007800000,"CloudWatchMetrics":[{"Namespace":"MyApp","Dimensions":[["Service"]],"Metrics":[{"Name":"Latency","Unit":"Milliseconds"}]}]},"level":"INFO"}
+}
+
+// validateEMF validates an EMF document against the rules of the Embedded Metric Format specification.
+func validateEMF(doc map[string]any) error {
+    aws, ok := doc["_aws"].(map[string]any)
+    if !ok {
+        return errors.New("_aws must be an object")
+    }
+
+    if _, ok := aws["Timestamp"].(float64); !ok {
+        return errors.New("_aws.Timestamp must be a number")
+    }
+
+    directives, ok := aws["CloudWatchMetrics"].([]any)
+    if !ok {
+        return errors.New("_aws.CloudWatchMetrics must be an array")
+    }
+
+    for _, d := range directives {
+        directive, ok := d.(map[string]any)
+        if !ok {
+            return errors.New("metric directive must be an object")
+        }
+
+        namespace, ok := directive["Namespace"].(string)
+        if !ok || namespace == "" || len(namespace) > 255 {
+            return errors.New("Namespace must be a non-empty string of at most 255 characters")
+        }
+
+        dimensionSets, ok := directive["Dimensions"].([]any)
+        if !ok {
+            return errors.New("Dimensions must be an array")
+        }
+        for _, ds := range dimensionSets {
+            dimensionSet, ok := ds.([]any)
+            if !ok || len(dimensionSet) > 30 {
+                return errors.New("dimension set must be an array of at most 30 dimensions")
+            }
+            for _, dimension := range dimensionSet {
+                name, ok := dimension.(string)
+                if !ok {
+                    return errors.New("dimension must be a string")
+                }
+                if _, ok := doc[name].(string); !ok {
+                    return fmt.Errorf("dimension %s must reference a string member of the root node", name)
+                }
+            }
+        }
+
+        metrics, ok := directive["Metrics"].([]any)
+        if !ok || len(metrics) > 100 {
+            return errors.New("Metrics must be an array of at most 100 metrics")
+        }
+        for _, m := range metrics {
+            metric, ok := m.(map[string]any)
+            if !ok {
+                return errors.New("metric definition must be an object")
+            }
+            name, ok := metric["Name"].(string)
+            if !ok {
+                return errors.New("metric Name must be a string")
+            }
+            if _, ok := doc[name].(float64); !ok {
+                return fmt.Errorf("metric %s must reference a numeric member of the root node", name)
+            }
+            if unit, ok := metric["Unit"]; ok {
+                if u, ok := unit.(string); !ok || !slices.Contains(validMetricUnits, MetricUnit(u)) {
+                    return fmt.Errorf("metric %s has an invalid unit", name)
+                }
+            }
+        }
+    }
+
+    return nil
+}
+
+func TestEMFFormatter_FormatLogLine(t *testing.T) {
+    requestMetrics, _ := NewMetricField("request", MetricSettings{
+        Namespace:  "MyApp",
+        Dimensions: [][]string{{"Service", "Route"}, {"Service"}},
+        Metrics: []MetricDefinition{
+            {Name: "Latency", Unit: MetricUnitMilliseconds, StorageResolution: 1},
+            {Name: "ResponseSize", Unit: MetricUnitBytes},
+            {Name: "Requests"},
+        },
+    })
+
+    tests := []struct {
+        name        string
+        data        MetricData
+        wantMetrics []string
+        wantErr     bool
+    }{
+        {
+            name: "All metrics",
+            data: MetricData{
+                Dimensions: map[string]string{"Service": "api", "Route": "/orders"},
+                Values:     map[string]float64{"Latency": 12, "ResponseSize": 2048, "Requests": 1},
+            },
+            wantMetrics: []string{"Latency", "ResponseSize", "Requests"},
+        },
+        {
+            name: "Subset of metrics",
+            data: MetricData{
+                Dimensions: map[string]string{"Service": "api", "Route": "/orders"},
+                Values:     map[string]float64{"Requests": 1, "Undeclared": 5},
+            },
+            wantMetrics: []string{"Requests"},
+        },
+        {
+            name: "No metrics",
+            data: MetricData{
+                Dimensions: map[string]string{"Service": "api", "Route": "/orders"},
+            },
+        },
+        {
+            name: "Missing dimension",
+            data: MetricData{
+                Dimensions: map[string]string{"Service": "api"},
+                Values:     map[string]float64{"Requests": 1},
+            },
+            wantErr: true,
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            f, err := NewEMFFormatter([]Field{NewMessageField(), requestMetrics})
+            if err != nil {
+                t.Fatalf("NewEMFFormatter() error = %v", err)
+            }
+            f.clock = mockClock{}
+
+            res := f.FormatLogLine(LogLineArgs{Level: Info}, tt.data)
+            if (res.err != nil) != tt.wantErr {
+                t.Fatalf("FormatLogLine() error = %v, wantErr %v", res.err, tt.wantErr)
+            }
+            if tt.wantErr {
+                return
+            }
+
+            doc := map[string]any{}
+            if err := json.Unmarshal(res.bytes, &doc); err != nil {
+                t.Fatalf("FormatLogLine() returned invalid JSON: %v", err)
+            }
+
+            if len(tt.wantMetrics) == 0 {
+                if _, ok := doc["_aws"]; ok {
+                    t.Errorf("FormatLogLine() = %s, want no _aws block", res.bytes)
+                }
+                return
+            }
+
+            if err := validateEMF(doc); err != nil {
+                t.Fatalf("FormatLogLine() = %s, invalid EMF: %v", res.bytes, err)
+            }
+
+            directive := doc["_aws"].(map[string]any)["CloudWatchMetrics"].([]any)[0].(map[string]any)
+            var gotMetrics []string
+            for _, m := range directive["Metrics"].([]any) {
+                gotMetrics = append(gotMetrics, m.(map[string]any)["Name"].(string))
+            }
+            if !slices.Equal(gotMetrics, tt.wantMetrics) {
+                t.Errorf("declared metrics = %v, want %v", gotMetrics, tt.wantMetrics)
+            }
+        })
+    }
+}
+
+func TestNewMetricField(t *testing.T) {
+    tests := []struct {
+        name     string
+        settings MetricSettings
+        wantErr  bool
+    }{
+        {
+            name:     "Valid",
+            settings: MetricSettings{Namespace: "ns", Metrics: []MetricDefinition{{Name: "Count", Unit: MetricUnitCount}}},
+        },
+        {
+            name:     "Empty namespace",
+            settings: MetricSettings{Metrics: []MetricDefinition{{Name: "Count"}}},
+            wantErr:  true,
+        },
+        {
+            name:     "No metrics",
+            settings: MetricSettings{Namespace: "ns"},
+            wantErr:  true,
+        },
+        {
+            name:     "Duplicate metric",
+            settings: MetricSettings{Namespace: "ns", Metrics: []MetricDefinition{{Name: "Count"}, {Name: "Count"}}},
+            wantErr:  true,
+        },
+        {
+            name:     "Invalid unit",
+            settings: MetricSettings{Namespace: "ns", Metrics: []MetricDefinition{{Name: "Count", Unit: "Apples"}}},
+            wantErr:  true,
+        },
+        {
+            name:     "Invalid storage resolution",
+            settings: MetricSettings{Namespace: "ns", Metrics: []MetricDefinition{{Name: "Count", StorageResolution: 5}}},
+            wantErr:  true,
+        },
+        {
+            name: "Dimension collides with metric",
+            settings: MetricSettings{
+                Namespace:  "ns",
+                Dimensions: [][]string{{"Count"}},
+                Metrics:    []MetricDefinition{{Name: "Count"}},
+            },
+            wantErr: true,
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            _, err := NewMetricField("metrics", tt.settings)
+            if (err != nil) != tt.wantErr {
+                t.Errorf("NewMetricField() error = %v, wantErr %v", err, tt.wantErr)
+            }
+        })
+    }
+}
+
+func TestMetricField_Text(t *testing.T) {
+    field, _ := NewMetricField("metrics", MetricSettings{
+        Namespace:  "ns",
+        Dimensions: [][]string{{"Service"}},
+        Metrics:    []MetricDefinition{{Name: "Latency"}, {Name: "Count"}},
+    })
+
+    formatter, _ := field.NewFieldFormatter()
+    result, err := formatter(LogLineArgs{OutputFormat: OutputFormatText}, MetricData{
+        Dimensions: map[string]string{"Service": "api"},
+        Values:     map[string]float64{"Latency": 1.5, "Count": 2},
+    })
+    if err != nil {
+        t.Fatalf("formatter() error = %v", err)
+    }
+
+    if want := "Count=2 Latency=1.5 Service=api"; result.Data != want {
+        t.Errorf("formatter() = %v, want %v", result.Data, want)
+    }
+}
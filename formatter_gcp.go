@@ -0,0 +1,212 @@
+package ultralogger
+
+import (
+    "encoding/json"
+    "fmt"
+    "net/url"
+    "strconv"
+    "time"
+)
+
+const (
+    gcpTraceKey          = "logging.googleapis.com/trace"
+    gcpSpanIDKey         = "logging.googleapis.com/spanId"
+    gcpTraceSampledKey   = "logging.googleapis.com/trace_sampled"
+    gcpSourceLocationKey = "logging.googleapis.com/sourceLocation"
+)
+
+// gcpSeverities maps a Level to a Cloud Logging LogSeverity.
+//
+// See https://cloud.google.com/logging/docs/reference/v2/rest/v2/LogEntry#LogSeverity for more information.
+var gcpSeverities = map[Level]string{
+    Debug: "DEBUG",
+    Info:  "INFO",
+    Warn:  "WARNING",
+    Error: "ERROR",
+    Panic: "CRITICAL",
+}
+
+// GCPFormatterSettings are the settings for a GCPFormatter.
+type GCPFormatterSettings struct {
+    // ProjectID is the Google Cloud project id. If it is set, trace ids are written in the
+    // "projects/PROJECT_ID/traces/TRACE_ID" form that Cloud Logging uses to link log entries to Cloud Trace. If it is
+    // empty, the bare trace id is written.
+    ProjectID string
+}
+
+// GCPFormatter is a formatter that formats log lines as the structured JSON understood by the Cloud Logging agent
+// (and by Cloud Run, GKE and Cloud Functions, which parse JSON written to stdout).
+//
+// The formatter writes the following special fields:
+//  - "severity" => the Level of the log line.
+//  - "message" => the result of the "message" field.
+//  - "time" => the time the line was formatted, in RFC3339Nano.
+//  - "logging.googleapis.com/trace", "logging.googleapis.com/spanId" and "logging.googleapis.com/trace_sampled" =>
+//    the [SpanContext] of the context passed to [ContextLogger.LogContext], if any.
+//  - "logging.googleapis.com/sourceLocation" => the Caller of the log line, if the logger was created with
+//    [WithCaller].
+//  - "httpRequest" => results produced by [NewRequestField] and [NewResponseField].
+//
+// Every other field result is written at its name, and ends up in the jsonPayload of the log entry.
+//
+// See https://cloud.google.com/logging/docs/structured-logging for more information.
+type GCPFormatter struct {
+    Fields   []Field
+    Settings GCPFormatterSettings
+
+    clock clock
+    plan  fieldPlanCache
+}
+
+// NewGCPFormatter returns a new GCPFormatter with the provided fields and settings.
+func NewGCPFormatter(fields []Field, settings GCPFormatterSettings) (*GCPFormatter, error) {
+    f := &GCPFormatter{
+        Fields:   fields,
+        Settings: settings,
+        clock:    &realClock{},
+    }
+    if _, err := f.plan.get(fields); err != nil {
+        return nil, err
+    }
+
+    return f, nil
+}
+
+// FormatLogLine formats the log line using the provided data and returns a FormatResult which contains the formatted
+// log line and any errors that may have occurred.
+func (f *GCPFormatter) FormatLogLine(args LogLineArgs, data any) FormatResult {
+    plan, err := f.plan.get(f.Fields)
+    if err != nil {
+        return FormatResult{nil, err}
+    }
+
+    c := f.clock
+    if c == nil {
+        c = &realClock{}
+    }
+
+    args.OutputFormat = OutputFormatJSON
+
+    entry := map[string]any{
+        "severity": gcpSeverity(args.Level),
+        "time":     c.Now().Format(time.RFC3339Nano),
+    }
+
+    if sc, ok := SpanContextFromContext(args.Context); ok {
+        entry[gcpTraceKey] = f.traceName(sc.TraceID)
+        if sc.SpanID != "" {
+            entry[gcpSpanIDKey] = sc.SpanID
+        }
+        entry[gcpTraceSampledKey] = sc.Sampled
+    }
+
+    if args.Caller != nil {
+        entry[gcpSourceLocationKey] = map[string]any{
+            "file":     args.Caller.File,
+            "line":     strconv.Itoa(args.Caller.Line),
+            "function": args.Caller.Function,
+        }
+    }
+
+    httpRequest := map[string]any{}
+
+    for i, field := range plan.fields {
+        switch field.(type) {
+        case *levelField, *currentTimeField:
+            // These are already covered by severity and time.
+            continue
+        }
+
+        fieldResult, err := plan.result(i, args, data)
+        if err != nil {
+            return FormatResult{nil, err}
+        }
+
+        if fieldResult == nil || fieldResult.Data == nil {
+            continue
+        }
+
+        switch v := fieldResult.Data.(type) {
+        case RequestLogEntry:
+            gcpSetRequest(httpRequest, v)
+        case ResponseLogEntry:
+            gcpSetResponse(httpRequest, v)
+        default:
+            if fieldResult.Name == "message" {
+                entry["message"] = fmt.Sprintf("%v", v)
+                continue
+            }
+            entry[fieldResult.Name] = v
+        }
+    }
+
+    if len(httpRequest) > 0 {
+        entry["httpRequest"] = httpRequest
+    }
+
+    jBytes, err := json.Marshal(entry)
+    return FormatResult{jBytes, err}
+}
+
+func (f *GCPFormatter) traceName(traceID string) string {
+    if f.Settings.ProjectID == "" {
+        return traceID
+    }
+    return "projects/" + f.Settings.ProjectID + "/traces/" + traceID
+}
+
+func gcpSeverity(level Level) string {
+    severity, ok := gcpSeverities[level]
+    if !ok {
+        return "DEFAULT"
+    }
+    return severity
+}
+
+// gcpSetRequest sets the HttpRequest keys that can be derived from a RequestLogEntry.
+//
+// See https://cloud.google.com/logging/docs/reference/v2/rest/v2/LogEntry#HttpRequest for more information.
+func gcpSetRequest(httpRequest map[string]any, entry RequestLogEntry) {
+    if entry.Method != "" {
+        httpRequest["requestMethod"] = entry.Method
+    }
+    if entry.Path != "" {
+        requestURL := entry.Path
+        if len(entry.Query) > 0 {
+            requestURL += "?" + url.Values(entry.Query).Encode()
+        }
+        httpRequest["requestUrl"] = requestURL
+    }
+    if entry.ClientIP != "" {
+        httpRequest["remoteIp"] = entry.ClientIP
+    } else if entry.SourceIP != "" {
+        httpRequest["remoteIp"] = entry.SourceIP
+    }
+    if entry.UserAgent != "" {
+        httpRequest["userAgent"] = entry.UserAgent
+    }
+    if entry.ContentLength != 0 {
+        httpRequest["requestSize"] = strconv.FormatInt(entry.ContentLength, 10)
+    }
+    if entry.Proto != "" {
+        httpRequest["protocol"] = entry.Proto
+    }
+}
+
+// gcpSetResponse sets the HttpRequest keys that can be derived from a ResponseLogEntry.
+func gcpSetResponse(httpRequest map[string]any, entry ResponseLogEntry) {
+    if entry.StatusCode != 0 {
+        httpRequest["status"] = entry.StatusCode
+    }
+    if entry.ContentLength != 0 {
+        httpRequest["responseSize"] = strconv.FormatInt(entry.ContentLength, 10)
+    }
+    if entry.Duration != 0 {
+        httpRequest["latency"] = strconv.FormatFloat(entry.Duration.Seconds(), 'f', -1, 64) + "s"
+    }
+    if entry.Path != "" {
+        if _, ok := httpRequest["requestUrl"]; !ok {
+            httpRequest["requestUrl"] = entry.Path
+        }
+    }
+}
@@ -0,0 +1,144 @@
+package ultralogger
+
+import (
+    "context"
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "reflect"
+    "testing"
+)
+
+func ExampleNewGCPFormatter() {
+    formatter, _ := NewGCPFormatter([]Field{
+        NewLevelField(Brackets.None),
+        NewMessageField(),
+    }, GCPFormatterSettings{ProjectID: "my-project"})
+    formatter.clock = mockClock{}
+
+    logger, _ := NewLoggerWithOptions(WithDestination(os.Stdout, formatter), WithAsync(false))
+
+    ctx := ContextWithSpanContext(context.Background(), SpanContext{
+        TraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+        SpanID:  "00f067aa0ba902b7",
+        Sampled: true,
+    })
+
+    logger.(ContextLogger).LogContext(ctx, Warn, "This is a warning message.")
+    // Output: {"logging.googleapis.com/spanId":"00f067aa0ba902b7","logging.googleapis.com/trace":"projects/my-project/traces/4bf92f3577b34da6a3ce929d0e0e4736","logging.googleapis.com/trace_sampled":true,"message":"This is a warning message.","severity":"WARNING","time":"2024-11-07T19:30:00Z"}
+}
+
+func TestGCPFormatter_FormatLogLine(t *testing.T) {
+    requestField, _ := NewRequestField("request", RequestFieldSettings{LogMethod: true, LogPath: true, LogSourceIP: true})
+    responseField, _ := NewResponseField("response", ResponseFieldSettings{LogStatusCode: true, LogPath: true})
+    stringField, _ := NewStringField("user")
+
+    request := httptest.NewRequest(http.MethodPost, "/orders", nil)
+    request.RemoteAddr = "10.0.0.1:1234"
+
+    tests := []struct {
+        name   string
+        fields []Field
+        args   LogLineArgs
+        data   any
+        want   map[string]any
+    }{
+        {
+            name:   "Severity",
+            fields: []Field{NewLevelField(Brackets.Angle), NewMessageField()},
+            args:   LogLineArgs{Level: Panic},
+            data:   "boom",
+            want: map[string]any{
+                "severity": "CRITICAL",
+                "time":     "2024-11-07T19:30:00Z",
+                "message":  "boom",
+            },
+        },
+        {
+            name:   "Source location",
+            fields: []Field{stringField},
+            args: LogLineArgs{
+                Level:  Debug,
+                Caller: &Caller{Function: "main.main", File: "/app/main.go", Line: 42},
+            },
+            data: "john",
+            want: map[string]any{
+                "severity": "DEBUG",
+                "time":     "2024-11-07T19:30:00Z",
+                "user":     "john",
+                gcpSourceLocationKey: map[string]any{
+                    "file":     "/app/main.go",
+                    "line":     "42",
+                    "function": "main.main",
+                },
+            },
+        },
+        {
+            name:   "Trace without project",
+            fields: []Field{},
+            args: LogLineArgs{
+                Level:   Info,
+                Context: ContextWithSpanContext(context.Background(), SpanContext{TraceID: "abc"}),
+            },
+            want: map[string]any{
+                "severity":         "INFO",
+                "time":             "2024-11-07T19:30:00Z",
+                gcpTraceKey:        "abc",
+                gcpTraceSampledKey: false,
+            },
+        },
+        {
+            name:   "HTTP request",
+            fields: []Field{requestField},
+            args:   LogLineArgs{Level: Error},
+            data:   request,
+            want: map[string]any{
+                "severity": "ERROR",
+                "time":     "2024-11-07T19:30:00Z",
+                "httpRequest": map[string]any{
+                    "requestMethod": "POST",
+                    "requestUrl":    "/orders",
+                    "remoteIp":      "10.0.0.1:1234",
+                },
+            },
+        },
+        {
+            name:   "HTTP response",
+            fields: []Field{responseField},
+            args:   LogLineArgs{Level: Info},
+            data:   &http.Response{StatusCode: 201, Request: request},
+            want: map[string]any{
+                "severity": "INFO",
+                "time":     "2024-11-07T19:30:00Z",
+                "httpRequest": map[string]any{
+                    "status":     201.0,
+                    "requestUrl": "/orders",
+                },
+            },
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            f, err := NewGCPFormatter(tt.fields, GCPFormatterSettings{})
+            if err != nil {
+                t.Fatalf("NewGCPFormatter() error = %v", err)
+            }
+            f.clock = mockClock{}
+
+            res := f.FormatLogLine(tt.args, tt.data)
+            if res.err != nil {
+                t.Fatalf("FormatLogLine() error = %v", res.err)
+            }
+
+            got := map[string]any{}
+            if err := json.Unmarshal(res.bytes, &got); err != nil {
+                t.Fatalf("FormatLogLine() returned invalid JSON: %v", err)
+            }
+
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("FormatLogLine() = %v, want %v", got, tt.want)
+            }
+        })
+    }
+}
@@ -0,0 +1,189 @@
+package ultralogger
+
+import (
+    "encoding/json"
+    "fmt"
+    "os"
+    "strings"
+    "time"
+)
+
+const gelfVersion = "1.1"
+
+// gelfSeverities maps a Level to the syslog severity used by the GELF "level" field.
+//
+// See https://en.wikipedia.org/wiki/Syslog#Severity_level for more information.
+var gelfSeverities = map[Level]int{
+    Debug: 7,
+    Info:  6,
+    Warn:  4,
+    Error: 3,
+    Panic: 2,
+}
+
+// GELFFormatter is a formatter that formats log lines as GELF 1.1 (Graylog Extended Log Format) messages.
+//
+// The "message" field result is used as the GELF short_message. If the message spans multiple lines, only the first
+// line is used as the short_message and the complete message is sent as the full_message. The "level" field result is
+// ignored, since the GELF level is always derived from the Level of the log line. Every other field result is added as
+// an additional field, prefixed with an underscore.
+//
+// See https://go2docs.graylog.org/current/getting_in_log_data/gelf.html for more information.
+type GELFFormatter struct {
+    Fields []Field
+    // Host is the name of the host, source or application that sent the message.
+    Host string
+
+    clock clock
+    plan  fieldPlanCache
+}
+
+// NewGELFFormatter returns a new GELFFormatter with the provided host and fields. If the host is empty, the hostname
+// reported by the kernel is used.
+func NewGELFFormatter(host string, fields []Field) (*GELFFormatter, error) {
+    if host == "" {
+        hostname, err := os.Hostname()
+        if err != nil {
+            return nil, err
+        }
+        host = hostname
+    }
+
+    f := &GELFFormatter{
+        Fields: fields,
+        Host:   host,
+        clock:  &realClock{},
+    }
+    if _, err := f.plan.get(fields); err != nil {
+        return nil, err
+    }
+
+    return f, nil
+}
+
+// FormatLogLine formats the log line using the provided data and returns a FormatResult which contains the formatted
+// log line and any errors that may have occurred.
+func (f *GELFFormatter) FormatLogLine(args LogLineArgs, data any) FormatResult {
+    plan, err := f.plan.get(f.Fields)
+    if err != nil {
+        return FormatResult{nil, err}
+    }
+
+    c := f.clock
+    if c == nil {
+        c = &realClock{}
+    }
+
+    args.OutputFormat = OutputFormatJSON
+
+    gelfMap := map[string]any{
+        "version":   gelfVersion,
+        "host":      f.Host,
+        "timestamp": gelfTimestamp(c.Now()),
+        "level":     gelfSeverity(args.Level),
+    }
+
+    for i := range plan.fields {
+        fieldResult, err := plan.result(i, args, data)
+        if err != nil {
+            return FormatResult{nil, err}
+        }
+
+        if fieldResult == nil || fieldResult.Data == nil {
+            continue
+        }
+
+        switch fieldResult.Name {
+        case "message":
+            message := fmt.Sprintf("%v", fieldResult.Data)
+            shortMessage, _, multiline := strings.Cut(message, "\n")
+            gelfMap["short_message"] = shortMessage
+            if multiline {
+                gelfMap["full_message"] = message
+            }
+        case "level":
+            continue
+        default:
+            gelfMap[gelfAdditionalFieldName(fieldResult.Name)] = gelfAdditionalFieldValue(fieldResult.Data)
+        }
+    }
+
+    // short_message is required by the spec, so we never omit it.
+    if _, ok := gelfMap["short_message"]; !ok {
+        gelfMap["short_message"] = ""
+    }
+
+    jBytes, err := json.Marshal(gelfMap)
+    return FormatResult{jBytes, err}
+}
+
+func gelfSeverity(level Level) int {
+    severity, ok := gelfSeverities[level]
+    if !ok {
+        // Unknown levels are reported as notices rather than dropping the severity entirely.
+        return 5
+    }
+    return severity
+}
+
+// gelfTimestamp returns the time as seconds since the UNIX epoch with millisecond precision.
+func gelfTimestamp(t time.Time) float64 {
+    return float64(t.UnixMilli()) / 1000
+}
+
+// gelfAdditionalFieldName prefixes the name with an underscore and replaces any character not allowed by the spec
+// (^[\w\.\-]*$) with an underscore. "_id" is reserved by Graylog, so a field named "id" is sent as "__id".
+func gelfAdditionalFieldName(name string) string {
+    b := strings.Builder{}
+    b.WriteByte('_')
+
+    if name == "id" {
+        b.WriteByte('_')
+    }
+
+    for _, r := range name {
+        switch {
+        case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '.', r == '-':
+            b.WriteRune(r)
+        default:
+            b.WriteByte('_')
+        }
+    }
+
+    return b.String()
+}
+
+// gelfAdditionalFieldValue converts the data into a value that GELF accepts for an additional field. GELF only allows
+// strings and numbers, so bools, times and errors are converted to strings, and anything else is JSON encoded. Errors
+// that wrap or join other errors, or carry fields, are JSON encoded as their [ErrorLogEntry] to keep the chain.
+func gelfAdditionalFieldValue(data any) any {
+    switch v := data.(type) {
+    case string, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+        return v
+    case time.Duration:
+        return int64(v)
+    case time.Time:
+        return v.Format(time.RFC3339Nano)
+    case bool:
+        if v {
+            return "true"
+        }
+        return "false"
+    case ErrorLogEntry:
+        if len(v.Chain) == 0 && len(v.Errors) == 0 && len(v.Fields) == 0 {
+            return v.Message
+        }
+    case structObject:
+        // Structs are JSON encoded like any other object, rather than written with their String method.
+    case error:
+        return v.Error()
+    case fmt.Stringer:
+        return v.String()
+    }
+
+    jBytes, err := json.Marshal(data)
+    if err != nil {
+        return fmt.Sprintf("%v", data)
+    }
+    return string(jBytes)
+}
@@ -0,0 +1,185 @@
+package ultralogger
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "os"
+    "reflect"
+    "testing"
+)
+
+func ExampleNewGELFFormatter() {
+    formatter, _ := NewGELFFormatter("example-host", []Field{
+        NewLevelField(Brackets.None),
+        NewTagField(Brackets.None, nil),
+        NewMessageField(),
+    })
+    formatter.clock = mockClock{}
+
+    logger, _ := NewLoggerWithOptions(WithDestination(os.Stdout, formatter), WithTag("api"), WithAsync(false))
+
+    logger.Warn("This is a warning message.")
+    // Output: {"_tag":"api","host":"example-host","level":4,"short_message":"This is a warning message.","timestamp":1731007800,"version":"1.1"}
+}
+
+func TestGELFFormatter_FormatLogLine(t *testing.T) {
+    idField, _ := NewStringField("id")
+    errField, _ := NewErrorField("user error")
+    causeField, _ := NewErrorField("cause")
+    boolField, _ := NewBoolField("ok")
+    type address struct {
+        City string `ulog:"city"`
+    }
+    addressField, _ := NewStructField[address]("address")
+    mapField, _ := NewMapField[string, int]("counts", func(args LogLineArgs, data string) any {
+        return data
+    }, func(args LogLineArgs, data int) any {
+        return data
+    })
+
+    tests := []struct {
+        name   string
+        fields []Field
+        args   LogLineArgs
+        data   any
+        want   map[string]any
+    }{
+        {
+            name:   "Message",
+            fields: []Field{NewLevelField(Brackets.Angle), NewMessageField()},
+            args:   LogLineArgs{Level: Error},
+            data:   "boom",
+            want: map[string]any{
+                "version":       "1.1",
+                "host":          "host",
+                "timestamp":     1731007800.0,
+                "level":         3.0,
+                "short_message": "boom",
+            },
+        },
+        {
+            name:   "Multiline message",
+            fields: []Field{NewMessageField()},
+            args:   LogLineArgs{Level: Debug},
+            data:   "first\nsecond",
+            want: map[string]any{
+                "version":       "1.1",
+                "host":          "host",
+                "timestamp":     1731007800.0,
+                "level":         7.0,
+                "short_message": "first",
+                "full_message":  "first\nsecond",
+            },
+        },
+        {
+            name:   "Reserved id field",
+            fields: []Field{idField},
+            args:   LogLineArgs{Level: Info},
+            data:   "abc",
+            want: map[string]any{
+                "version":       "1.1",
+                "host":          "host",
+                "timestamp":     1731007800.0,
+                "level":         6.0,
+                "short_message": "",
+                "__id":          "abc",
+            },
+        },
+        {
+            name:   "Error field name sanitized",
+            fields: []Field{errField},
+            args:   LogLineArgs{Level: Panic},
+            data:   errors.New("bad"),
+            want: map[string]any{
+                "version":       "1.1",
+                "host":          "host",
+                "timestamp":     1731007800.0,
+                "level":         2.0,
+                "short_message": "",
+                "_user_error":   "bad",
+            },
+        },
+        {
+            name:   "Wrapped error",
+            fields: []Field{causeField},
+            args:   LogLineArgs{Level: Error},
+            data:   fmt.Errorf("query: %w", io.EOF),
+            want: map[string]any{
+                "version":       "1.1",
+                "host":          "host",
+                "timestamp":     1731007800.0,
+                "level":         3.0,
+                "short_message": "",
+                "_cause": `{"message":"query: EOF","type":"*fmt.wrapError",` +
+                    `"chain":[{"message":"EOF","type":"*errors.errorString"}]}`,
+            },
+        },
+        {
+            name:   "Struct field",
+            fields: []Field{addressField},
+            args:   LogLineArgs{Level: Info},
+            data:   address{City: "Paris"},
+            want: map[string]any{
+                "version":       "1.1",
+                "host":          "host",
+                "timestamp":     1731007800.0,
+                "level":         6.0,
+                "short_message": "",
+                "_address":      `{"city":"Paris"}`,
+            },
+        },
+        {
+            name:   "Bool field",
+            fields: []Field{boolField},
+            args:   LogLineArgs{Level: Info},
+            data:   true,
+            want: map[string]any{
+                "version":       "1.1",
+                "host":          "host",
+                "timestamp":     1731007800.0,
+                "level":         6.0,
+                "short_message": "",
+                "_ok":           "true",
+            },
+        },
+        {
+            name:   "Map field",
+            fields: []Field{mapField},
+            args:   LogLineArgs{Level: Info},
+            data:   map[string]int{"a": 1},
+            want: map[string]any{
+                "version":       "1.1",
+                "host":          "host",
+                "timestamp":     1731007800.0,
+                "level":         6.0,
+                "short_message": "",
+                "_counts":       `{"a":1}`,
+            },
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            f, err := NewGELFFormatter("host", tt.fields)
+            if err != nil {
+                t.Fatalf("NewGELFFormatter() error = %v", err)
+            }
+            f.clock = mockClock{}
+
+            res := f.FormatLogLine(tt.args, tt.data)
+            if res.err != nil {
+                t.Fatalf("FormatLogLine() error = %v", res.err)
+            }
+
+            got := map[string]any{}
+            if err := json.Unmarshal(res.bytes, &got); err != nil {
+                t.Fatalf("FormatLogLine() returned invalid JSON: %v", err)
+            }
+
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("FormatLogLine() = %v, want %v", got, tt.want)
+            }
+        })
+    }
+}
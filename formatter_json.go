@@ -1,40 +1,215 @@
 package ultralogger
 
 import (
-    "encoding/json"
+    "slices"
+    "time"
+)
+
+// jsonLeadingFields are the names of the fields that are always written first, in this order, regardless of where
+// they are declared.
+var jsonLeadingFields = []string{"time", "level", "message"}
+
+// TimeEncoding defines how the JSONFormatter writes time.Time values.
+type TimeEncoding int
+
+const (
+    // TimeEncodingRFC3339Nano writes times as RFC 3339 strings with nanoseconds, e.g. "2024-11-07T19:30:00.5Z". It is
+    // the default, and matches encoding/json.
+    TimeEncodingRFC3339Nano TimeEncoding = iota
+    // TimeEncodingRFC3339 writes times as RFC 3339 strings without fractional seconds, e.g. "2024-11-07T19:30:00Z".
+    TimeEncodingRFC3339
+    // TimeEncodingUnix writes times as the integer number of seconds since the Unix epoch.
+    TimeEncodingUnix
+    // TimeEncodingUnixMilli writes times as the integer number of milliseconds since the Unix epoch.
+    TimeEncodingUnixMilli
+    // TimeEncodingUnixNano writes times as the integer number of nanoseconds since the Unix epoch.
+    TimeEncodingUnixNano
+)
+
+// DurationEncoding defines how the JSONFormatter writes time.Duration values.
+type DurationEncoding int
+
+const (
+    // DurationEncodingNanoseconds writes durations as the integer number of nanoseconds. It is the default, and
+    // matches encoding/json.
+    DurationEncodingNanoseconds DurationEncoding = iota
+    // DurationEncodingMilliseconds writes durations as the integer number of milliseconds, truncated.
+    DurationEncodingMilliseconds
+    // DurationEncodingSeconds writes durations as the floating point number of seconds, e.g. 1.5.
+    DurationEncodingSeconds
+    // DurationEncodingString writes durations as strings, formatted with time.Duration.String, e.g. "1.5s".
+    DurationEncodingString
 )
 
 // JSONFormatter is a formatter that formats log lines as JSON.
+//
+// Keys are written in the order the Fields are declared, except for "time", "level" and "message", which are always
+// written first.
 type JSONFormatter struct {
     Fields                 []Field
     destinationInitialized bool
-}
+    // Policies are the policies for nil data and data of the wrong type. By default, fields with nil data and fields
+    // that reject the data are omitted.
+    Policies FieldPolicies
+    // TimeEncoding is the encoding of the time.Time values of the field results, also within arrays.
+    TimeEncoding TimeEncoding
+    // TimeLocation is the location that times are converted to before they are written, e.g. time.UTC. If it is nil,
+    // times are written in their own location.
+    TimeLocation *time.Location
+    // DurationEncoding is the encoding of the time.Duration values of the field results, also within arrays.
+    DurationEncoding DurationEncoding
 
-// TODO: Provide a way to specify behavior on nil data. I.e. if the field should be omitted, or if we should include
-//  a zero value, or something else. This is a bit tricky, because we don't know the type of the data, and we don't
-//  know the type of the field.
+    plan fieldPlanCache
+}
 
 // FormatLogLine formats the log line using the provided data and returns a FormatResult which contains the formatted
 // log line and any errors that may have occurred.
 func (f *JSONFormatter) FormatLogLine(args LogLineArgs, data any) FormatResult {
-    jsonMap := make(map[string]any)
+    plan, err := f.plan.get(f.Fields)
+    if err != nil {
+        return FormatResult{nil, err}
+    }
+
+    enc := getJSONEncoder()
+    defer putJSONEncoder(enc)
+
+    enc.timeEncoding = f.TimeEncoding
+    enc.timeLocation = f.TimeLocation
+    enc.durationEncoding = f.DurationEncoding
 
     args.OutputFormat = OutputFormatJSON
 
-    for _, field := range f.Fields {
-        fieldResult, err := computeFieldResult(field, args, data)
+    enc.results, err = appendObjectResults(enc.results, plan, args, data, f.Policies)
+    if err != nil {
+        return FormatResult{nil, err}
+    }
+
+    if err := enc.encodeObject(enc.results); err != nil {
+        return FormatResult{nil, err}
+    }
+
+    return FormatResult{enc.line(), nil}
+}
+
+// WithTimeEncoding sets the encoding of the times written by a JSONFormatter, also when it is colorized.
+func WithTimeEncoding(encoding TimeEncoding) FormatterOption {
+    return func(f LogLineFormatter) LogLineFormatter {
+        return withJSONFormatter(f, func(jsonFormatter *JSONFormatter) {
+            jsonFormatter.TimeEncoding = encoding
+        })
+    }
+}
+
+// WithTimeLocation converts the times written by a JSONFormatter to the location, also when it is colorized. Use
+// time.UTC to force UTC, or time.LoadLocation for a named location.
+func WithTimeLocation(location *time.Location) FormatterOption {
+    return func(f LogLineFormatter) LogLineFormatter {
+        return withJSONFormatter(f, func(jsonFormatter *JSONFormatter) {
+            jsonFormatter.TimeLocation = location
+        })
+    }
+}
+
+// WithDurationEncoding sets the encoding of the durations written by a JSONFormatter, also when it is colorized.
+func WithDurationEncoding(encoding DurationEncoding) FormatterOption {
+    return func(f LogLineFormatter) LogLineFormatter {
+        return withJSONFormatter(f, func(jsonFormatter *JSONFormatter) {
+            jsonFormatter.DurationEncoding = encoding
+        })
+    }
+}
+
+func withJSONFormatter(f LogLineFormatter, apply func(jsonFormatter *JSONFormatter)) LogLineFormatter {
+    switch formatter := f.(type) {
+    case *JSONFormatter:
+        apply(formatter)
+    case *ColorizedFormatter:
+        withJSONFormatter(formatter.BaseFormatter, apply)
+    case *RedactingFormatter:
+        withJSONFormatter(formatter.BaseFormatter, apply)
+    }
+    return f
+}
+
+// appendObjectResults appends the results of the fields of the plan, in the order they are written to an object: the
+// results are compacted with jsonCompactResults, and ordered by jsonFieldRank. It is shared by the formatters of the
+// structured output formats.
+func appendObjectResults(
+    results []FieldResult,
+    plan *fieldPlan,
+    args LogLineArgs,
+    data any,
+    policies FieldPolicies,
+) ([]FieldResult, error) {
+    var err error
+    for i := range plan.fields {
+        results, err = plan.appendPolicyResults(results, i, args, data, policies)
         if err != nil {
-            return FormatResult{nil, err}
+            return nil, err
+        }
+    }
+
+    results = jsonCompactResults(results)
+
+    slices.SortStableFunc(results, func(a, b FieldResult) int {
+        return jsonFieldRank(a.Name) - jsonFieldRank(b.Name)
+    })
+
+    return results, nil
+}
+
+// jsonCompactResults throws away results with nil data. Fields that don't implement NamedField can't be checked for
+// duplicates up front, so a later result with the same name replaces the earlier one in place.
+func jsonCompactResults(results []FieldResult) []FieldResult {
+    compacted := results[:0]
+
+    for _, result := range results {
+        if result.Data == nil {
+            continue
+        }
+
+        i := slices.IndexFunc(compacted, func(r FieldResult) bool {
+            return r.Name == result.Name
+        })
+        if i >= 0 {
+            compacted[i] = result
+            continue
         }
 
-        // Throw away fields that are nil or have nil data.
-        if fieldResult == nil || fieldResult.Data == nil {
+        compacted = append(compacted, result)
+    }
+
+    return compacted
+}
+
+// jsonFieldRank returns the position of the name in jsonLeadingFields, or len(jsonLeadingFields) for every other name.
+func jsonFieldRank(name string) int {
+    i := slices.Index(jsonLeadingFields, name)
+    if i < 0 {
+        return len(jsonLeadingFields)
+    }
+    return i
+}
+
+// validateObjectFields returns an ErrorDuplicateFieldName if two of the fields produce results with the same name.
+func validateObjectFields(fields []Field) error {
+    seen := make(map[string]bool, len(fields))
+
+    for _, field := range fields {
+        namedField, ok := field.(NamedField)
+        if !ok {
             continue
         }
 
-        jsonMap[fieldResult.Name] = fieldResult.Data
+        name := namedField.FieldName()
+        if name == "" {
+            continue
+        }
+        if seen[name] {
+            return &ErrorDuplicateFieldName{name: name}
+        }
+        seen[name] = true
     }
 
-    jBytes, err := json.Marshal(jsonMap)
-    return FormatResult{jBytes, err}
+    return nil
 }
@@ -0,0 +1,443 @@
+package ultralogger
+
+import (
+    "encoding/json"
+    "errors"
+    "io"
+    "net/http"
+    "os"
+    "testing"
+    "time"
+)
+
+func ExampleJSONFormatter_order() {
+    userField, _ := NewStringField("user")
+
+    formatter, _ := NewFormatter(OutputFormatJSON, []Field{
+        userField,
+        NewTagField(Brackets.None, nil),
+        NewMessageField(),
+        NewLevelField(Brackets.None),
+    })
+
+    logger, _ := NewLoggerWithOptions(WithDestination(os.Stdout, formatter), WithTag("api"), WithAsync(false))
+
+    logger.Info("john")
+    // Output: {"level":"INFO","message":"john","user":"john","tag":"api"}
+}
+
+// unnamedField is a Field that does not implement NamedField.
+type unnamedField struct {
+    name string
+}
+
+func (f unnamedField) NewFieldFormatter() (FieldFormatter, error) {
+    return func(args LogLineArgs, data any) (FieldResult, error) {
+        return FieldResult{Name: f.name, Data: f.name}, nil
+    }, nil
+}
+
+func TestJSONFormatter_FormatLogLine(t *testing.T) {
+    zField, _ := NewStringField("z")
+    aField, _ := NewStringField("a")
+    timeField, _ := NewCurrentTimeField("time", "")
+    timeField.(*currentTimeField).clock = mockClock{}
+
+    tests := []struct {
+        name   string
+        fields []Field
+        data   any
+        want   string
+    }{
+        {
+            name:   "Declaration order",
+            fields: []Field{zField, aField},
+            data:   "x",
+            want:   `{"z":"x","a":"x"}`,
+        },
+        {
+            name:   "Leading fields",
+            fields: []Field{zField, NewMessageField(), NewLevelField(Brackets.None), timeField},
+            data:   "x",
+            want:   `{"time":"2024-11-07T19:30:00Z","level":"INFO","message":"x","z":"x"}`,
+        },
+        {
+            name:   "Unnamed duplicate replaced in place",
+            fields: []Field{unnamedField{"dup"}, zField, unnamedField{"dup"}},
+            data:   "x",
+            want:   `{"dup":"dup","z":"x"}`,
+        },
+        {
+            name:   "Nil data omitted",
+            fields: []Field{zField, NewMessageField()},
+            data:   42,
+            want:   `{}`,
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            f := &JSONFormatter{Fields: tt.fields}
+
+            res := f.FormatLogLine(LogLineArgs{Level: Info}, tt.data)
+            if res.err != nil {
+                t.Fatalf("FormatLogLine() error = %v", res.err)
+            }
+
+            if string(res.bytes) != tt.want {
+                t.Errorf("FormatLogLine() = %s, want %s", res.bytes, tt.want)
+            }
+        })
+    }
+}
+
+func TestNewFormatter_DuplicateFieldName(t *testing.T) {
+    userField, _ := NewStringField("user")
+    otherUserField, _ := NewIntField("user")
+
+    tests := []struct {
+        name         string
+        outputFormat OutputFormat
+        fields       []Field
+        wantErr      bool
+    }{
+        {
+            name:         "JSON duplicate",
+            outputFormat: OutputFormatJSON,
+            fields:       []Field{userField, otherUserField},
+            wantErr:      true,
+        },
+        {
+            name:         "JSON duplicate built-in",
+            outputFormat: OutputFormatJSON,
+            fields:       []Field{NewMessageField(), NewMessageField()},
+            wantErr:      true,
+        },
+        {
+            name:         "JSON unique",
+            outputFormat: OutputFormatJSON,
+            fields:       []Field{userField, NewMessageField()},
+        },
+        {
+            name:         "Text allows duplicates",
+            outputFormat: OutputFormatText,
+            fields:       []Field{NewLevelField(Brackets.Angle), NewLevelField(Brackets.Angle)},
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            _, err := NewFormatter(tt.outputFormat, tt.fields)
+
+            var duplicate *ErrorDuplicateFieldName
+            if errors.As(err, &duplicate) != tt.wantErr {
+                t.Errorf("NewFormatter() error = %v, wantErr %v", err, tt.wantErr)
+            }
+        })
+    }
+}
+
+func ExampleWithDurationEncoding() {
+    elapsedField, _ := NewDurationField("elapsed")
+
+    formatter, _ := NewFormatter(OutputFormatJSON, []Field{elapsedField}, WithDurationEncoding(DurationEncodingSeconds))
+
+    logger, _ := NewLoggerWithOptions(WithDestination(os.Stdout, formatter), WithAsync(false))
+
+    logger.Info(1500 * time.Millisecond)
+    // Output: {"elapsed":1.5}
+}
+
+func TestJSONFormatter_TimeEncoding(t *testing.T) {
+    startField, _ := NewTimeField("start", time.Kitchen)
+    timesField, _ := NewArrayField[time.Time]("times", func(args LogLineArgs, data time.Time) any {
+        return data
+    })
+    start := time.Date(2024, 11, 7, 21, 30, 0, 500000000, time.FixedZone("CEST", 2*60*60))
+    tokyo, _ := time.LoadLocation("Asia/Tokyo")
+
+    tests := []struct {
+        name     string
+        fields   []Field
+        data     any
+        encoding TimeEncoding
+        location *time.Location
+        want     string
+    }{
+        {
+            name:   "Default",
+            fields: []Field{startField},
+            data:   start,
+            want:   `{"start":"2024-11-07T21:30:00.5+02:00"}`,
+        },
+        {
+            name:     "RFC3339",
+            fields:   []Field{startField},
+            data:     start,
+            encoding: TimeEncodingRFC3339,
+            want:     `{"start":"2024-11-07T21:30:00+02:00"}`,
+        },
+        {
+            name:     "RFC3339Nano UTC",
+            fields:   []Field{startField},
+            data:     start,
+            location: time.UTC,
+            want:     `{"start":"2024-11-07T19:30:00.5Z"}`,
+        },
+        {
+            name:     "Named location",
+            fields:   []Field{startField},
+            data:     start,
+            encoding: TimeEncodingRFC3339,
+            location: tokyo,
+            want:     `{"start":"2024-11-08T04:30:00+09:00"}`,
+        },
+        {
+            name:     "Unix",
+            fields:   []Field{startField},
+            data:     start,
+            encoding: TimeEncodingUnix,
+            want:     `{"start":1731007800}`,
+        },
+        {
+            name:     "Unix milliseconds",
+            fields:   []Field{startField},
+            data:     start,
+            encoding: TimeEncodingUnixMilli,
+            want:     `{"start":1731007800500}`,
+        },
+        {
+            name:     "Unix nanoseconds",
+            fields:   []Field{startField},
+            data:     start,
+            encoding: TimeEncodingUnixNano,
+            want:     `{"start":1731007800500000000}`,
+        },
+        {
+            name:     "Array",
+            fields:   []Field{timesField},
+            data:     []time.Time{start, start.Add(time.Second)},
+            encoding: TimeEncodingUnix,
+            want:     `{"times":[1731007800,1731007801]}`,
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            f, _ := NewFormatter(
+                OutputFormatJSON,
+                tt.fields,
+                WithTimeEncoding(tt.encoding),
+                WithTimeLocation(tt.location),
+            )
+
+            res := f.FormatLogLine(LogLineArgs{Level: Info}, tt.data)
+            if res.err != nil {
+                t.Fatalf("FormatLogLine() error = %v", res.err)
+            }
+
+            if string(res.bytes) != tt.want {
+                t.Errorf("FormatLogLine() = %s, want %s", res.bytes, tt.want)
+            }
+        })
+    }
+}
+
+func TestJSONFormatter_DurationEncoding(t *testing.T) {
+    elapsedField, _ := NewDurationField("elapsed")
+
+    tests := []struct {
+        name     string
+        encoding DurationEncoding
+        want     string
+    }{
+        {name: "Default", want: `{"elapsed":1500700000}`},
+        {name: "Milliseconds", encoding: DurationEncodingMilliseconds, want: `{"elapsed":1500}`},
+        {name: "Seconds", encoding: DurationEncodingSeconds, want: `{"elapsed":1.5007}`},
+        {name: "String", encoding: DurationEncodingString, want: `{"elapsed":"1.5007s"}`},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            f := &JSONFormatter{Fields: []Field{elapsedField}, DurationEncoding: tt.encoding}
+
+            res := f.FormatLogLine(LogLineArgs{Level: Info}, 1500700*time.Microsecond)
+            if res.err != nil {
+                t.Fatalf("FormatLogLine() error = %v", res.err)
+            }
+
+            if string(res.bytes) != tt.want {
+                t.Errorf("FormatLogLine() = %s, want %s", res.bytes, tt.want)
+            }
+        })
+    }
+}
+
+func TestJSONFormatter_DurationEncodingInMaps(t *testing.T) {
+    type timings struct {
+        Total  time.Duration            `ulog:"total"`
+        Phases map[string]time.Duration `ulog:"phases"`
+    }
+
+    mapField, _ := NewMapField[string, time.Duration]("m", func(args LogLineArgs, data string) any {
+        return data
+    }, func(args LogLineArgs, data time.Duration) any {
+        return data
+    })
+    structField, _ := NewStructField[timings]("timings")
+
+    tests := []struct {
+        name  string
+        field Field
+        data  any
+        want  string
+    }{
+        {
+            name:  "Map field",
+            field: mapField,
+            data:  map[string]time.Duration{"db": time.Second},
+            want:  `{"m":{"db":"1s"}}`,
+        },
+        {
+            name:  "Struct map member",
+            field: structField,
+            data:  timings{Total: time.Second, Phases: map[string]time.Duration{"db": time.Second}},
+            want:  `{"timings":{"total":"1s","phases":{"db":"1s"}}}`,
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            f := &JSONFormatter{Fields: []Field{tt.field}, DurationEncoding: DurationEncodingString}
+
+            res := f.FormatLogLine(LogLineArgs{Level: Info}, tt.data)
+            if res.err != nil {
+                t.Fatalf("FormatLogLine() error = %v", res.err)
+            }
+
+            if string(res.bytes) != tt.want {
+                t.Errorf("FormatLogLine() = %s, want %s", res.bytes, tt.want)
+            }
+        })
+    }
+}
+
+func TestJSONFormatter_entryEncoding(t *testing.T) {
+    entryField, _ := NewObjectField[any]("entry", func(args LogLineArgs, data any) any {
+        return data
+    })
+    receivedAt := time.Date(2024, 11, 7, 19, 30, 0, 0, time.UTC)
+
+    tests := []struct {
+        name string
+        data any
+        want string
+    }{
+        {
+            name: "Request",
+            data: RequestLogEntry{ReceivedAt: receivedAt, Method: http.MethodGet, Path: "/orders"},
+            want: `{"entry":{"received_at":1731007800,"method":"GET","path":"/orders"}}`,
+        },
+        {
+            name: "Request without time",
+            data: RequestLogEntry{Method: http.MethodGet, Query: map[string][]string{"id": {"1"}}},
+            want: `{"entry":{"method":"GET","query":{"id":["1"]}}}`,
+        },
+        {
+            name: "Response",
+            data: ResponseLogEntry{StatusCode: http.StatusOK, Duration: 1500 * time.Millisecond},
+            want: `{"entry":{"status_code":200,"duration":"1.5s"}}`,
+        },
+        {
+            name: "Transport",
+            data: TransportLogEntry{Method: http.MethodGet, URL: "https://example.com", Duration: time.Second},
+            want: `{"entry":{"method":"GET","url":"https://example.com","duration":"1s"}}`,
+        },
+        {
+            name: "Map",
+            data: map[string]any{"db": time.Second, "at": receivedAt, "phases": map[string]any{"io": time.Millisecond}},
+            want: `{"entry":{"at":1731007800,"db":"1s","phases":{"io":"1ms"}}}`,
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            f, _ := NewFormatter(
+                OutputFormatJSON,
+                []Field{entryField},
+                WithTimeEncoding(TimeEncodingUnix),
+                WithDurationEncoding(DurationEncodingString),
+            )
+
+            res := f.FormatLogLine(LogLineArgs{Level: Info}, tt.data)
+            if res.err != nil {
+                t.Fatalf("FormatLogLine() error = %v", res.err)
+            }
+
+            if string(res.bytes) != tt.want {
+                t.Errorf("FormatLogLine() = %s, want %s", res.bytes, tt.want)
+            }
+        })
+    }
+}
+
+func benchmarkJSONFields() []Field {
+    timeField, _ := NewCurrentTimeField("time", "")
+    durationField, _ := NewObjectField[time.Duration]("elapsed", func(args LogLineArgs, data time.Duration) any {
+        return data
+    })
+
+    return []Field{
+        timeField,
+        NewLevelField(Brackets.None),
+        NewTagField(Brackets.None, nil),
+        NewMessageField(),
+        durationField,
+    }
+}
+
+// formatJSONWithMap formats the log line the way the JSONFormatter did before it used the streaming encoder, as a
+// baseline for the benchmarks.
+func formatJSONWithMap(fields []Field, args LogLineArgs, data any) ([]byte, error) {
+    jsonMap := make(map[string]any, len(fields))
+
+    args.OutputFormat = OutputFormatJSON
+    for _, field := range fields {
+        fieldResult, err := computeFieldResult(field, args, data)
+        if err != nil {
+            return nil, err
+        }
+        if fieldResult == nil || fieldResult.Data == nil {
+            continue
+        }
+        jsonMap[fieldResult.Name] = fieldResult.Data
+    }
+
+    return json.Marshal(jsonMap)
+}
+
+func BenchmarkJSONFormatter_FormatLogLine(b *testing.B) {
+    formatter := &JSONFormatter{Fields: benchmarkJSONFields()}
+    args := LogLineArgs{Level: Info, Tag: "api"}
+
+    b.ReportAllocs()
+    for range b.N {
+        res := formatter.FormatLogLine(args, "request handled")
+        if res.err != nil {
+            b.Fatal(res.err)
+        }
+        if err := write(io.Discard, res.bytes, true); err != nil {
+            b.Fatal(err)
+        }
+    }
+}
+
+func BenchmarkJSONFormatter_encodingJSON(b *testing.B) {
+    fields := benchmarkJSONFields()
+    args := LogLineArgs{Level: Info, Tag: "api"}
+
+    b.ReportAllocs()
+    for range b.N {
+        line, err := formatJSONWithMap(fields, args, "request handled")
+        if err != nil {
+            b.Fatal(err)
+        }
+        if err := write(io.Discard, line, true); err != nil {
+            b.Fatal(err)
+        }
+    }
+}
@@ -0,0 +1,272 @@
+package ultralogger
+
+import (
+    "encoding/json"
+    "fmt"
+    "reflect"
+    "slices"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// otlpSeverityNumbers maps a Level to the OpenTelemetry SeverityNumber at the start of the matching range.
+//
+// See https://opentelemetry.io/docs/specs/otel/logs/data-model/#field-severitynumber for more information.
+var otlpSeverityNumbers = map[Level]int{
+    Debug: 5,
+    Info:  9,
+    Warn:  13,
+    Error: 17,
+    Panic: 21,
+}
+
+// OTLPFormatterSettings are the settings for an OTLPFormatter.
+type OTLPFormatterSettings struct {
+    // ResourceAttributes describe the entity producing the logs, e.g. {"service.name": "checkout"}. They are only
+    // written when Envelope is true, since a bare LogRecord has no resource.
+    ResourceAttributes map[string]any
+    // ScopeName is the name of the instrumentation scope. It is only written when Envelope is true.
+    ScopeName string
+    // ScopeVersion is the version of the instrumentation scope. It is only written when Envelope is true.
+    ScopeVersion string
+    // Envelope wraps every LogRecord in an ExportLogsServiceRequest ({"resourceLogs": [...]}), which is the payload
+    // accepted by the OTLP/HTTP receiver. If Envelope is false, each line is a bare LogRecord.
+    Envelope bool
+}
+
+// OTLPFormatter is a formatter that formats log lines as OTLP/JSON LogRecords, following the OpenTelemetry log data
+// model.
+//
+// The formatter writes the following LogRecord fields:
+//  - "timeUnixNano" and "observedTimeUnixNano" => the time the line was formatted.
+//  - "severityNumber" and "severityText" => the Level of the log line.
+//  - "body" => the result of the "message" field.
+//  - "attributes" => every other field result, encoded as AnyValues, followed by the code.* attributes of the Caller
+//    if the logger was created with [WithCaller].
+//  - "traceId", "spanId" and "flags" => the [SpanContext] of the context passed to [ContextLogger.LogContext], if any.
+//
+// See https://opentelemetry.io/docs/specs/otel/protocol/file-exporter/ and
+// https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding for more information.
+type OTLPFormatter struct {
+    Fields   []Field
+    Settings OTLPFormatterSettings
+
+    clock clock
+    plan  fieldPlanCache
+}
+
+// NewOTLPFormatter returns a new OTLPFormatter with the provided fields and settings.
+func NewOTLPFormatter(fields []Field, settings OTLPFormatterSettings) (*OTLPFormatter, error) {
+    f := &OTLPFormatter{
+        Fields:   fields,
+        Settings: settings,
+        clock:    &realClock{},
+    }
+    if _, err := f.plan.get(fields); err != nil {
+        return nil, err
+    }
+
+    return f, nil
+}
+
+type otlpKeyValue struct {
+    Key   string         `json:"key"`
+    Value map[string]any `json:"value"`
+}
+
+type otlpLogRecord struct {
+    TimeUnixNano         string         `json:"timeUnixNano"`
+    ObservedTimeUnixNano string         `json:"observedTimeUnixNano"`
+    SeverityNumber       int            `json:"severityNumber"`
+    SeverityText         string         `json:"severityText"`
+    Body                 map[string]any `json:"body,omitempty"`
+    Attributes           []otlpKeyValue `json:"attributes,omitempty"`
+    Flags                int            `json:"flags,omitempty"`
+    TraceID              string         `json:"traceId,omitempty"`
+    SpanID               string         `json:"spanId,omitempty"`
+}
+
+// FormatLogLine formats the log line using the provided data and returns a FormatResult which contains the formatted
+// log line and any errors that may have occurred.
+func (f *OTLPFormatter) FormatLogLine(args LogLineArgs, data any) FormatResult {
+    plan, err := f.plan.get(f.Fields)
+    if err != nil {
+        return FormatResult{nil, err}
+    }
+
+    c := f.clock
+    if c == nil {
+        c = &realClock{}
+    }
+
+    args.OutputFormat = OutputFormatJSON
+
+    now := strconv.FormatInt(c.Now().UnixNano(), 10)
+    record := &otlpLogRecord{
+        TimeUnixNano:         now,
+        ObservedTimeUnixNano: now,
+        SeverityNumber:       otlpSeverityNumbers[args.Level],
+        SeverityText:         args.Level.String(),
+    }
+
+    if sc, ok := SpanContextFromContext(args.Context); ok {
+        record.TraceID = sc.TraceID
+        record.SpanID = sc.SpanID
+        if sc.Sampled {
+            record.Flags = 1
+        }
+    }
+
+    for i, field := range plan.fields {
+        switch field.(type) {
+        case *levelField, *currentTimeField:
+            // These are already covered by the severity and the timestamps.
+            continue
+        }
+
+        fieldResult, err := plan.result(i, args, data)
+        if err != nil {
+            return FormatResult{nil, err}
+        }
+
+        if fieldResult == nil || fieldResult.Data == nil {
+            continue
+        }
+
+        if fieldResult.Name == "message" {
+            record.Body = otlpAnyValue(fieldResult.Data)
+            continue
+        }
+
+        record.Attributes = append(record.Attributes, otlpKeyValue{
+            Key:   fieldResult.Name,
+            Value: otlpAnyValue(fieldResult.Data),
+        })
+    }
+
+    if args.Caller != nil {
+        record.Attributes = append(record.Attributes,
+            otlpKeyValue{Key: "code.filepath", Value: otlpAnyValue(args.Caller.File)},
+            otlpKeyValue{Key: "code.lineno", Value: otlpAnyValue(args.Caller.Line)},
+            otlpKeyValue{Key: "code.function", Value: otlpAnyValue(args.Caller.Function)},
+        )
+    }
+
+    if !f.Settings.Envelope {
+        jBytes, err := json.Marshal(record)
+        return FormatResult{jBytes, err}
+    }
+
+    jBytes, err := json.Marshal(f.envelope(record))
+    return FormatResult{jBytes, err}
+}
+
+// envelope wraps the record in an ExportLogsServiceRequest.
+func (f *OTLPFormatter) envelope(record *otlpLogRecord) map[string]any {
+    resource := map[string]any{}
+    if len(f.Settings.ResourceAttributes) > 0 {
+        resource["attributes"] = otlpKeyValues(reflect.ValueOf(f.Settings.ResourceAttributes))
+    }
+
+    scope := map[string]any{}
+    if f.Settings.ScopeName != "" {
+        scope["name"] = f.Settings.ScopeName
+    }
+    if f.Settings.ScopeVersion != "" {
+        scope["version"] = f.Settings.ScopeVersion
+    }
+
+    return map[string]any{
+        "resourceLogs": []any{
+            map[string]any{
+                "resource": resource,
+                "scopeLogs": []any{
+                    map[string]any{
+                        "scope":      scope,
+                        "logRecords": []any{record},
+                    },
+                },
+            },
+        },
+    }
+}
+
+// otlpAnyValue converts the data into an OTLP/JSON AnyValue. Following the protobuf JSON mapping, 64 bit integers are
+// encoded as strings. Structs keep the order of their fields, and errors are converted into their [ErrorLogEntry]
+// fields. Values that have no direct AnyValue representation are converted through their JSON encoding.
+func otlpAnyValue(data any) map[string]any {
+    switch v := data.(type) {
+    case nil:
+        return map[string]any{}
+    case string:
+        return map[string]any{"stringValue": v}
+    case bool:
+        return map[string]any{"boolValue": v}
+    case []byte:
+        return map[string]any{"bytesValue": v}
+    case time.Time:
+        return map[string]any{"stringValue": v.Format(time.RFC3339Nano)}
+    case time.Duration:
+        return map[string]any{"intValue": strconv.FormatInt(int64(v), 10)}
+    case structObject:
+        keyValues := make([]otlpKeyValue, len(v))
+        for i, result := range v {
+            keyValues[i] = otlpKeyValue{Key: result.Name, Value: otlpAnyValue(result.Data)}
+        }
+        return map[string]any{"kvlistValue": map[string]any{"values": keyValues}}
+    case ErrorLogEntry:
+        return otlpAnyValue(jsonGeneric(v))
+    case error:
+        return map[string]any{"stringValue": v.Error()}
+    case fmt.Stringer:
+        return map[string]any{"stringValue": v.String()}
+    case json.Marshaler:
+        return otlpAnyValue(jsonGeneric(v))
+    }
+
+    rv := reflect.ValueOf(data)
+    switch rv.Kind() {
+    case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+        return map[string]any{"intValue": strconv.FormatInt(rv.Int(), 10)}
+    case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+        return map[string]any{"intValue": strconv.FormatUint(rv.Uint(), 10)}
+    case reflect.Float32, reflect.Float64:
+        return map[string]any{"doubleValue": rv.Float()}
+    case reflect.Slice, reflect.Array:
+        values := make([]map[string]any, rv.Len())
+        for i := range values {
+            values[i] = otlpAnyValue(rv.Index(i).Interface())
+        }
+        return map[string]any{"arrayValue": map[string]any{"values": values}}
+    case reflect.Map:
+        return map[string]any{"kvlistValue": map[string]any{"values": otlpKeyValues(rv)}}
+    case reflect.Pointer, reflect.Interface:
+        if rv.IsNil() {
+            return map[string]any{}
+        }
+        return otlpAnyValue(rv.Elem().Interface())
+    }
+
+    return otlpAnyValue(jsonGeneric(data))
+}
+
+// otlpKeyValues converts a map into a list of OTLP/JSON KeyValues sorted by key. Non-string keys are formatted with
+// %v.
+func otlpKeyValues(rv reflect.Value) []otlpKeyValue {
+    keyValues := make([]otlpKeyValue, 0, rv.Len())
+
+    iter := rv.MapRange()
+    for iter.Next() {
+        keyValues = append(keyValues, otlpKeyValue{
+            Key:   fmt.Sprintf("%v", iter.Key().Interface()),
+            Value: otlpAnyValue(iter.Value().Interface()),
+        })
+    }
+
+    slices.SortFunc(keyValues, func(a, b otlpKeyValue) int {
+        return strings.Compare(a.Key, b.Key)
+    })
+
+    return keyValues
+}
@@ -0,0 +1,207 @@
+package ultralogger
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "os"
+    "reflect"
+    "testing"
+    "time"
+)
+
+func ExampleNewOTLPFormatter() {
+    formatter, _ := NewOTLPFormatter([]Field{
+        NewLevelField(Brackets.None),
+        NewTagField(Brackets.None, nil),
+        NewMessageField(),
+    }, OTLPFormatterSettings{})
+    formatter.clock = mockClock{}
+
+    logger, _ := NewLoggerWithOptions(WithDestination(os.Stdout, formatter), WithTag("api"), WithAsync(false))
+
+    logger.Info("This is an info message.")
+    // Output: {"timeUnixNano":"1731007800000000000","observedTimeUnixNano":"1731007800000000000","severityNumber":9,"severityText":"INFO","body":{"stringValue":"This is an info message."},"attributes":[{"key":"tag","value":{"stringValue":"api"}}]}
+}
+
+func ExampleNewOTLPFormatter_envelope() {
+    formatter, _ := NewOTLPFormatter([]Field{NewMessageField()}, OTLPFormatterSettings{
+        ResourceAttributes: map[string]any{"service.name": "checkout"},
+        ScopeName:          "ultralogger",
+        Envelope:           true,
+    })
+    formatter.clock = mockClock{}
+
+    logger, _ := NewLoggerWithOptions(WithDestination(os.Stdout, formatter), WithAsync(false))
+
+    logger.Error("This is an error message.")
+    // Output: {"resourceLogs":[{"resource":{"attributes":[{"key":"service.name","value":{"stringValue":"checkout"}}]},"scopeLogs":[{"logRecords":[{"timeUnixNano":"1731007800000000000","observedTimeUnixNano":"1731007800000000000","severityNumber":17,"severityText":"ERROR","body":{"stringValue":"This is an error message."}}],"scope":{"name":"ultralogger"}}]}]}
+}
+
+func TestOTLPFormatter_FormatLogLine(t *testing.T) {
+    type order struct {
+        ID    string `json:"id"`
+        Total int    `json:"total"`
+    }
+    type address struct {
+        City string `ulog:"city"`
+    }
+    type customer struct {
+        Name    string  `ulog:"name"`
+        Address address `ulog:"address"`
+    }
+
+    intField, _ := NewIntField("count")
+    floatField, _ := NewFloatField("ratio")
+    durationField, _ := NewDurationField("elapsed")
+    errField, _ := NewErrorField("error")
+    arrayField, _ := NewArrayField[int]("ids", func(args LogLineArgs, data int) any {
+        return data
+    })
+    mapField, _ := NewMapField[string, bool]("flags", func(args LogLineArgs, data string) any {
+        return data
+    }, func(args LogLineArgs, data bool) any {
+        return data
+    })
+    orderField, _ := NewObjectField[order]("order", func(args LogLineArgs, data order) any {
+        return data
+    })
+    customerField, _ := NewStructField[customer]("customer")
+
+    tests := []struct {
+        name           string
+        fields         []Field
+        args           LogLineArgs
+        data           any
+        wantAttributes any
+        wantTrace      map[string]any
+    }{
+        {
+            name:           "Int",
+            fields:         []Field{intField},
+            data:           42,
+            wantAttributes: []any{map[string]any{"key": "count", "value": map[string]any{"intValue": "42"}}},
+        },
+        {
+            name:           "Float",
+            fields:         []Field{floatField},
+            data:           0.5,
+            wantAttributes: []any{map[string]any{"key": "ratio", "value": map[string]any{"doubleValue": 0.5}}},
+        },
+        {
+            name:           "Duration",
+            fields:         []Field{durationField},
+            data:           time.Second,
+            wantAttributes: []any{map[string]any{"key": "elapsed", "value": map[string]any{"intValue": "1000000000"}}},
+        },
+        {
+            name:   "Error",
+            fields: []Field{errField},
+            data:   errors.New("boom"),
+            wantAttributes: []any{map[string]any{"key": "error", "value": map[string]any{"kvlistValue": map[string]any{
+                "values": []any{
+                    map[string]any{"key": "message", "value": map[string]any{"stringValue": "boom"}},
+                    map[string]any{"key": "type", "value": map[string]any{"stringValue": "*errors.errorString"}},
+                },
+            }}}},
+        },
+        {
+            name:   "Array",
+            fields: []Field{arrayField},
+            data:   []int{1, 2},
+            wantAttributes: []any{map[string]any{"key": "ids", "value": map[string]any{"arrayValue": map[string]any{
+                "values": []any{map[string]any{"intValue": "1"}, map[string]any{"intValue": "2"}},
+            }}}},
+        },
+        {
+            name:   "Map",
+            fields: []Field{mapField},
+            data:   map[string]bool{"b": false, "a": true},
+            wantAttributes: []any{map[string]any{"key": "flags", "value": map[string]any{"kvlistValue": map[string]any{
+                "values": []any{
+                    map[string]any{"key": "a", "value": map[string]any{"boolValue": true}},
+                    map[string]any{"key": "b", "value": map[string]any{"boolValue": false}},
+                },
+            }}}},
+        },
+        {
+            name:   "Struct",
+            fields: []Field{orderField},
+            data:   order{ID: "o-1", Total: 3},
+            wantAttributes: []any{map[string]any{"key": "order", "value": map[string]any{"kvlistValue": map[string]any{
+                "values": []any{
+                    map[string]any{"key": "id", "value": map[string]any{"stringValue": "o-1"}},
+                    map[string]any{"key": "total", "value": map[string]any{"intValue": "3"}},
+                },
+            }}}},
+        },
+        {
+            name:   "Nested struct",
+            fields: []Field{customerField},
+            data:   customer{Name: "Jane", Address: address{City: "Paris"}},
+            wantAttributes: []any{map[string]any{"key": "customer", "value": map[string]any{
+                "kvlistValue": map[string]any{"values": []any{
+                    map[string]any{"key": "name", "value": map[string]any{"stringValue": "Jane"}},
+                    map[string]any{"key": "address", "value": map[string]any{"kvlistValue": map[string]any{
+                        "values": []any{
+                            map[string]any{"key": "city", "value": map[string]any{"stringValue": "Paris"}},
+                        },
+                    }}},
+                }},
+            }}},
+        },
+        {
+            name:   "Caller",
+            fields: []Field{},
+            args:   LogLineArgs{Caller: &Caller{Function: "main.main", File: "/app/main.go", Line: 7}},
+            wantAttributes: []any{
+                map[string]any{"key": "code.filepath", "value": map[string]any{"stringValue": "/app/main.go"}},
+                map[string]any{"key": "code.lineno", "value": map[string]any{"intValue": "7"}},
+                map[string]any{"key": "code.function", "value": map[string]any{"stringValue": "main.main"}},
+            },
+        },
+        {
+            name:   "Trace",
+            fields: []Field{},
+            args: LogLineArgs{Context: ContextWithSpanContext(context.Background(), SpanContext{
+                TraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+                SpanID:  "00f067aa0ba902b7",
+                Sampled: true,
+            })},
+            wantTrace: map[string]any{
+                "traceId": "4bf92f3577b34da6a3ce929d0e0e4736",
+                "spanId":  "00f067aa0ba902b7",
+                "flags":   1.0,
+            },
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            f, err := NewOTLPFormatter(tt.fields, OTLPFormatterSettings{})
+            if err != nil {
+                t.Fatalf("NewOTLPFormatter() error = %v", err)
+            }
+            f.clock = mockClock{}
+
+            res := f.FormatLogLine(tt.args, tt.data)
+            if res.err != nil {
+                t.Fatalf("FormatLogLine() error = %v", res.err)
+            }
+
+            got := map[string]any{}
+            if err := json.Unmarshal(res.bytes, &got); err != nil {
+                t.Fatalf("FormatLogLine() returned invalid JSON: %v", err)
+            }
+
+            if !reflect.DeepEqual(got["attributes"], tt.wantAttributes) {
+                t.Errorf("attributes = %v, want %v", got["attributes"], tt.wantAttributes)
+            }
+
+            for k, v := range tt.wantTrace {
+                if !reflect.DeepEqual(got[k], v) {
+                    t.Errorf("%s = %v, want %v", k, got[k], v)
+                }
+            }
+        })
+    }
+}
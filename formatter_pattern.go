@@ -0,0 +1,307 @@
+package ultralogger
+
+import (
+    "errors"
+    "fmt"
+    "strings"
+    "unicode/utf8"
+)
+
+// PatternFormatter is a text formatter that renders log lines from a layout pattern, e.g.
+// "%time %-5level [%tag] %msg (%caller)".
+//
+// A placeholder starts with a '%', followed by optional modifiers and the name of the placeholder. Everything else in
+// the pattern is literal text, and "%%" renders a literal '%'. The name is either a run of letters, digits and
+// underscores, or any text wrapped in curly braces, e.g. "%{user.id}".
+//
+// Modifiers:
+//  - '-' => left align the value (pad on the right). Values are right aligned by default.
+//  - width => the minimum width of the value, in runes. Shorter values are padded with spaces.
+//  - '.' max => the maximum width of the value, in runes. Longer values are truncated at the end.
+//
+// For example, "%-5level" renders "INFO " and "%.3level" renders "DEB" for a Debug line.
+//
+// Placeholders refer to the name of a field result. The following placeholders are built in, and are used unless a
+// provided field has the same name:
+//  - %time => the current time. The layout can be set in braces, e.g. "%time{15:04:05}". Default: "2006-01-02 15:04:05".
+//  - %level => the Level of the log line.
+//  - %tag => the Tag of the log line.
+//  - %msg, %message => the message, formatted by [NewMessageField].
+//  - %caller => the Caller of the log line as "file.go:line", if the logger was created with [WithCaller].
+//
+// The pattern is compiled once, when the formatter is created. Unknown placeholders are reported as an
+// ErrorInvalidPattern.
+type PatternFormatter struct {
+    segments []patternSegment
+    clock    clock
+}
+
+// patternValue renders the value of a placeholder for a log line.
+type patternValue func(f *PatternFormatter, args LogLineArgs, data any) (string, error)
+
+type patternSegment struct {
+    literal   string
+    value     patternValue
+    leftAlign bool
+    minWidth  int
+    maxWidth  int
+    // deferred reports whether the value is rendered after the other values, e.g. the count of a redaction count field.
+    deferred bool
+}
+
+// NewPatternFormatter compiles the pattern and returns a new PatternFormatter. The fields are the fields that can be
+// referred to by name in the pattern, in addition to the built-in placeholders.
+func NewPatternFormatter(pattern string, fields []Field) (*PatternFormatter, error) {
+    fieldsByName := map[string]Field{}
+    for _, field := range fields {
+        name, err := fieldName(field)
+        if err != nil {
+            return nil, err
+        }
+        fieldsByName[name] = field
+    }
+
+    segments, err := compilePattern(pattern, fieldsByName)
+    if err != nil {
+        return nil, err
+    }
+
+    return &PatternFormatter{
+        segments: segments,
+        clock:    &realClock{},
+    }, nil
+}
+
+// FormatLogLine formats the log line using the provided data and returns a FormatResult which contains the formatted
+// log line and any errors that may have occurred.
+func (f *PatternFormatter) FormatLogLine(args LogLineArgs, data any) FormatResult {
+    args.OutputFormat = OutputFormatText
+
+    // Redaction counts are rendered after the other placeholders, so that they count the redactions of every field.
+    values := make([]string, len(f.segments))
+    for _, deferred := range []bool{false, true} {
+        for i, segment := range f.segments {
+            if segment.value == nil || segment.deferred != deferred {
+                continue
+            }
+
+            value, err := segment.value(f, args, data)
+            if err != nil {
+                return FormatResult{nil, err}
+            }
+            values[i] = segment.align(value)
+        }
+    }
+
+    b := strings.Builder{}
+    for i, segment := range f.segments {
+        if segment.value == nil {
+            b.WriteString(segment.literal)
+            continue
+        }
+        b.WriteString(values[i])
+    }
+
+    return FormatResult{[]byte(b.String()), nil}
+}
+
+// align truncates and pads the value according to the modifiers of the segment.
+func (s patternSegment) align(value string) string {
+    length := utf8.RuneCountInString(value)
+
+    if s.maxWidth > 0 && length > s.maxWidth {
+        runes := []rune(value)
+        value = string(runes[:s.maxWidth])
+        length = s.maxWidth
+    }
+
+    if length >= s.minWidth {
+        return value
+    }
+
+    padding := strings.Repeat(" ", s.minWidth-length)
+    if s.leftAlign {
+        return value + padding
+    }
+    return padding + value
+}
+
+func compilePattern(pattern string, fieldsByName map[string]Field) ([]patternSegment, error) {
+    var segments []patternSegment
+    literal := strings.Builder{}
+
+    flushLiteral := func() {
+        if literal.Len() > 0 {
+            segments = append(segments, patternSegment{literal: literal.String()})
+            literal.Reset()
+        }
+    }
+
+    for i := 0; i < len(pattern); i++ {
+        if pattern[i] != '%' {
+            literal.WriteByte(pattern[i])
+            continue
+        }
+
+        i++
+        if i >= len(pattern) {
+            return nil, &ErrorInvalidPattern{pattern: pattern, reason: "pattern ends with '%'"}
+        }
+        if pattern[i] == '%' {
+            literal.WriteByte('%')
+            continue
+        }
+
+        segment := patternSegment{}
+
+        if pattern[i] == '-' {
+            segment.leftAlign = true
+            i++
+        }
+        segment.minWidth, i = parsePatternNumber(pattern, i)
+        if i < len(pattern) && pattern[i] == '.' {
+            segment.maxWidth, i = parsePatternNumber(pattern, i+1)
+            if segment.maxWidth == 0 {
+                return nil, &ErrorInvalidPattern{pattern: pattern, reason: "missing max width after '.'"}
+            }
+        }
+
+        name, option, next, err := parsePatternName(pattern, i)
+        if err != nil {
+            return nil, err
+        }
+        i = next - 1
+
+        segment.value, err = patternValueFor(name, option, fieldsByName)
+        if err != nil {
+            var initErr *ErrorFieldFormatterInit
+            if errors.As(err, &initErr) {
+                return nil, err
+            }
+            return nil, &ErrorInvalidPattern{pattern: pattern, reason: err.Error()}
+        }
+
+        _, segment.deferred = fieldsByName[name].(*redactionCountField)
+
+        flushLiteral()
+        segments = append(segments, segment)
+    }
+
+    flushLiteral()
+    return segments, nil
+}
+
+// parsePatternNumber parses the decimal number starting at i, and returns the number and the index after it. If there
+// is no number at i, 0 and i are returned.
+func parsePatternNumber(pattern string, i int) (int, int) {
+    n := 0
+    for ; i < len(pattern) && pattern[i] >= '0' && pattern[i] <= '9'; i++ {
+        n = n*10 + int(pattern[i]-'0')
+    }
+    return n, i
+}
+
+// parsePatternName parses the placeholder name starting at i, followed by an optional option in curly braces, and
+// returns the name, the option and the index after them.
+func parsePatternName(pattern string, i int) (string, string, int, error) {
+    var name string
+
+    if i < len(pattern) && pattern[i] == '{' {
+        end := strings.IndexByte(pattern[i:], '}')
+        if end < 0 {
+            return "", "", 0, &ErrorInvalidPattern{pattern: pattern, reason: "unterminated '{'"}
+        }
+        name = pattern[i+1 : i+end]
+        i += end + 1
+    } else {
+        start := i
+        for ; i < len(pattern) && isPatternNameByte(pattern[i]); i++ {
+        }
+        name = pattern[start:i]
+    }
+
+    if name == "" {
+        return "", "", 0, &ErrorInvalidPattern{pattern: pattern, reason: "missing placeholder name"}
+    }
+
+    var option string
+    if i < len(pattern) && pattern[i] == '{' {
+        end := strings.IndexByte(pattern[i:], '}')
+        if end < 0 {
+            return "", "", 0, &ErrorInvalidPattern{pattern: pattern, reason: "unterminated '{'"}
+        }
+        option = pattern[i+1 : i+end]
+        i += end + 1
+    }
+
+    return name, option, i, nil
+}
+
+func isPatternNameByte(c byte) bool {
+    return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_'
+}
+
+// patternValueFor resolves the placeholder name to a patternValue. Provided fields take precedence over the built-in
+// placeholders.
+func patternValueFor(name, option string, fieldsByName map[string]Field) (patternValue, error) {
+    if field, ok := fieldsByName[name]; ok {
+        return patternFieldValue(field)
+    }
+
+    switch name {
+    case "time":
+        layout := option
+        if layout == "" {
+            layout = defaultDateTimeFormat
+        }
+        return func(f *PatternFormatter, _ LogLineArgs, _ any) (string, error) {
+            return f.clock.Now().Format(layout), nil
+        }, nil
+    case "level":
+        return func(_ *PatternFormatter, args LogLineArgs, _ any) (string, error) {
+            return args.Level.String(), nil
+        }, nil
+    case "tag":
+        return func(_ *PatternFormatter, args LogLineArgs, _ any) (string, error) {
+            return args.Tag, nil
+        }, nil
+    case "msg", "message":
+        if field, ok := fieldsByName["message"]; ok {
+            return patternFieldValue(field)
+        }
+        return patternFieldValue(NewMessageField())
+    case "caller":
+        return func(_ *PatternFormatter, args LogLineArgs, _ any) (string, error) {
+            if args.Caller == nil {
+                return "", nil
+            }
+            return args.Caller.String(), nil
+        }, nil
+    }
+
+    return nil, fmt.Errorf("unknown placeholder %q", name)
+}
+
+// patternFieldValue renders the text result of the field. Fields that have no result for the data render as an empty
+// string.
+func patternFieldValue(field Field) (patternValue, error) {
+    plan, err := compileFieldPlan([]Field{field})
+    if err != nil {
+        return nil, err
+    }
+
+    return func(_ *PatternFormatter, args LogLineArgs, data any) (string, error) {
+        fieldResult, err := plan.result(0, args, data)
+        if err != nil {
+            return "", err
+        }
+
+        if fieldResult == nil {
+            return "", nil
+        }
+        if fieldResult.Data == nil {
+            return "<nil>", nil
+        }
+        return fmt.Sprintf("%v", fieldResult.Data), nil
+    }, nil
+}
@@ -0,0 +1,163 @@
+package ultralogger
+
+import (
+    "errors"
+    "os"
+    "testing"
+)
+
+func ExampleNewPatternFormatter() {
+    formatter, _ := NewPatternFormatter("%-5level [%tag] %msg", nil)
+
+    logger, _ := NewLoggerWithOptions(WithDestination(os.Stdout, formatter), WithTag("api"), WithAsync(false))
+
+    logger.Info("This is an info message.")
+    logger.Error("This is an error message.")
+    // Output:
+    // INFO  [api] This is an info message.
+    // ERROR [api] This is an error message.
+}
+
+func TestPatternFormatter_FormatLogLine(t *testing.T) {
+    userField, _ := NewStringField("user")
+    levelOverride, _ := NewObjectField[string]("level", func(args LogLineArgs, data string) any {
+        return "custom"
+    })
+
+    tests := []struct {
+        name    string
+        pattern string
+        fields  []Field
+        args    LogLineArgs
+        data    any
+        want    string
+    }{
+        {
+            name:    "Literal only",
+            pattern: "hello world",
+            want:    "hello world",
+        },
+        {
+            name:    "Escaped percent",
+            pattern: "100%% %msg",
+            data:    "done",
+            want:    "100% done",
+        },
+        {
+            name:    "Right align",
+            pattern: "%6level|",
+            args:    LogLineArgs{Level: Warn},
+            want:    "  WARN|",
+        },
+        {
+            name:    "Left align",
+            pattern: "%-6level|",
+            args:    LogLineArgs{Level: Warn},
+            want:    "WARN  |",
+        },
+        {
+            name:    "Truncate",
+            pattern: "%.3level",
+            args:    LogLineArgs{Level: Debug},
+            want:    "DEB",
+        },
+        {
+            name:    "Truncate and pad",
+            pattern: "%-4.2message|",
+            data:    "héllo",
+            want:    "hé  |",
+        },
+        {
+            name:    "Time layout",
+            pattern: "%time{15:04}",
+            want:    "19:30",
+        },
+        {
+            name:    "Default time layout",
+            pattern: "%time",
+            want:    "2024-11-07 19:30:00",
+        },
+        {
+            name:    "Caller",
+            pattern: "%caller",
+            args:    LogLineArgs{Caller: &Caller{File: "/app/cmd/main.go", Line: 12}},
+            want:    "main.go:12",
+        },
+        {
+            name:    "Missing caller",
+            pattern: "(%caller)",
+            want:    "()",
+        },
+        {
+            name:    "Custom field",
+            pattern: "user=%user",
+            fields:  []Field{userField},
+            data:    "john",
+            want:    "user=john",
+        },
+        {
+            name:    "Braced name",
+            pattern: "%{user}name",
+            fields:  []Field{userField},
+            data:    "john",
+            want:    "johnname",
+        },
+        {
+            name:    "Field without result",
+            pattern: "[%user]",
+            fields:  []Field{userField},
+            data:    42,
+            want:    "[]",
+        },
+        {
+            name:    "Field overrides built-in",
+            pattern: "%level",
+            fields:  []Field{levelOverride},
+            args:    LogLineArgs{Level: Info},
+            data:    "x",
+            want:    "custom",
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            f, err := NewPatternFormatter(tt.pattern, tt.fields)
+            if err != nil {
+                t.Fatalf("NewPatternFormatter() error = %v", err)
+            }
+            f.clock = mockClock{}
+
+            res := f.FormatLogLine(tt.args, tt.data)
+            if res.err != nil {
+                t.Fatalf("FormatLogLine() error = %v", res.err)
+            }
+
+            if string(res.bytes) != tt.want {
+                t.Errorf("FormatLogLine() = %q, want %q", res.bytes, tt.want)
+            }
+        })
+    }
+}
+
+func TestNewPatternFormatter_Invalid(t *testing.T) {
+    tests := []struct {
+        name    string
+        pattern string
+    }{
+        {"Unknown placeholder", "%unknown"},
+        {"Trailing percent", "abc %"},
+        {"Missing name", "%-5 abc"},
+        {"Missing max width", "%.level"},
+        {"Unterminated brace", "%{level"},
+        {"Unterminated option", "%time{15:04"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            _, err := NewPatternFormatter(tt.pattern, nil)
+
+            var invalidPattern *ErrorInvalidPattern
+            if !errors.As(err, &invalidPattern) {
+                t.Errorf("NewPatternFormatter() error = %v, want ErrorInvalidPattern", err)
+            }
+        })
+    }
+}
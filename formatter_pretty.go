@@ -0,0 +1,260 @@
+package ultralogger
+
+import (
+    "fmt"
+    "maps"
+    "reflect"
+    "slices"
+    "strings"
+    "time"
+    "unicode/utf8"
+)
+
+const (
+    defaultPrettyTimeFormat = "15:04:05.000"
+    prettyLevelWidth        = 5
+    prettyIndent            = "    "
+)
+
+// PrettyFormatterSettings are the settings for a PrettyFormatter.
+type PrettyFormatterSettings struct {
+    // TimeFormat is the layout of the time column. If it is empty, "15:04:05.000" is used.
+    TimeFormat string
+    // TagWidth is the minimum width of the tag column, including the brackets. Lines without a tag are padded too, so
+    // that the message column stays aligned.
+    TagWidth int
+    // LevelColors are the colors of the level token. If it is nil, the default level colors are used.
+    LevelColors map[Level]Color
+    // DisableColors disables colorization, e.g. when the output is not a terminal.
+    DisableColors bool
+}
+
+// PrettyFormatter is a human-friendly formatter for local development.
+//
+// Each line starts with aligned time, level and tag columns, followed by the message and the remaining field results
+// as dimmed key=value pairs. Only the level token is colorized. Nested values (maps, slices and structs) and
+// multi-line strings, such as stack traces, are rendered below the line, indented:
+//
+//  19:30:00.000 ERROR [api] request failed status=500
+//      error:
+//          connection reset
+//          main.go:42
+//      headers:
+//          Accept: */*
+//
+// The formatter renders the time, level and tag itself, so results of the fields created by [NewCurrentTimeField],
+// [NewLevelField] and [NewTagField] are ignored.
+type PrettyFormatter struct {
+    Fields   []Field
+    Settings PrettyFormatterSettings
+
+    clock clock
+    plan  fieldPlanCache
+}
+
+// NewPrettyFormatter returns a new PrettyFormatter with the provided fields and settings.
+func NewPrettyFormatter(fields []Field, settings PrettyFormatterSettings) (*PrettyFormatter, error) {
+    if settings.TimeFormat == "" {
+        settings.TimeFormat = defaultPrettyTimeFormat
+    }
+    if settings.LevelColors == nil {
+        settings.LevelColors = defaultLevelColors
+    }
+
+    f := &PrettyFormatter{
+        Fields:   fields,
+        Settings: settings,
+        clock:    &realClock{},
+    }
+    if _, err := f.plan.get(fields); err != nil {
+        return nil, err
+    }
+
+    return f, nil
+}
+
+var prettyDim = Colors.Default.Dim()
+
+// FormatLogLine formats the log line using the provided data and returns a FormatResult which contains the formatted
+// log line and any errors that may have occurred.
+func (f *PrettyFormatter) FormatLogLine(args LogLineArgs, data any) FormatResult {
+    plan, err := f.plan.get(f.Fields)
+    if err != nil {
+        return FormatResult{nil, err}
+    }
+
+    c := f.clock
+    if c == nil {
+        c = &realClock{}
+    }
+
+    args.OutputFormat = OutputFormatJSON
+
+    b := &strings.Builder{}
+
+    b.WriteString(c.Now().Format(f.Settings.TimeFormat))
+    b.WriteByte(' ')
+
+    level := args.Level.String()
+    b.WriteString(f.colorize(f.Settings.LevelColors[args.Level], level))
+    b.WriteString(strings.Repeat(" ", max(prettyLevelWidth-len(level), 0)))
+
+    tag := ""
+    if args.Tag != "" {
+        tag = Brackets.Square.Wrap(args.Tag)
+    }
+    if tag != "" || f.Settings.TagWidth > 0 {
+        b.WriteByte(' ')
+        b.WriteString(tag)
+        b.WriteString(strings.Repeat(" ", max(f.Settings.TagWidth-utf8.RuneCountInString(tag), 0)))
+    }
+
+    var nested []FieldResult
+
+    for i, field := range plan.fields {
+        switch field.(type) {
+        case *levelField, *tagField, *currentTimeField:
+            continue
+        }
+
+        fieldResult, err := plan.result(i, args, data)
+        if err != nil {
+            return FormatResult{nil, err}
+        }
+
+        if fieldResult == nil || fieldResult.Data == nil {
+            continue
+        }
+
+        if fieldResult.Name == "message" {
+            b.WriteByte(' ')
+            b.WriteString(fmt.Sprintf("%v", fieldResult.Data))
+            continue
+        }
+
+        value, ok := prettyInline(fieldResult.Data)
+        if !ok {
+            nested = append(nested, *fieldResult)
+            continue
+        }
+
+        b.WriteByte(' ')
+        b.WriteString(f.colorize(prettyDim, fieldResult.Name+"="+value))
+    }
+
+    // The padding of the columns is only needed when something follows it.
+    line := &strings.Builder{}
+    line.WriteString(strings.TrimRight(b.String(), " "))
+
+    for _, fieldResult := range nested {
+        line.WriteByte('\n')
+        line.WriteString(prettyIndent)
+        line.WriteString(f.colorize(prettyDim, fieldResult.Name+":"))
+        prettyWriteNested(line, prettyNormalize(fieldResult.Data), prettyIndent+prettyIndent)
+    }
+
+    return FormatResult{[]byte(line.String()), nil}
+}
+
+func (f *PrettyFormatter) colorize(color Color, s string) string {
+    if f.Settings.DisableColors || color == nil {
+        return s
+    }
+    return string(color.Colorize([]byte(s)))
+}
+
+// prettyInline returns the inline representation of scalar values. Nested values, multi-line strings and errors with
+// a stack trace are not rendered inline.
+func prettyInline(data any) (string, bool) {
+    switch v := data.(type) {
+    case time.Time:
+        return v.Format(time.RFC3339Nano), true
+    case ErrorLogEntry:
+        if ecsStackTrace(v.err) != "" {
+            return "", false
+        }
+        return prettyInline(v.Message)
+    case error:
+        if ecsStackTrace(v) != "" {
+            return "", false
+        }
+        return prettyInline(v.Error())
+    case fmt.Stringer:
+        return prettyInline(v.String())
+    case string:
+        if strings.Contains(v, "\n") {
+            return "", false
+        }
+        return v, true
+    }
+
+    switch reflect.ValueOf(data).Kind() {
+    case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct, reflect.Pointer, reflect.Interface:
+        return "", false
+    }
+
+    return fmt.Sprintf("%v", data), true
+}
+
+// prettyNormalize converts the data into strings, maps and slices, so that it can be rendered by prettyWriteNested.
+// Errors are converted into their stack trace, if they render one, so that every frame is written on its own line.
+func prettyNormalize(data any) any {
+    switch v := data.(type) {
+    case ErrorLogEntry:
+        if stackTrace := ecsStackTrace(v.err); stackTrace != "" {
+            return stackTrace
+        }
+        return v.Message
+    case error:
+        if stackTrace := ecsStackTrace(v); stackTrace != "" {
+            return stackTrace
+        }
+        return v.Error()
+    case fmt.Stringer:
+        return v.String()
+    case string:
+        return v
+    }
+    return jsonGeneric(data)
+}
+
+// prettyWriteNested writes the value on new lines, with every line prefixed by the indent.
+func prettyWriteNested(b *strings.Builder, value any, indent string) {
+    switch v := value.(type) {
+    case map[string]any:
+        for _, key := range slices.Sorted(maps.Keys(v)) {
+            b.WriteByte('\n')
+            b.WriteString(indent)
+            b.WriteString(key)
+            b.WriteByte(':')
+            if inline, ok := prettyInline(v[key]); ok {
+                b.WriteByte(' ')
+                b.WriteString(inline)
+                continue
+            }
+            prettyWriteNested(b, v[key], indent+prettyIndent)
+        }
+    case []any:
+        for _, item := range v {
+            b.WriteByte('\n')
+            b.WriteString(indent)
+            b.WriteString("-")
+            if inline, ok := prettyInline(item); ok {
+                b.WriteByte(' ')
+                b.WriteString(inline)
+                continue
+            }
+            prettyWriteNested(b, item, indent+prettyIndent)
+        }
+    case string:
+        for _, line := range strings.Split(v, "\n") {
+            b.WriteByte('\n')
+            b.WriteString(indent)
+            b.WriteString(line)
+        }
+    default:
+        b.WriteByte('\n')
+        b.WriteString(indent)
+        b.WriteString(fmt.Sprintf("%v", v))
+    }
+}
@@ -0,0 +1,162 @@
+package ultralogger
+
+import (
+    "errors"
+    "os"
+    "strings"
+    "testing"
+)
+
+func ExampleNewPrettyFormatter() {
+    statusField, _ := NewObjectField[int]("status", func(args LogLineArgs, data int) any {
+        return data
+    })
+
+    formatter, _ := NewPrettyFormatter(
+        []Field{NewLevelField(Brackets.Angle), NewMessageField(), statusField},
+        PrettyFormatterSettings{TagWidth: 8, DisableColors: true},
+    )
+    formatter.clock = mockClock{}
+
+    logger, _ := NewLoggerWithOptions(WithDestination(os.Stdout, formatter), WithTag("api"), WithAsync(false))
+
+    logger.Info("This is an info message.")
+    logger.Warn(404)
+    // Output:
+    // 19:30:00.000 INFO  [api]    This is an info message.
+    // 19:30:00.000 WARN  [api]    status=404
+}
+
+func TestPrettyFormatter_FormatLogLine(t *testing.T) {
+    type address struct {
+        City string `json:"city"`
+        Zip  string `json:"zip"`
+    }
+
+    errField, _ := NewErrorField("error")
+    mapField, _ := NewMapField[string, string]("headers", func(args LogLineArgs, data string) any {
+        return data
+    }, func(args LogLineArgs, data string) any {
+        return data
+    })
+    addressesField, _ := NewArrayField[address]("addresses", func(args LogLineArgs, data address) any {
+        return data
+    })
+    idsField, _ := NewArrayField[int]("ids", func(args LogLineArgs, data int) any {
+        return data
+    })
+
+    tests := []struct {
+        name     string
+        fields   []Field
+        settings PrettyFormatterSettings
+        args     LogLineArgs
+        data     any
+        want     string
+    }{
+        {
+            name:     "Colors only the level",
+            fields:   []Field{NewMessageField()},
+            settings: PrettyFormatterSettings{},
+            args:     LogLineArgs{Level: Error},
+            data:     "boom",
+            want:     "19:30:00.000 " + string(Colors.Red.Colorize([]byte("ERROR"))) + " boom",
+        },
+        {
+            name:     "Dimmed key value pairs",
+            fields:   []Field{errField},
+            settings: PrettyFormatterSettings{},
+            args:     LogLineArgs{Level: Info},
+            data:     errors.New("boom"),
+            want: "19:30:00.000 " + string(Colors.White.Colorize([]byte("INFO"))) + "  " +
+                string(prettyDim.Colorize([]byte("error=boom"))),
+        },
+        {
+            name:     "Stack trace",
+            fields:   []Field{errField},
+            settings: PrettyFormatterSettings{DisableColors: true},
+            args:     LogLineArgs{Level: Error},
+            data:     errors.New("boom\nmain.go:42"),
+            want:     "19:30:00.000 ERROR\n    error:\n        boom\n        main.go:42",
+        },
+        {
+            name:     "Formatted stack trace",
+            fields:   []Field{errField},
+            settings: PrettyFormatterSettings{DisableColors: true},
+            args:     LogLineArgs{Level: Error},
+            data:     stackError{},
+            want:     "19:30:00.000 ERROR\n    error:\n        stack error\n        main.go:12",
+        },
+        {
+            name:     "Map",
+            fields:   []Field{mapField},
+            settings: PrettyFormatterSettings{DisableColors: true},
+            args:     LogLineArgs{Level: Debug},
+            data:     map[string]string{"b": "2", "a": "1"},
+            want:     "19:30:00.000 DEBUG\n    headers:\n        a: 1\n        b: 2",
+        },
+        {
+            name:     "Array of structs",
+            fields:   []Field{addressesField},
+            settings: PrettyFormatterSettings{DisableColors: true},
+            args:     LogLineArgs{Level: Info},
+            data:     []address{{City: "Paris", Zip: "75001"}},
+            want:     "19:30:00.000 INFO\n    addresses:\n        -\n            city: Paris\n            zip: 75001",
+        },
+        {
+            name:     "Array of scalars",
+            fields:   []Field{idsField},
+            settings: PrettyFormatterSettings{DisableColors: true},
+            args:     LogLineArgs{Level: Info},
+            data:     []int{1, 2},
+            want:     "19:30:00.000 INFO\n    ids:\n        - 1\n        - 2",
+        },
+        {
+            name:     "Tag column",
+            fields:   []Field{NewTagField(Brackets.Square, nil), NewMessageField()},
+            settings: PrettyFormatterSettings{DisableColors: true, TagWidth: 6},
+            args:     LogLineArgs{Level: Info, Tag: "db"},
+            data:     "connected",
+            want:     "19:30:00.000 INFO  [db]   connected",
+        },
+        {
+            name:     "Empty tag column",
+            fields:   []Field{NewMessageField()},
+            settings: PrettyFormatterSettings{DisableColors: true, TagWidth: 6},
+            args:     LogLineArgs{Level: Info},
+            data:     "connected",
+            want:     "19:30:00.000 INFO         connected",
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            f, err := NewPrettyFormatter(tt.fields, tt.settings)
+            if err != nil {
+                t.Fatalf("NewPrettyFormatter() error = %v", err)
+            }
+            f.clock = mockClock{}
+
+            res := f.FormatLogLine(tt.args, tt.data)
+            if res.err != nil {
+                t.Fatalf("FormatLogLine() error = %v", res.err)
+            }
+
+            if string(res.bytes) != tt.want {
+                t.Errorf("FormatLogLine() = %q, want %q", res.bytes, tt.want)
+            }
+        })
+    }
+}
+
+func TestPrettyFormatter_zeroValue(t *testing.T) {
+    f := &PrettyFormatter{Fields: []Field{NewMessageField()}, Settings: PrettyFormatterSettings{DisableColors: true}}
+
+    res := f.FormatLogLine(LogLineArgs{Level: Info}, "hello")
+    if res.err != nil {
+        t.Fatalf("FormatLogLine() error = %v", res.err)
+    }
+
+    if !strings.HasSuffix(string(res.bytes), "INFO  hello") {
+        t.Errorf("FormatLogLine() = %q, want a line ending with the message", res.bytes)
+    }
+}
@@ -0,0 +1,156 @@
+package ultralogger
+
+import (
+    "bytes"
+    "encoding/json"
+    "fmt"
+    "strings"
+    "text/template"
+    "time"
+    "unicode/utf8"
+)
+
+// TemplateRecord is the data that the template of a TemplateFormatter is executed against.
+type TemplateRecord struct {
+    // Level is the Level of the log line.
+    Level Level
+    // Tag is the Tag of the log line.
+    Tag string
+    // Time is the time the log line was formatted.
+    Time time.Time
+    // Caller is the Caller of the log line, or nil if the logger was not created with [WithCaller].
+    Caller *Caller
+    // Fields are the text results of the formatter's fields, by field name. Fields that have no result for the data
+    // are missing from the map.
+    Fields map[string]any
+}
+
+// TemplateFormatter is a formatter that renders log lines with a text/template.
+//
+// The template is executed against a [TemplateRecord], e.g.
+//
+//  {{.Time.Format "15:04:05"}} {{.Level | pad 5 | color .Level}} {{.Fields.message}}
+//
+// The following functions are available in addition to the text/template builtins:
+//  - pad WIDTH VALUE => VALUE left aligned and padded with spaces to WIDTH runes.
+//  - padLeft WIDTH VALUE => VALUE right aligned and padded with spaces to WIDTH runes.
+//  - truncate MAX VALUE => VALUE truncated to at most MAX runes.
+//  - json VALUE => VALUE encoded as JSON.
+//  - color LEVEL VALUE => VALUE colorized with the default color of LEVEL.
+//  - upper VALUE, lower VALUE => VALUE in upper or lower case.
+//
+// A TemplateFormatter must be created with [NewTemplateFormatter]; a struct literal has no template, and every line
+// fails with ErrorNilTemplate.
+type TemplateFormatter struct {
+    Fields []Field
+
+    tmpl  *template.Template
+    clock clock
+    plan  fieldPlanCache
+}
+
+// NewTemplateFormatter parses the template and returns a new TemplateFormatter that renders the provided fields. Parse
+// errors are returned immediately, rather than on every log line.
+func NewTemplateFormatter(tmpl string, fields []Field) (*TemplateFormatter, error) {
+    parsed, err := template.New("ultralogger").Funcs(templateFuncs).Parse(tmpl)
+    if err != nil {
+        return nil, err
+    }
+
+    f := &TemplateFormatter{
+        Fields: fields,
+        tmpl:   parsed,
+        clock:  &realClock{},
+    }
+    if _, err := f.plan.get(fields); err != nil {
+        return nil, err
+    }
+
+    return f, nil
+}
+
+// FormatLogLine formats the log line using the provided data and returns a FormatResult which contains the formatted
+// log line and any errors that may have occurred.
+func (f *TemplateFormatter) FormatLogLine(args LogLineArgs, data any) FormatResult {
+    if f.tmpl == nil {
+        return FormatResult{nil, ErrorNilTemplate}
+    }
+
+    plan, err := f.plan.get(f.Fields)
+    if err != nil {
+        return FormatResult{nil, err}
+    }
+
+    args.OutputFormat = OutputFormatText
+
+    record := TemplateRecord{
+        Level:  args.Level,
+        Tag:    args.Tag,
+        Time:   f.clock.Now(),
+        Caller: args.Caller,
+        Fields: make(map[string]any, len(f.Fields)),
+    }
+
+    for i := range plan.fields {
+        fieldResult, err := plan.result(i, args, data)
+        if err != nil {
+            return FormatResult{nil, err}
+        }
+
+        if fieldResult == nil {
+            continue
+        }
+
+        record.Fields[fieldResult.Name] = fieldResult.Data
+    }
+
+    buf := &bytes.Buffer{}
+    if err := f.tmpl.Execute(buf, record); err != nil {
+        return FormatResult{nil, err}
+    }
+
+    return FormatResult{buf.Bytes(), nil}
+}
+
+var templateFuncs = template.FuncMap{
+    "pad": func(width int, value any) string {
+        s := fmt.Sprint(value)
+        return s + templatePadding(width, s)
+    },
+    "padLeft": func(width int, value any) string {
+        s := fmt.Sprint(value)
+        return templatePadding(width, s) + s
+    },
+    "truncate": func(max int, value any) string {
+        s := fmt.Sprint(value)
+        if utf8.RuneCountInString(s) <= max {
+            return s
+        }
+        return string([]rune(s)[:max])
+    },
+    "json": func(value any) (string, error) {
+        jBytes, err := json.Marshal(value)
+        return string(jBytes), err
+    },
+    "color": func(level Level, value any) string {
+        color, ok := defaultLevelColors[level]
+        if !ok {
+            return fmt.Sprint(value)
+        }
+        return string(color.Colorize([]byte(fmt.Sprint(value))))
+    },
+    "upper": func(value any) string {
+        return strings.ToUpper(fmt.Sprint(value))
+    },
+    "lower": func(value any) string {
+        return strings.ToLower(fmt.Sprint(value))
+    },
+}
+
+func templatePadding(width int, s string) string {
+    length := utf8.RuneCountInString(s)
+    if length >= width {
+        return ""
+    }
+    return strings.Repeat(" ", width-length)
+}
@@ -0,0 +1,118 @@
+package ultralogger
+
+import (
+    "errors"
+    "os"
+    "testing"
+)
+
+func ExampleNewTemplateFormatter() {
+    formatter, _ := NewTemplateFormatter(
+        `{{.Time.Format "15:04:05"}} {{.Level | pad 5}} {{with .Tag}}[{{.}}] {{end}}{{.Fields.message}}`,
+        []Field{NewMessageField()},
+    )
+    formatter.clock = mockClock{}
+
+    logger, _ := NewLoggerWithOptions(WithDestination(os.Stdout, formatter), WithTag("api"), WithAsync(false))
+
+    logger.Info("This is an info message.")
+    // Output: 19:30:00 INFO  [api] This is an info message.
+}
+
+func TestTemplateFormatter_FormatLogLine(t *testing.T) {
+    countField, _ := NewIntField("count")
+
+    tests := []struct {
+        name    string
+        tmpl    string
+        fields  []Field
+        args    LogLineArgs
+        data    any
+        want    string
+        wantErr bool
+    }{
+        {
+            name: "padLeft",
+            tmpl: "{{padLeft 6 .Level}}|",
+            args: LogLineArgs{Level: Warn},
+            want: "  WARN|",
+        },
+        {
+            name:   "truncate",
+            tmpl:   "{{.Fields.message | truncate 4}}",
+            fields: []Field{NewMessageField()},
+            data:   "héllo world",
+            want:   "héll",
+        },
+        {
+            name:   "json",
+            tmpl:   "{{json .Fields.message}}",
+            fields: []Field{NewMessageField()},
+            data:   `say "hi"`,
+            want:   `"say \"hi\""`,
+        },
+        {
+            name: "color",
+            tmpl: "{{color .Level .Level}}",
+            args: LogLineArgs{Level: Error},
+            want: string(Colors.Red.Colorize([]byte("ERROR"))),
+        },
+        {
+            name: "upper and lower",
+            tmpl: "{{lower .Level}} {{upper .Tag}}",
+            args: LogLineArgs{Level: Info, Tag: "api"},
+            want: "info API",
+        },
+        {
+            name:   "Missing field result",
+            tmpl:   "{{with .Fields.count}}count={{.}}{{else}}no count{{end}}",
+            fields: []Field{countField},
+            data:   "not an int",
+            want:   "no count",
+        },
+        {
+            name:   "Field result",
+            tmpl:   "count={{.Fields.count}}",
+            fields: []Field{countField},
+            data:   3,
+            want:   "count=3",
+        },
+        {
+            name:    "Execution error",
+            tmpl:    "{{.Missing}}",
+            wantErr: true,
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            f, err := NewTemplateFormatter(tt.tmpl, tt.fields)
+            if err != nil {
+                t.Fatalf("NewTemplateFormatter() error = %v", err)
+            }
+            f.clock = mockClock{}
+
+            res := f.FormatLogLine(tt.args, tt.data)
+            if (res.err != nil) != tt.wantErr {
+                t.Fatalf("FormatLogLine() error = %v, wantErr %v", res.err, tt.wantErr)
+            }
+
+            if !tt.wantErr && string(res.bytes) != tt.want {
+                t.Errorf("FormatLogLine() = %q, want %q", res.bytes, tt.want)
+            }
+        })
+    }
+}
+
+func TestNewTemplateFormatter_ParseError(t *testing.T) {
+    if _, err := NewTemplateFormatter("{{.Level", nil); err == nil {
+        t.Errorf("NewTemplateFormatter() error = nil, want parse error")
+    }
+}
+
+func TestTemplateFormatter_zeroValue(t *testing.T) {
+    f := &TemplateFormatter{Fields: []Field{NewMessageField()}}
+
+    if res := f.FormatLogLine(LogLineArgs{Level: Info}, "hello"); !errors.Is(res.err, ErrorNilTemplate) {
+        t.Errorf("FormatLogLine() error = %v, want ErrorNilTemplate", res.err)
+    }
+}
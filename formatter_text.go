@@ -2,44 +2,130 @@ package ultralogger
 
 import (
     "fmt"
+    "slices"
+    "strconv"
+    "strings"
+    "unicode"
 )
 
+const defaultTextFieldSeparator = " "
+
 // TextFormatter is a formatter that formats log lines as text.
+//
+// The field results are written in the order of the Fields, separated by the FieldSeparator. Results that are written
+// as an empty string, e.g. the tag of a line without a tag, are skipped. Results can be written as name=value pairs,
+// in which case values that contain spaces, quotes, '=' or the separator are quoted.
 type TextFormatter struct {
-    Fields         []Field
+    Fields []Field
+    // FieldSeparator is written between the field results. If it is empty, a single space is used.
     FieldSeparator string
-}
+    // KeyValue writes every field result as name=value.
+    KeyValue bool
+    // KeyValueFields are the names of the field results that are written as name=value, if KeyValue is false.
+    KeyValueFields []string
+    // Policies are the policies for nil data and data of the wrong type. By default, nil data is written as "<nil>",
+    // and fields that reject the data are omitted.
+    Policies FieldPolicies
 
-// TODO: Provide a way to specify the separator between fields.
-// TODO: Provide a way to specify behavior on nil data.
+    plan fieldPlanCache
+}
 
 // FormatLogLine formats the log line using the provided data and returns a FormatResult which contains the formatted
 // log line and any errors that may have occurred.
 func (f *TextFormatter) FormatLogLine(args LogLineArgs, data any) FormatResult {
-    line := make([]byte, 0)
+    plan, err := f.plan.get(f.Fields)
+    if err != nil {
+        return FormatResult{nil, err}
+    }
+
     args.OutputFormat = OutputFormatText
 
-    for i, field := range f.Fields {
-        fieldResult, err := computeFieldResult(field, args, data)
+    results := make([]FieldResult, 0, len(plan.fields))
+    for i := range plan.fields {
+        results, err = plan.appendPolicyResults(results, i, args, data, f.Policies)
         if err != nil {
-            return FormatResult{nil, &ErrorFieldFormatterInit{field: field, err: err}}
+            return FormatResult{nil, err}
         }
+    }
 
-        if fieldResult == nil {
+    separator := f.FieldSeparator
+    if separator == "" {
+        separator = defaultTextFieldSeparator
+    }
+
+    line := make([]byte, 0)
+    for _, fieldResult := range results {
+        var value string
+        if fieldResult.Data == nil {
+            value = defaultNilPlaceholder
+        } else {
+            value = fmt.Sprint(fieldResult.Data)
+        }
+
+        if value == "" {
             continue
         }
 
-        resultBytes := fieldResult.Data
-        if fieldResult.Data == nil {
-            resultBytes = "<nil>"
+        if len(line) > 0 {
+            line = append(line, separator...)
         }
 
-        if i < len(f.Fields)-1 {
-            line = fmt.Append(line, resultBytes, " ")
-        } else {
-            line = fmt.Append(line, resultBytes)
+        if f.KeyValue || slices.Contains(f.KeyValueFields, fieldResult.Name) {
+            line = append(line, fieldResult.Name...)
+            line = append(line, '=')
+            line = appendTextValue(line, value, separator)
+            continue
         }
+
+        line = append(line, value...)
     }
 
     return FormatResult{line, nil}
 }
+
+// appendTextValue appends the value of a name=value pair, quoted if it contains spaces, quotes, '=', non-printable
+// characters or the separator, so that the pairs can be parsed back.
+func appendTextValue(line []byte, value, separator string) []byte {
+    needsQuotes := strings.Contains(value, separator) || strings.ContainsFunc(value, func(r rune) bool {
+        return unicode.IsSpace(r) || r == '"' || r == '=' || !unicode.IsPrint(r)
+    })
+    if needsQuotes {
+        return strconv.AppendQuote(line, value)
+    }
+    return append(line, value...)
+}
+
+// WithFieldSeparator sets the separator between the field results of a TextFormatter, also when it is colorized.
+func WithFieldSeparator(separator string) FormatterOption {
+    return func(f LogLineFormatter) LogLineFormatter {
+        return withTextFormatter(f, func(textFormatter *TextFormatter) {
+            textFormatter.FieldSeparator = separator
+        })
+    }
+}
+
+// WithKeyValue writes the field results of a TextFormatter as name=value pairs, also when it is colorized. If names are
+// provided, only the results with those names are written as pairs; otherwise all results are.
+func WithKeyValue(names ...string) FormatterOption {
+    return func(f LogLineFormatter) LogLineFormatter {
+        return withTextFormatter(f, func(textFormatter *TextFormatter) {
+            if len(names) == 0 {
+                textFormatter.KeyValue = true
+                return
+            }
+            textFormatter.KeyValueFields = append(textFormatter.KeyValueFields, names...)
+        })
+    }
+}
+
+func withTextFormatter(f LogLineFormatter, apply func(textFormatter *TextFormatter)) LogLineFormatter {
+    switch formatter := f.(type) {
+    case *TextFormatter:
+        apply(formatter)
+    case *ColorizedFormatter:
+        withTextFormatter(formatter.BaseFormatter, apply)
+    case *RedactingFormatter:
+        withTextFormatter(formatter.BaseFormatter, apply)
+    }
+    return f
+}
@@ -0,0 +1,168 @@
+package ultralogger
+
+import (
+    "os"
+    "testing"
+)
+
+func ExampleWithKeyValue() {
+    userField, _ := NewStringField("user")
+
+    formatter, _ := NewFormatter(
+        OutputFormatText,
+        []Field{NewLevelField(Brackets.Angle), NewTagField(Brackets.Square, nil), userField},
+        WithKeyValue("user"),
+    )
+
+    logger, _ := NewLoggerWithOptions(WithDestination(os.Stdout, formatter), WithAsync(false))
+
+    logger.Info("john doe")
+    // Output: <INFO> user="john doe"
+}
+
+func TestTextFormatter_FormatLogLine(t *testing.T) {
+    userField, _ := NewStringField("user")
+    countField, _ := NewObjectField[string]("count", func(args LogLineArgs, data string) any {
+        return len(data)
+    })
+
+    tests := []struct {
+        name      string
+        formatter *TextFormatter
+        args      LogLineArgs
+        data      any
+        want      string
+    }{
+        {
+            name: "Default separator",
+            formatter: &TextFormatter{
+                Fields: []Field{NewLevelField(Brackets.Angle), NewMessageField()},
+            },
+            args: LogLineArgs{Level: Info},
+            data: "hello world",
+            want: "<INFO> hello world",
+        },
+        {
+            name: "Custom separator",
+            formatter: &TextFormatter{
+                Fields:         []Field{NewLevelField(Brackets.None), NewMessageField(), countField},
+                FieldSeparator: " | ",
+            },
+            args: LogLineArgs{Level: Warn},
+            data: "hello",
+            want: "WARN | hello | 5",
+        },
+        {
+            name: "Empty tag",
+            formatter: &TextFormatter{
+                Fields: []Field{NewTagField(Brackets.Square, nil), NewLevelField(Brackets.Angle), NewMessageField()},
+            },
+            args: LogLineArgs{Level: Info},
+            data: "hello",
+            want: "<INFO> hello",
+        },
+        {
+            name: "Empty last result",
+            formatter: &TextFormatter{
+                Fields: []Field{NewLevelField(Brackets.Angle), NewMessageField(), NewTagField(Brackets.Square, nil)},
+            },
+            args: LogLineArgs{Level: Info},
+            data: "hello",
+            want: "<INFO> hello",
+        },
+        {
+            name: "Key value",
+            formatter: &TextFormatter{
+                Fields:   []Field{NewLevelField(Brackets.None), NewTagField(Brackets.None, nil), userField, countField},
+                KeyValue: true,
+            },
+            args: LogLineArgs{Level: Error, Tag: "api"},
+            data: "john",
+            want: "level=ERROR tag=api user=john count=4",
+        },
+        {
+            name: "Key value fields",
+            formatter: &TextFormatter{
+                Fields:         []Field{NewLevelField(Brackets.Angle), NewMessageField(), countField},
+                KeyValueFields: []string{"count"},
+            },
+            args: LogLineArgs{Level: Info},
+            data: "hello world",
+            want: "<INFO> hello world count=11",
+        },
+        {
+            name: "Quoted values",
+            formatter: &TextFormatter{
+                Fields:   []Field{userField},
+                KeyValue: true,
+            },
+            args: LogLineArgs{Level: Info},
+            data: `john "jd" doe`,
+            want: `user="john \"jd\" doe"`,
+        },
+        {
+            name: "Quoted equals",
+            formatter: &TextFormatter{
+                Fields:   []Field{userField},
+                KeyValue: true,
+            },
+            args: LogLineArgs{Level: Info},
+            data: "a=b",
+            want: `user="a=b"`,
+        },
+        {
+            name: "Quoted separator",
+            formatter: &TextFormatter{
+                Fields:         []Field{userField, countField},
+                FieldSeparator: ",",
+                KeyValue:       true,
+            },
+            args: LogLineArgs{Level: Info},
+            data: "doe,john",
+            want: `user="doe,john",count=8`,
+        },
+        {
+            name: "Quoted newline",
+            formatter: &TextFormatter{
+                Fields:   []Field{userField},
+                KeyValue: true,
+            },
+            args: LogLineArgs{Level: Info},
+            data: "john\ndoe",
+            want: `user="john\ndoe"`,
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            res := tt.formatter.FormatLogLine(tt.args, tt.data)
+            if res.err != nil {
+                t.Fatalf("FormatLogLine() error = %v", res.err)
+            }
+
+            if string(res.bytes) != tt.want {
+                t.Errorf("FormatLogLine() = %q, want %q", res.bytes, tt.want)
+            }
+        })
+    }
+}
+
+func TestTextFormatterOptions(t *testing.T) {
+    formatter, err := NewFormatter(
+        OutputFormatText,
+        []Field{NewLevelField(Brackets.None), NewMessageField()},
+        WithDefaultColorization(),
+        WithFieldSeparator("\t"),
+        WithKeyValue(),
+    )
+    if err != nil {
+        t.Fatalf("NewFormatter() error = %v", err)
+    }
+
+    textFormatter := formatter.(*ColorizedFormatter).BaseFormatter.(*TextFormatter)
+    if textFormatter.FieldSeparator != "\t" {
+        t.Errorf("FieldSeparator = %q, want %q", textFormatter.FieldSeparator, "\t")
+    }
+    if !textFormatter.KeyValue {
+        t.Errorf("KeyValue = false, want true")
+    }
+}
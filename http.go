@@ -0,0 +1,315 @@
+package ultralogger
+
+import (
+    "bufio"
+    "context"
+    "fmt"
+    "net"
+    "net/http"
+    "path"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// combinedLogTimeFormat is the format of the time of a line of the Apache combined log format.
+const combinedLogTimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+type requestStartKey struct{}
+
+type requestDurationKey struct{}
+
+// ContextWithRequestStart returns a copy of the context that carries the time the request was sent, or received.
+// [NewResponseField] reads it from the context of the request of the response to log the duration of the request.
+func ContextWithRequestStart(ctx context.Context, start time.Time) context.Context {
+    return context.WithValue(ctx, requestStartKey{}, start)
+}
+
+// RequestStartFromContext returns the time the request was sent, or received, carried by the context, if any. It is
+// safe to call with a nil context.
+func RequestStartFromContext(ctx context.Context) (time.Time, bool) {
+    if ctx == nil {
+        return time.Time{}, false
+    }
+
+    start, ok := ctx.Value(requestStartKey{}).(time.Time)
+    if !ok || start.IsZero() {
+        return time.Time{}, false
+    }
+    return start, true
+}
+
+// contextWithRequestDuration returns a copy of the context that carries the duration of the completed request, so that
+// the duration doesn't include the time until the line is formatted.
+func contextWithRequestDuration(ctx context.Context, duration time.Duration) context.Context {
+    return context.WithValue(ctx, requestDurationKey{}, duration)
+}
+
+// requestDuration returns the duration of the completed request carried by the context, or else the time since the
+// request start. Returns 0 if the context carries neither.
+func requestDuration(ctx context.Context) time.Duration {
+    if duration, ok := ctx.Value(requestDurationKey{}).(time.Duration); ok {
+        return duration
+    }
+    if start, ok := RequestStartFromContext(ctx); ok {
+        return time.Since(start)
+    }
+    return 0
+}
+
+// MiddlewareOption is an option of [HTTPMiddleware].
+type MiddlewareOption func(m *httpMiddleware)
+
+// WithStatusLevel sets the function that maps the status code of a response to the level of its access-log line. By
+// default, 5xx responses are logged at Error, 4xx responses at Warn, and other responses at Info.
+func WithStatusLevel(level func(statusCode int) Level) MiddlewareOption {
+    return func(m *httpMiddleware) {
+        m.statusLevel = level
+    }
+}
+
+// WithSkipPaths skips the access-log lines of the requests whose path matches one of the patterns, e.g. "/healthz" or
+// "/debug/*". The patterns use the syntax of [path.Match]; invalid patterns match no path.
+func WithSkipPaths(patterns ...string) MiddlewareOption {
+    return func(m *httpMiddleware) {
+        m.skipPaths = append(m.skipPaths, patterns...)
+    }
+}
+
+// WithCombinedLog logs the access-log lines as strings in the Apache combined log format, e.g.
+// `203.0.113.7 - - [07/Nov/2024:19:30:00 +0000] "GET /orders HTTP/1.1" 200 512 "-" "curl/8.5.0"`, instead of as
+// responses.
+//
+// See https://httpd.apache.org/docs/current/logs.html#combined for more information.
+func WithCombinedLog() MiddlewareOption {
+    return func(m *httpMiddleware) {
+        m.combinedLog = true
+    }
+}
+
+// WithRequestBody captures up to maxSize bytes of the body of every request with [CaptureRequestBody] before the
+// handler is called, so that a [NewRequestField] with a MaxBodySize logs it.
+func WithRequestBody(maxSize int) MiddlewareOption {
+    return func(m *httpMiddleware) {
+        m.maxBodySize = maxSize
+    }
+}
+
+// HTTPMiddleware returns a net/http middleware that logs one access-log line per request with the logger, once the
+// handler returns.
+//
+// The data of the line is an *http.Response with the status code, the number of bytes written as the ContentLength,
+// the headers of the response and the request, whose context carries the start and the duration of the request. Log
+// it with a [NewResponseField] and a [NewRequestField]. If the logger is a [ContextLogger], the line is logged with
+// the context of the request.
+//
+// The wrapped http.ResponseWriter supports http.Flusher and http.Hijacker if the original one does, and
+// [http.ResponseController]. Hijacked connections are logged with the status code 101 if the handler didn't write one.
+func HTTPMiddleware(logger Logger, opts ...MiddlewareOption) func(http.Handler) http.Handler {
+    m := &httpMiddleware{
+        logger:      logger,
+        statusLevel: defaultStatusLevel,
+        clock:       &realClock{},
+    }
+    for _, opt := range opts {
+        opt(m)
+    }
+
+    return func(next http.Handler) http.Handler {
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            if m.skip(r) {
+                next.ServeHTTP(w, r)
+                return
+            }
+
+            start := m.clock.Now()
+            r = CaptureRequestBody(r, m.maxBodySize)
+            r = r.WithContext(ContextWithRequestStart(r.Context(), start))
+
+            rw := &accessLogResponseWriter{ResponseWriter: w}
+            defer func() {
+                // The request of a panicking handler is logged as a 500, before the panic is passed on to the server.
+                if p := recover(); p != nil {
+                    if rw.status == 0 {
+                        rw.status = http.StatusInternalServerError
+                    }
+                    m.log(r, rw, m.clock.Now().Sub(start))
+                    panic(p)
+                }
+                m.log(r, rw, m.clock.Now().Sub(start))
+            }()
+
+            next.ServeHTTP(rw, r)
+        })
+    }
+}
+
+type httpMiddleware struct {
+    logger      Logger
+    statusLevel func(statusCode int) Level
+    skipPaths   []string
+    combinedLog bool
+    maxBodySize int
+
+    clock clock
+}
+
+func defaultStatusLevel(statusCode int) Level {
+    switch {
+    case statusCode >= 500:
+        return Error
+    case statusCode >= 400:
+        return Warn
+    default:
+        return Info
+    }
+}
+
+func (m *httpMiddleware) skip(r *http.Request) bool {
+    for _, pattern := range m.skipPaths {
+        if ok, _ := path.Match(pattern, r.URL.Path); ok {
+            return true
+        }
+    }
+    return false
+}
+
+func (m *httpMiddleware) log(r *http.Request, rw *accessLogResponseWriter, duration time.Duration) {
+    statusCode := rw.statusCode()
+
+    ctx := contextWithRequestDuration(r.Context(), duration)
+    level := m.statusLevel(statusCode)
+
+    if m.combinedLog {
+        start, _ := RequestStartFromContext(ctx)
+        logContext(m.logger, ctx, level, combinedLogLine(r, statusCode, rw.written, start))
+        return
+    }
+
+    logContext(m.logger, ctx, level, &http.Response{
+        Status:        strconv.Itoa(statusCode) + " " + http.StatusText(statusCode),
+        StatusCode:    statusCode,
+        Proto:         r.Proto,
+        ProtoMajor:    r.ProtoMajor,
+        ProtoMinor:    r.ProtoMinor,
+        Header:        rw.Header().Clone(),
+        ContentLength: rw.written,
+        Request:       r.WithContext(ctx),
+    })
+}
+
+// combinedLogLine returns the line of the request in the Apache combined log format.
+func combinedLogLine(r *http.Request, statusCode int, written int64, start time.Time) string {
+    host, _, err := net.SplitHostPort(r.RemoteAddr)
+    if err != nil {
+        host = r.RemoteAddr
+    }
+
+    user := "-"
+    if r.URL.User != nil && r.URL.User.Username() != "" {
+        user = r.URL.User.Username()
+    } else if username, _, ok := r.BasicAuth(); ok && username != "" {
+        user = username
+    }
+
+    size := "-"
+    if written > 0 {
+        size = strconv.FormatInt(written, 10)
+    }
+
+    return fmt.Sprintf(
+        `%s - %s [%s] "%s %s %s" %d %s "%s" "%s"`,
+        host,
+        combinedLogEscape(user),
+        start.Format(combinedLogTimeFormat),
+        r.Method,
+        combinedLogEscape(r.RequestURI),
+        r.Proto,
+        statusCode,
+        size,
+        combinedLogEscape(combinedLogDefault(r.Referer())),
+        combinedLogEscape(combinedLogDefault(r.UserAgent())),
+    )
+}
+
+func combinedLogDefault(s string) string {
+    if s == "" {
+        return "-"
+    }
+    return s
+}
+
+// combinedLogEscape escapes the quotes, backslashes and non-printable characters of a value of the combined log
+// format, like Apache does.
+func combinedLogEscape(s string) string {
+    quoted := strconv.Quote(s)
+    return strings.ReplaceAll(quoted[1:len(quoted)-1], `\'`, `'`)
+}
+
+// accessLogResponseWriter is an http.ResponseWriter that records the status code and the number of bytes written.
+type accessLogResponseWriter struct {
+    http.ResponseWriter
+    status   int
+    written  int64
+    hijacked bool
+}
+
+func (w *accessLogResponseWriter) WriteHeader(statusCode int) {
+    // Informational responses, e.g. 103 Early Hints, are followed by the final response.
+    if w.status == 0 && (statusCode >= 200 || statusCode == http.StatusSwitchingProtocols) {
+        w.status = statusCode
+    }
+    w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *accessLogResponseWriter) Write(b []byte) (int, error) {
+    if w.status == 0 {
+        w.status = http.StatusOK
+    }
+
+    n, err := w.ResponseWriter.Write(b)
+    w.written += int64(n)
+    return n, err
+}
+
+// Flush implements http.Flusher. It does nothing if the original http.ResponseWriter doesn't implement it.
+func (w *accessLogResponseWriter) Flush() {
+    if w.status == 0 {
+        w.status = http.StatusOK
+    }
+
+    if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+        flusher.Flush()
+    }
+}
+
+// Hijack implements http.Hijacker. It returns http.ErrNotSupported if the original http.ResponseWriter doesn't
+// implement it.
+func (w *accessLogResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+    hijacker, ok := w.ResponseWriter.(http.Hijacker)
+    if !ok {
+        return nil, nil, http.ErrNotSupported
+    }
+
+    conn, rw, err := hijacker.Hijack()
+    if err == nil {
+        w.hijacked = true
+    }
+    return conn, rw, err
+}
+
+// Unwrap returns the original http.ResponseWriter, for http.ResponseController.
+func (w *accessLogResponseWriter) Unwrap() http.ResponseWriter {
+    return w.ResponseWriter
+}
+
+func (w *accessLogResponseWriter) statusCode() int {
+    switch {
+    case w.status != 0:
+        return w.status
+    case w.hijacked:
+        return http.StatusSwitchingProtocols
+    default:
+        return http.StatusOK
+    }
+}
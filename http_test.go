@@ -0,0 +1,274 @@
+package ultralogger
+
+import (
+    "bufio"
+    "bytes"
+    "encoding/json"
+    "errors"
+    "io"
+    "net"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "strings"
+    "testing"
+    "time"
+)
+
+func ExampleHTTPMiddleware() {
+    requestField, _ := NewRequestField("request", RequestFieldSettings{LogMethod: true, LogPath: true})
+    responseField, _ := NewResponseField("response", ResponseFieldSettings{LogStatusCode: true, LogContentLength: true})
+
+    formatter, _ := NewFormatter(OutputFormatJSON, []Field{requestField, responseField})
+
+    logger, _ := NewLoggerWithOptions(WithDestination(os.Stdout, formatter), WithAsync(false))
+
+    handler := HTTPMiddleware(logger, WithSkipPaths("/healthz"))(http.HandlerFunc(
+        func(w http.ResponseWriter, r *http.Request) {
+            w.WriteHeader(http.StatusCreated)
+            _, _ = w.Write([]byte("created"))
+        },
+    ))
+
+    handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+    handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/orders", nil))
+    // Output: {"request":{"method":"POST","path":"/orders"},"response":{"status_code":201,"content_length":7}}
+}
+
+type hijackableRecorder struct {
+    *httptest.ResponseRecorder
+}
+
+func (r hijackableRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+    conn, _ := net.Pipe()
+    return conn, bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn)), nil
+}
+
+func TestHTTPMiddleware(t *testing.T) {
+    tests := []struct {
+        name       string
+        opts       []MiddlewareOption
+        path       string
+        writer     func() http.ResponseWriter
+        handler    http.HandlerFunc
+        wantLevel  string
+        wantStatus int
+        wantSize   int
+    }{
+        {
+            name: "Implicit OK",
+            path: "/orders",
+            handler: func(w http.ResponseWriter, r *http.Request) {
+                _, _ = w.Write([]byte("hello"))
+                _, _ = w.Write([]byte(" world"))
+            },
+            wantLevel:  "INFO",
+            wantStatus: http.StatusOK,
+            wantSize:   11,
+        },
+        {
+            name: "Client error",
+            path: "/orders",
+            handler: func(w http.ResponseWriter, r *http.Request) {
+                http.NotFound(w, r)
+            },
+            wantLevel:  "WARN",
+            wantStatus: http.StatusNotFound,
+            wantSize:   len("404 page not found\n"),
+        },
+        {
+            name: "Server error",
+            path: "/orders",
+            handler: func(w http.ResponseWriter, r *http.Request) {
+                w.WriteHeader(http.StatusBadGateway)
+                w.WriteHeader(http.StatusOK)
+            },
+            wantLevel:  "ERROR",
+            wantStatus: http.StatusBadGateway,
+        },
+        {
+            name: "Early hints",
+            path: "/orders",
+            handler: func(w http.ResponseWriter, r *http.Request) {
+                w.WriteHeader(http.StatusEarlyHints)
+                w.WriteHeader(http.StatusNoContent)
+            },
+            wantLevel:  "INFO",
+            wantStatus: http.StatusNoContent,
+        },
+        {
+            name: "Status level",
+            opts: []MiddlewareOption{WithStatusLevel(func(statusCode int) Level {
+                return Debug
+            })},
+            path: "/orders",
+            handler: func(w http.ResponseWriter, r *http.Request) {
+                w.WriteHeader(http.StatusInternalServerError)
+            },
+            wantLevel:  "DEBUG",
+            wantStatus: http.StatusInternalServerError,
+        },
+        {
+            name: "Flush",
+            path: "/events",
+            handler: func(w http.ResponseWriter, r *http.Request) {
+                if err := http.NewResponseController(w).Flush(); err != nil {
+                    t.Errorf("Flush() error = %v", err)
+                }
+            },
+            wantLevel:  "INFO",
+            wantStatus: http.StatusOK,
+        },
+        {
+            name: "Hijack",
+            path: "/ws",
+            writer: func() http.ResponseWriter {
+                return hijackableRecorder{httptest.NewRecorder()}
+            },
+            handler: func(w http.ResponseWriter, r *http.Request) {
+                conn, _, err := http.NewResponseController(w).Hijack()
+                if err != nil {
+                    t.Fatalf("Hijack() error = %v", err)
+                }
+                _ = conn.Close()
+            },
+            wantLevel:  "INFO",
+            wantStatus: http.StatusSwitchingProtocols,
+        },
+        {
+            name:    "Skip",
+            opts:    []MiddlewareOption{WithSkipPaths("/healthz", "/debug/*")},
+            path:    "/debug/pprof",
+            handler: func(w http.ResponseWriter, r *http.Request) {},
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            responseField, _ := NewResponseField("response", ResponseFieldSettings{
+                LogStatusCode:    true,
+                LogContentLength: true,
+                LogDuration:      true,
+            })
+            formatter, _ := NewFormatter(OutputFormatJSON, []Field{NewLevelField(Brackets.None), responseField})
+
+            buf := &bytes.Buffer{}
+            logger, _ := NewLoggerWithOptions(WithDestination(buf, formatter), WithAsync(false), WithMinLevel(Debug))
+
+            w := http.ResponseWriter(httptest.NewRecorder())
+            if tt.writer != nil {
+                w = tt.writer()
+            }
+
+            handler := HTTPMiddleware(logger, tt.opts...)(tt.handler)
+            handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+            if tt.wantLevel == "" {
+                if buf.Len() != 0 {
+                    t.Errorf("HTTPMiddleware() logged %s, want nothing", buf)
+                }
+                return
+            }
+
+            var line struct {
+                Level    string           `json:"level"`
+                Response ResponseLogEntry `json:"response"`
+            }
+            if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+                t.Fatalf("Unmarshal() error = %v, line = %s", err, buf)
+            }
+
+            if line.Level != tt.wantLevel {
+                t.Errorf("HTTPMiddleware() level = %s, want %s", line.Level, tt.wantLevel)
+            }
+            if line.Response.StatusCode != tt.wantStatus {
+                t.Errorf("HTTPMiddleware() status = %d, want %d", line.Response.StatusCode, tt.wantStatus)
+            }
+            if line.Response.ContentLength != int64(tt.wantSize) {
+                t.Errorf("HTTPMiddleware() size = %d, want %d", line.Response.ContentLength, tt.wantSize)
+            }
+            if line.Response.Duration <= 0 {
+                t.Errorf("HTTPMiddleware() duration = %v, want > 0", line.Response.Duration)
+            }
+        })
+    }
+}
+
+func TestHTTPMiddleware_requestBody(t *testing.T) {
+    requestField, _ := NewRequestField("request", RequestFieldSettings{MaxBodySize: 16})
+    formatter, _ := NewFormatter(OutputFormatJSON, []Field{requestField})
+
+    buf := &bytes.Buffer{}
+    logger, _ := NewLoggerWithOptions(WithDestination(buf, formatter), WithAsync(false))
+
+    var read string
+    handler := HTTPMiddleware(logger, WithRequestBody(4))(http.HandlerFunc(
+        func(w http.ResponseWriter, r *http.Request) {
+            body, _ := io.ReadAll(r.Body)
+            read = string(body)
+        },
+    ))
+
+    request := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader("hello"))
+    handler.ServeHTTP(httptest.NewRecorder(), request)
+
+    if read != "hello" {
+        t.Errorf("ReadAll() = %s, want the whole body", read)
+    }
+    if want := `{"request":{"body":"hell","body_truncated":true}}`; buf.String() != want+"\n" {
+        t.Errorf("HTTPMiddleware() logged %s, want %s", buf, want)
+    }
+}
+
+func TestHTTPMiddleware_panic(t *testing.T) {
+    formatter, _ := NewFormatter(OutputFormatText, []Field{NewLevelField(Brackets.None), NewMessageField()})
+
+    buf := &bytes.Buffer{}
+    logger, _ := NewLoggerWithOptions(WithDestination(buf, formatter), WithAsync(false))
+
+    handler := HTTPMiddleware(logger, WithCombinedLog())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        panic(http.ErrAbortHandler)
+    }))
+
+    defer func() {
+        if err, _ := recover().(error); !errors.Is(err, http.ErrAbortHandler) {
+            t.Errorf("ServeHTTP() panic = %v, want http.ErrAbortHandler", err)
+        }
+        if !strings.Contains(buf.String(), `"GET /orders HTTP/1.1" 500 -`) {
+            t.Errorf("HTTPMiddleware() logged %s, want a 500", buf)
+        }
+    }()
+
+    handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders", nil))
+}
+
+func TestHTTPMiddleware_hijackNotSupported(t *testing.T) {
+    w := &accessLogResponseWriter{ResponseWriter: httptest.NewRecorder()}
+
+    if _, _, err := w.Hijack(); !errors.Is(err, http.ErrNotSupported) {
+        t.Errorf("Hijack() error = %v, want http.ErrNotSupported", err)
+    }
+}
+
+func TestCombinedLogLine(t *testing.T) {
+    start := time.Date(2024, 11, 7, 19, 30, 0, 0, time.FixedZone("", -5*60*60))
+
+    request := httptest.NewRequest(http.MethodGet, "/orders?q=%22x%22", nil)
+    request.RemoteAddr = "203.0.113.7:4711"
+    request.SetBasicAuth("john", "secret")
+    request.Header.Set("Referer", "https://example.com/")
+    request.Header.Set("User-Agent", `agent "quoted"`)
+
+    want := `203.0.113.7 - john [07/Nov/2024:19:30:00 -0500] "GET /orders?q=%22x%22 HTTP/1.1" 200 512 ` +
+        `"https://example.com/" "agent \"quoted\""`
+    if got := combinedLogLine(request, http.StatusOK, 512, start); got != want {
+        t.Errorf("combinedLogLine() = %s, want %s", got, want)
+    }
+
+    request = httptest.NewRequest(http.MethodHead, "/", nil)
+    request.RemoteAddr = "@"
+
+    want = `@ - - [07/Nov/2024:19:30:00 -0500] "HEAD / HTTP/1.1" 204 - "-" "-"`
+    if got := combinedLogLine(request, http.StatusNoContent, 0, start); got != want {
+        t.Errorf("combinedLogLine() = %s, want %s", got, want)
+    }
+}
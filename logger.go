@@ -1,6 +1,7 @@
 package ultralogger
 
 import (
+    "context"
     "errors"
     "io"
     "os"
@@ -38,6 +39,24 @@ type Logger interface {
     Silence(enable bool)
 }
 
+// ContextLogger is a Logger that can log with a context. The loggers returned by this package implement it.
+type ContextLogger interface {
+    Logger
+
+    // LogContext logs at the specified level without formatting. The context is made available to fields through
+    // LogLineArgs, which allows fields to render request scoped data such as trace ids.
+    LogContext(ctx context.Context, level Level, data any)
+}
+
+// logContext logs with the context if the logger is a ContextLogger, and without it otherwise.
+func logContext(logger Logger, ctx context.Context, level Level, data any) {
+    if cl, ok := logger.(ContextLogger); ok {
+        cl.LogContext(ctx, level, data)
+        return
+    }
+    logger.Log(level, data)
+}
+
 var defaultDateTimeFormat = "2006-01-02 15:04:05"
 var defaultLevelBracket = Brackets.Angle
 
@@ -149,3 +149,14 @@ func WithAsync(async bool) LoggerOption {
         return nil
     }
 }
+
+// WithCaller enables caller reporting. Default=false.
+//
+// If caller is true, the logger records the file, line and function that emitted each log line in LogLineArgs. This
+// requires walking the stack on every log line, so it is disabled by default.
+func WithCaller(caller bool) LoggerOption {
+    return func(l *ultraLogger) error {
+        l.caller = caller
+        return nil
+    }
+}
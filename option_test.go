@@ -2,9 +2,13 @@ package ultralogger
 
 import (
     "bytes"
+    "context"
     "fmt"
     "io"
     "os"
+    "path/filepath"
+    "strings"
+    "testing"
 )
 
 func ExampleWithMinLevel() {
@@ -249,3 +253,44 @@ func ExampleWithTag() {
     // Output:
     // [TAG] <INFO> This is an info message.
 }
+
+// argsRecorder is a LogLineFormatter that records the LogLineArgs of every line it formats.
+type argsRecorder struct {
+    args []LogLineArgs
+}
+
+func (r *argsRecorder) FormatLogLine(args LogLineArgs, _ any) FormatResult {
+    r.args = append(r.args, args)
+    return FormatResult{}
+}
+
+func TestWithCaller(t *testing.T) {
+    recorder := &argsRecorder{}
+    logger, _ := NewLoggerWithOptions(
+        WithDestination(io.Discard, recorder),
+        WithCaller(true),
+        WithMinLevel(Debug),
+        WithAsync(false),
+    )
+
+    logger.Debug("debug")
+    logger.Info("info")
+    logger.Log(Warn, "warn")
+    logger.(ContextLogger).LogContext(context.Background(), Error, "error")
+
+    if len(recorder.args) != 4 {
+        t.Fatalf("recorded %d lines, want 4", len(recorder.args))
+    }
+
+    for _, args := range recorder.args {
+        if args.Caller == nil {
+            t.Fatalf("Caller = nil, want caller")
+        }
+        if !strings.HasSuffix(args.Caller.Function, ".TestWithCaller") {
+            t.Errorf("Caller.Function = %v, want TestWithCaller", args.Caller.Function)
+        }
+        if filepath.Base(args.Caller.File) != "option_test.go" {
+            t.Errorf("Caller.File = %v, want option_test.go", args.Caller.File)
+        }
+    }
+}
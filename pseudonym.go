@@ -0,0 +1,295 @@
+package ultralogger
+
+import (
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/hex"
+    "fmt"
+    "reflect"
+    "slices"
+    "strings"
+    "sync"
+    "time"
+)
+
+const (
+    // pseudonymSize is the number of bytes of the HMAC-SHA256 that are kept in a pseudonym.
+    pseudonymSize = 16
+    // minPseudonymSecretSize is the smallest secret that a PseudonymKey accepts.
+    minPseudonymSecretSize = 16
+)
+
+// PseudonymKey is a versioned key of a Pseudonymizer. The version is written as the prefix of the pseudonyms, so that
+// pseudonyms stay joinable while the key is current, and can be verified after it is rotated.
+type PseudonymKey struct {
+    // Version identifies the key, e.g. "2024q4". It must not be empty, and must not contain ':'.
+    Version string
+    // Secret is the HMAC key. It must be at least 16 bytes long.
+    Secret []byte
+}
+
+// Pseudonymizer replaces identifiers with deterministic pseudonyms: the truncated HMAC-SHA256 of the identifier under
+// the current key, prefixed with the version of the key, e.g. "2024q4:5f0c2a...". The same identifier has the same
+// pseudonym for as long as the key is current, so that log lines can be correlated without revealing the identifier.
+//
+// A Pseudonymizer is safe for concurrent use, also while its key is rotated.
+type Pseudonymizer struct {
+    mu      sync.RWMutex
+    current string
+    keys    map[string][]byte
+}
+
+// NewPseudonymizer returns a new Pseudonymizer with the current key. An ErrorInvalidPseudonymKey is returned if the key
+// is invalid.
+func NewPseudonymizer(key PseudonymKey) (*Pseudonymizer, error) {
+    p := &Pseudonymizer{keys: map[string][]byte{}}
+    if err := p.Rotate(key); err != nil {
+        return nil, err
+    }
+    return p, nil
+}
+
+// Rotate makes the key the current key. Previous keys are kept to verify the pseudonyms they produced. An
+// ErrorInvalidPseudonymKey is returned if the key is invalid, or if its version was used with another secret.
+func (p *Pseudonymizer) Rotate(key PseudonymKey) error {
+    switch {
+    case key.Version == "":
+        return &ErrorInvalidPseudonymKey{version: key.Version, reason: "version is empty"}
+    case strings.Contains(key.Version, ":"):
+        return &ErrorInvalidPseudonymKey{version: key.Version, reason: "version contains ':'"}
+    case len(key.Secret) < minPseudonymSecretSize:
+        return &ErrorInvalidPseudonymKey{
+            version: key.Version,
+            reason:  fmt.Sprintf("secret is shorter than %d bytes", minPseudonymSecretSize),
+        }
+    }
+
+    p.mu.Lock()
+    defer p.mu.Unlock()
+
+    if secret, ok := p.keys[key.Version]; ok && !hmac.Equal(secret, key.Secret) {
+        return &ErrorInvalidPseudonymKey{version: key.Version, reason: "version is used by another secret"}
+    }
+
+    p.keys[key.Version] = slices.Clone(key.Secret)
+    p.current = key.Version
+    return nil
+}
+
+// Pseudonymize returns the pseudonym of the identifier under the current key.
+func (p *Pseudonymizer) Pseudonymize(id string) string {
+    p.mu.RLock()
+    version, secret := p.current, p.keys[p.current]
+    p.mu.RUnlock()
+
+    return version + ":" + hex.EncodeToString(pseudonymMAC(secret, id))
+}
+
+// Verify reports whether the pseudonym is the pseudonym of the identifier, under the key of the version in its prefix,
+// e.g. to check whether the log lines of a support request concern a user. The key may have been rotated since.
+func (p *Pseudonymizer) Verify(id, pseudonym string) bool {
+    version, encoded, ok := strings.Cut(pseudonym, ":")
+    if !ok {
+        return false
+    }
+
+    mac, err := hex.DecodeString(encoded)
+    if err != nil {
+        return false
+    }
+
+    p.mu.RLock()
+    secret, ok := p.keys[version]
+    p.mu.RUnlock()
+    if !ok {
+        return false
+    }
+
+    return hmac.Equal(mac, pseudonymMAC(secret, id))
+}
+
+func pseudonymMAC(secret []byte, id string) []byte {
+    mac := hmac.New(sha256.New, secret)
+    mac.Write([]byte(id))
+    return mac.Sum(nil)[:pseudonymSize]
+}
+
+// NewPseudonymField returns a Field that formats like the provided field, with its data replaced by pseudonyms.
+//
+// If keys are provided, the values at those keys of the objects in the data, e.g. of a [NewStructField] or a map, are
+// replaced, at any depth. Objects of other types, e.g. structs or typed maps returned by an [ObjectField], are
+// converted into generic objects, with the keys they have in JSON, if any of their values is replaced. For
+// OutputFormatText, the field is formatted as for OutputFormatJSON, so that the keys can be found before the object is
+// rendered as text. If the data of the field is not an object or an array, the FieldFormatter returns an
+// ErrorUnsupportedPseudonymData without data, and the formatters drop the field from the line.
+//
+// Otherwise, the data is replaced if it is a scalar, and every element is replaced if it is an array. Values other than
+// strings are formatted with %v before they are pseudonymized, so that the pseudonym is the same for every output
+// format.
+func NewPseudonymField(field Field, pseudonymizer *Pseudonymizer, keys ...string) Field {
+    return &pseudonymField{Field: field, pseudonymizer: pseudonymizer, keys: keys}
+}
+
+type pseudonymField struct {
+    Field
+    pseudonymizer *Pseudonymizer
+    keys          []string
+}
+
+func (f *pseudonymField) NewFieldFormatter() (FieldFormatter, error) {
+    fieldFormatter, err := f.Field.NewFieldFormatter()
+    if err != nil {
+        return nil, err
+    }
+
+    return func(args LogLineArgs, data any) (FieldResult, error) {
+        if len(f.keys) == 0 {
+            result, err := fieldFormatter(args, data)
+            if err != nil || result.Data == nil {
+                return result, err
+            }
+
+            result.Data = f.pseudonymizeValue(result.Data)
+            return result, nil
+        }
+
+        // Text results are already rendered, so the keys are looked up in the structured result instead.
+        outputFormat := args.OutputFormat
+        if outputFormat == OutputFormatText {
+            args.OutputFormat = OutputFormatJSON
+        }
+
+        result, err := fieldFormatter(args, data)
+        if err != nil || result.Data == nil {
+            return result, err
+        }
+
+        pseudonymized, ok := f.pseudonymizeObject(result.Data)
+        if !ok {
+            // The data is dropped rather than written unpseudonymized. The TextFormatter skips empty strings.
+            dropped := FieldResult{Name: result.Name}
+            if outputFormat == OutputFormatText {
+                dropped.Data = ""
+            }
+            return dropped, &ErrorUnsupportedPseudonymData{dataType: fmt.Sprintf("%T", result.Data)}
+        }
+
+        if obj, isObject := pseudonymized.(structObject); isObject && outputFormat == OutputFormatText {
+            pseudonymized = obj.String()
+        }
+        result.Data = pseudonymized
+        return result, nil
+    }, nil
+}
+
+func (f *pseudonymField) FieldName() string {
+    name, _ := fieldName(f.Field)
+    return name
+}
+
+func (f *pseudonymField) PerLine() bool {
+    perLineField, ok := f.Field.(PerLineField)
+    return ok && perLineField.PerLine()
+}
+
+// pseudonymizeValue replaces the scalar, or every element of the array, with its pseudonym.
+func (f *pseudonymField) pseudonymizeValue(v any) any {
+    switch data := v.(type) {
+    case nil:
+        return nil
+    case string:
+        return f.pseudonymizer.Pseudonymize(data)
+    case []string:
+        values := make([]string, len(data))
+        for i, s := range data {
+            values[i] = f.pseudonymizer.Pseudonymize(s)
+        }
+        return values
+    case []any:
+        values := make([]any, len(data))
+        for i, item := range data {
+            values[i] = f.pseudonymizeValue(item)
+        }
+        return values
+    }
+    return f.pseudonymizer.Pseudonymize(fmt.Sprint(v))
+}
+
+// pseudonymizeObject replaces the values at the keys of the field in the object or array, and returns false if the
+// value is neither.
+func (f *pseudonymField) pseudonymizeObject(v any) (any, bool) {
+    switch v.(type) {
+    case structObject, map[string]any, []any:
+        pseudonymized, _ := f.pseudonymizeKeys(v, 0)
+        return pseudonymized, true
+    }
+
+    switch reflect.ValueOf(v).Kind() {
+    case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array, reflect.Pointer:
+    default:
+        return v, false
+    }
+
+    switch generic := jsonGeneric(v).(type) {
+    case map[string]any, []any:
+        if pseudonymized, replaced := f.pseudonymizeKeys(generic, 0); replaced {
+            return pseudonymized, true
+        }
+        return v, true
+    }
+    return v, false
+}
+
+// pseudonymizeKeys replaces the values at the keys of the field in the objects of the value, and reports whether any
+// value was replaced.
+func (f *pseudonymField) pseudonymizeKeys(v any, depth int) (any, bool) {
+    if depth > maxRedactionDepth {
+        return v, false
+    }
+
+    replaced := false
+    switch data := v.(type) {
+    case structObject:
+        obj := make(structObject, len(data))
+        for i, result := range data {
+            value, ok := f.pseudonymizeKey(result.Name, result.Data, depth)
+            obj[i] = FieldResult{Name: result.Name, Data: value}
+            replaced = replaced || ok
+        }
+        return obj, replaced
+    case map[string]any:
+        m := make(map[string]any, len(data))
+        for key, value := range data {
+            var ok bool
+            m[key], ok = f.pseudonymizeKey(key, value, depth)
+            replaced = replaced || ok
+        }
+        return m, replaced
+    case []any:
+        values := make([]any, len(data))
+        for i, item := range data {
+            var ok bool
+            values[i], ok = f.pseudonymizeKeys(item, depth+1)
+            replaced = replaced || ok
+        }
+        return values, replaced
+    case string, time.Time, time.Duration:
+        return v, false
+    }
+
+    // Other objects are replaced by a generic copy, with their JSON keys, if any of their values is replaced.
+    switch reflect.ValueOf(v).Kind() {
+    case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array, reflect.Pointer:
+        if pseudonymized, replaced := f.pseudonymizeKeys(jsonGeneric(v), depth); replaced {
+            return pseudonymized, true
+        }
+    }
+    return v, false
+}
+
+func (f *pseudonymField) pseudonymizeKey(key string, v any, depth int) (any, bool) {
+    if slices.Contains(f.keys, key) {
+        return f.pseudonymizeValue(v), v != nil
+    }
+    return f.pseudonymizeKeys(v, depth+1)
+}
@@ -0,0 +1,302 @@
+package ultralogger
+
+import (
+    "errors"
+    "fmt"
+    "strings"
+    "testing"
+)
+
+var (
+    testPseudonymKey1 = PseudonymKey{Version: "v1", Secret: []byte("0123456789abcdef")}
+    testPseudonymKey2 = PseudonymKey{Version: "v2", Secret: []byte("fedcba9876543210")}
+)
+
+func ExamplePseudonymizer_Verify() {
+    pseudonymizer, _ := NewPseudonymizer(testPseudonymKey1)
+
+    pseudonym := pseudonymizer.Pseudonymize("user-42")
+
+    _ = pseudonymizer.Rotate(testPseudonymKey2)
+
+    fmt.Println(strings.HasPrefix(pseudonym, "v1:"))
+    fmt.Println(pseudonymizer.Verify("user-42", pseudonym))
+    fmt.Println(pseudonymizer.Verify("user-43", pseudonym))
+    // Output:
+    // true
+    // true
+    // false
+}
+
+func TestPseudonymizer(t *testing.T) {
+    pseudonymizer, err := NewPseudonymizer(testPseudonymKey1)
+    if err != nil {
+        t.Fatalf("NewPseudonymizer() error = %v", err)
+    }
+
+    v1 := pseudonymizer.Pseudonymize("user-42")
+    if v1 != pseudonymizer.Pseudonymize("user-42") {
+        t.Errorf("Pseudonymize() is not deterministic")
+    }
+    if v1 == pseudonymizer.Pseudonymize("user-43") {
+        t.Errorf("Pseudonymize() = %s for different identifiers", v1)
+    }
+    if _, mac, _ := strings.Cut(v1, ":"); len(mac) != 2*pseudonymSize {
+        t.Errorf("Pseudonymize() = %s, want %d hex characters after the version", v1, 2*pseudonymSize)
+    }
+
+    if err := pseudonymizer.Rotate(testPseudonymKey2); err != nil {
+        t.Fatalf("Rotate() error = %v", err)
+    }
+
+    v2 := pseudonymizer.Pseudonymize("user-42")
+    if !strings.HasPrefix(v2, "v2:") || v2[3:] == v1[3:] {
+        t.Errorf("Pseudonymize() = %s after rotation, want a new v2 pseudonym", v2)
+    }
+
+    tests := []struct {
+        name      string
+        id        string
+        pseudonym string
+        want      bool
+    }{
+        {name: "Current key", id: "user-42", pseudonym: v2, want: true},
+        {name: "Rotated key", id: "user-42", pseudonym: v1, want: true},
+        {name: "Other identifier", id: "user-43", pseudonym: v2, want: false},
+        {name: "Unknown version", id: "user-42", pseudonym: "v3" + v2[2:], want: false},
+        {name: "Version of another key", id: "user-42", pseudonym: "v1" + v2[2:], want: false},
+        {name: "Truncated", id: "user-42", pseudonym: v2[:len(v2)-2], want: false},
+        {name: "Not hex", id: "user-42", pseudonym: "v2:zz", want: false},
+        {name: "No version", id: "user-42", pseudonym: v2[3:], want: false},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := pseudonymizer.Verify(tt.id, tt.pseudonym); got != tt.want {
+                t.Errorf("Verify() = %v, want %v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestPseudonymizer_Rotate(t *testing.T) {
+    pseudonymizer, _ := NewPseudonymizer(testPseudonymKey1)
+
+    tests := []struct {
+        name string
+        key  PseudonymKey
+    }{
+        {name: "Empty version", key: PseudonymKey{Secret: testPseudonymKey1.Secret}},
+        {name: "Version with separator", key: PseudonymKey{Version: "v:2", Secret: testPseudonymKey1.Secret}},
+        {name: "Short secret", key: PseudonymKey{Version: "v2", Secret: []byte("short")}},
+        {name: "Reused version", key: PseudonymKey{Version: "v1", Secret: testPseudonymKey2.Secret}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            var invalidKey *ErrorInvalidPseudonymKey
+            if err := pseudonymizer.Rotate(tt.key); !errors.As(err, &invalidKey) {
+                t.Errorf("Rotate() error = %v, want ErrorInvalidPseudonymKey", err)
+            }
+        })
+    }
+
+    if err := pseudonymizer.Rotate(testPseudonymKey1); err != nil {
+        t.Errorf("Rotate() error = %v, want nil for the same key", err)
+    }
+}
+
+func TestPseudonymField(t *testing.T) {
+    type user struct {
+        ID    string `ulog:"id"`
+        Name  string `ulog:"name"`
+        Email string `ulog:"email"`
+    }
+    type account struct {
+        Owner string `json:"owner"`
+        Plan  string `json:"plan"`
+    }
+
+    pseudonymizer, _ := NewPseudonymizer(testPseudonymKey1)
+    p := pseudonymizer.Pseudonymize
+
+    idField, _ := NewIntField("user_id")
+    idsField, _ := NewArrayField[string]("user_ids", func(args LogLineArgs, data string) any {
+        return data
+    })
+    userField, _ := NewStructField[user]("user")
+    accountField, _ := NewObjectField[account]("account", func(args LogLineArgs, data account) any {
+        return data
+    })
+    labelsField, _ := NewObjectField[map[string]string]("labels", func(args LogLineArgs, data map[string]string) any {
+        return data
+    })
+    nameField, _ := NewStringField("name")
+
+    tests := []struct {
+        name         string
+        field        Field
+        outputFormat OutputFormat
+        data         any
+        want         any
+    }{
+        {
+            name:         "JSON",
+            field:        NewPseudonymField(idField, pseudonymizer),
+            outputFormat: OutputFormatJSON,
+            data:         42,
+            want:         p("42"),
+        },
+        {
+            name:         "Text",
+            field:        NewPseudonymField(idField, pseudonymizer),
+            outputFormat: OutputFormatText,
+            data:         42,
+            want:         p("42"),
+        },
+        {
+            name:         "Array",
+            field:        NewPseudonymField(idsField, pseudonymizer),
+            outputFormat: OutputFormatJSON,
+            data:         []string{"a", "b"},
+            want:         fmt.Sprint([]any{p("a"), p("b")}),
+        },
+        {
+            name:         "Keys",
+            field:        NewPseudonymField(userField, pseudonymizer, "id", "email"),
+            outputFormat: OutputFormatJSON,
+            data:         user{ID: "42", Name: "john", Email: "john@example.com"},
+            want:         fmt.Sprintf("{id=%s name=john email=%s}", p("42"), p("john@example.com")),
+        },
+        {
+            name:         "Keys text",
+            field:        NewPseudonymField(userField, pseudonymizer, "id", "email"),
+            outputFormat: OutputFormatText,
+            data:         user{ID: "42", Name: "john", Email: "john@example.com"},
+            want:         fmt.Sprintf("{id=%s name=john email=%s}", p("42"), p("john@example.com")),
+        },
+        {
+            name:         "Keys in struct",
+            field:        NewPseudonymField(accountField, pseudonymizer, "owner"),
+            outputFormat: OutputFormatJSON,
+            data:         account{Owner: "john", Plan: "pro"},
+            want:         fmt.Sprint(map[string]any{"owner": p("john"), "plan": "pro"}),
+        },
+        {
+            name:         "Keys in typed map",
+            field:        NewPseudonymField(labelsField, pseudonymizer, "owner"),
+            outputFormat: OutputFormatText,
+            data:         map[string]string{"owner": "john", "plan": "pro"},
+            want:         fmt.Sprint(map[string]any{"owner": p("john"), "plan": "pro"}),
+        },
+        {
+            name:         "Keys unchanged",
+            field:        NewPseudonymField(accountField, pseudonymizer, "email"),
+            outputFormat: OutputFormatJSON,
+            data:         account{Owner: "john", Plan: "pro"},
+            want:         fmt.Sprint(account{Owner: "john", Plan: "pro"}),
+        },
+        {
+            name:         "Keys in scalar",
+            field:        NewPseudonymField(nameField, pseudonymizer, "name"),
+            outputFormat: OutputFormatJSON,
+            data:         "john",
+        },
+        {name: "Mismatch", field: NewPseudonymField(idField, pseudonymizer), outputFormat: OutputFormatJSON, data: "x"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            fieldFormatter, err := tt.field.NewFieldFormatter()
+            if err != nil {
+                t.Fatalf("NewFieldFormatter() error = %v", err)
+            }
+
+            result, err := fieldFormatter(LogLineArgs{OutputFormat: tt.outputFormat}, tt.data)
+            if tt.want == nil {
+                if err == nil {
+                    t.Errorf("FieldFormatter() = %v, want error", result.Data)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("FieldFormatter() error = %v", err)
+            }
+
+            if got := fmt.Sprint(result.Data); got != tt.want {
+                t.Errorf("FieldFormatter() = %v, want %v", got, tt.want)
+            }
+        })
+    }
+
+    if name, _ := fieldName(NewPseudonymField(idField, pseudonymizer)); name != "user_id" {
+        t.Errorf("fieldName() = %s, want user_id", name)
+    }
+}
+
+func TestPseudonymField_largeInteger(t *testing.T) {
+    type order struct {
+        UserID int64  `json:"user_id" ulog:"user_id"`
+        Item   string `json:"item" ulog:"item"`
+    }
+
+    pseudonymizer, _ := NewPseudonymizer(testPseudonymKey1)
+
+    objectField, _ := NewObjectField[order]("order", func(args LogLineArgs, data order) any {
+        return data
+    })
+    structField, _ := NewStructField[order]("order")
+
+    data := order{UserID: 1234567890123456789, Item: "book"}
+
+    var pseudonyms []any
+    for _, field := range []Field{objectField, structField} {
+        fieldFormatter, _ := NewPseudonymField(field, pseudonymizer, "user_id").NewFieldFormatter()
+
+        result, err := fieldFormatter(LogLineArgs{OutputFormat: OutputFormatJSON}, data)
+        if err != nil {
+            t.Fatalf("FieldFormatter() error = %v", err)
+        }
+
+        switch obj := result.Data.(type) {
+        case map[string]any:
+            pseudonyms = append(pseudonyms, obj["user_id"])
+        case structObject:
+            pseudonyms = append(pseudonyms, obj[0].Data)
+        default:
+            t.Fatalf("FieldFormatter() = %T, want an object", result.Data)
+        }
+    }
+
+    if pseudonyms[0] != pseudonyms[1] {
+        t.Errorf("FieldFormatter() = %v for a typed object and %v for a struct field", pseudonyms[0], pseudonyms[1])
+    }
+    if pseudonym, _ := pseudonyms[0].(string); !pseudonymizer.Verify("1234567890123456789", pseudonym) {
+        t.Errorf("Verify() = false for %v, want the pseudonym of the exact ID", pseudonyms[0])
+    }
+}
+
+func TestPseudonymField_unsupportedData(t *testing.T) {
+    pseudonymizer, _ := NewPseudonymizer(testPseudonymKey1)
+    nameField, _ := NewStringField("name")
+    fields := []Field{NewMessageField(), NewPseudonymField(nameField, pseudonymizer, "name")}
+
+    tests := []struct {
+        outputFormat OutputFormat
+        want         string
+    }{
+        {outputFormat: OutputFormatJSON, want: `{"message":"john"}`},
+        {outputFormat: OutputFormatText, want: `john`},
+    }
+    for _, tt := range tests {
+        t.Run(string(tt.outputFormat), func(t *testing.T) {
+            f, _ := NewFormatter(tt.outputFormat, fields)
+
+            res := f.FormatLogLine(LogLineArgs{Level: Info}, "john")
+            if res.err != nil {
+                t.Fatalf("FormatLogLine() error = %v", res.err)
+            }
+
+            if string(res.bytes) != tt.want {
+                t.Errorf("FormatLogLine() = %s, want %s", res.bytes, tt.want)
+            }
+        })
+    }
+}
@@ -0,0 +1,416 @@
+package ultralogger
+
+import (
+    "fmt"
+    "reflect"
+    "regexp"
+    "strconv"
+    "strings"
+    "time"
+    "unicode/utf8"
+)
+
+const (
+    // maxRedactionDepth is the deepest level of nested values that the redaction rules are applied to.
+    maxRedactionDepth = 32
+    // redactionKeep is the number of trailing characters that RedactPartial keeps.
+    redactionKeep = 4
+)
+
+// RedactAction defines what a RedactionRule does with the values it matches.
+type RedactAction int
+
+const (
+    // RedactMask replaces the value with "[REDACTED]". For Pattern rules, only the matched text is replaced.
+    RedactMask RedactAction = iota
+    // RedactPartial replaces every character of the value with '*', except for the last 4, e.g. "************1111".
+    // Values of 4 characters or fewer are masked entirely. For Pattern rules, only the matched text is masked.
+    RedactPartial
+    // RedactDrop removes the value: the field, the key of the object, or the element of the array.
+    RedactDrop
+)
+
+// RedactionRule is a rule of a [Redactor]. Exactly one of Name, Path and Pattern must be set.
+type RedactionRule struct {
+    // Name matches field results, and keys of nested objects, with the name, ignoring case, e.g. "password".
+    Name string
+    // Path matches the value at the dotted path into a field result, ignoring case, e.g. "user.email" matches the
+    // "email" key of the "user" field. Arrays are passed through, so "users.email" matches the "email" key of every
+    // element of the "users" field.
+    Path string
+    // Pattern matches string values, at any depth, that contain a match of the regular expression.
+    Pattern *regexp.Regexp
+    // Action is what is done with the matched values.
+    Action RedactAction
+}
+
+// Redactor redacts the field results of a formatter, according to its rules. Attach it to a formatter with
+// [WithRedactor].
+//
+// Rules are applied to the results of the fields, and to the values nested in them: objects of [NewStructField],
+// maps, slices and structs. Structs of other types that contain redacted values are written as generic objects, with
+// the keys they have in JSON. When several rules match a value, the first one is applied.
+//
+// For OutputFormatText, most fields format their data as a string, so only Name rules for the fields themselves and
+// Pattern rules apply.
+type Redactor struct {
+    rules []RedactionRule
+}
+
+// NewRedactor returns a new Redactor with the rules. An ErrorInvalidRedactionRule is returned if a rule doesn't set
+// exactly one of Name, Path and Pattern, or has an unknown Action.
+func NewRedactor(rules ...RedactionRule) (*Redactor, error) {
+    for i, rule := range rules {
+        set := 0
+        for _, ok := range []bool{rule.Name != "", rule.Path != "", rule.Pattern != nil} {
+            if ok {
+                set++
+            }
+        }
+        if set != 1 {
+            return nil, &ErrorInvalidRedactionRule{
+                index:  i,
+                reason: "exactly one of Name, Path and Pattern must be set",
+            }
+        }
+        if rule.Action < RedactMask || rule.Action > RedactDrop {
+            return nil, &ErrorInvalidRedactionRule{index: i, reason: fmt.Sprintf("unknown action %d", rule.Action)}
+        }
+    }
+
+    return &Redactor{rules: rules}, nil
+}
+
+// RedactingFormatter applies the rules of a Redactor to the field results of the base formatter. It works with the
+// built-in formatters, which format their fields through the redaction of the line.
+type RedactingFormatter struct {
+    BaseFormatter LogLineFormatter
+    Redactor      *Redactor
+}
+
+// NewRedactingFormatter returns a new RedactingFormatter that redacts the field results of the base formatter.
+func NewRedactingFormatter(baseFormatter LogLineFormatter, redactor *Redactor) *RedactingFormatter {
+    return &RedactingFormatter{
+        BaseFormatter: baseFormatter,
+        Redactor:      redactor,
+    }
+}
+
+// FormatLogLine formats the log line using the provided data and returns a FormatResult which contains the formatted
+// log line and any errors that may have occurred.
+func (f *RedactingFormatter) FormatLogLine(args LogLineArgs, data any) FormatResult {
+    if f.Redactor != nil {
+        args.redaction = &lineRedaction{redactor: f.Redactor}
+    }
+    return f.BaseFormatter.FormatLogLine(args, data)
+}
+
+func (f *RedactingFormatter) framed() bool {
+    return isFramed(f.BaseFormatter)
+}
+
+// WithRedactor redacts the field results of the formatter with the redactor. Add a [NewRedactionCountField] to the
+// fields of the formatter to record how many redactions were applied to every line.
+func WithRedactor(redactor *Redactor) FormatterOption {
+    return func(f LogLineFormatter) LogLineFormatter {
+        return NewRedactingFormatter(f, redactor)
+    }
+}
+
+// NewRedactionCountField returns a new Field that formats the number of redactions that were applied to the log line,
+// for auditing. The field counts the redactions of every other field of the line, wherever it is declared. It has no
+// data if the formatter has no Redactor.
+//
+// OutputFormats:
+//  - OutputFormatText => count is formatted as a string with strconv.Itoa().
+//  - OutputFormatJSON => count is formatted as an int.
+func NewRedactionCountField(name string) (Field, error) {
+    if name == "" {
+        return &redactionCountField{}, ErrorEmptyFieldName
+    }
+    return &redactionCountField{name: name}, nil
+}
+
+type redactionCountField struct {
+    name string
+}
+
+func (f *redactionCountField) NewFieldFormatter() (FieldFormatter, error) {
+    return f.format, nil
+}
+
+func (f *redactionCountField) FieldName() string {
+    return f.name
+}
+
+func (f *redactionCountField) format(args LogLineArgs, _ any) (FieldResult, error) {
+    result := FieldResult{
+        Name: f.name,
+    }
+
+    if args.redaction == nil {
+        return result, nil
+    }
+
+    if args.OutputFormat == OutputFormatText {
+        result.Data = strconv.Itoa(args.redaction.count)
+    } else {
+        result.Data = args.redaction.count
+    }
+    return result, nil
+}
+
+// lineRedaction applies the rules of a Redactor to the field results of a single log line, and counts the redactions.
+type lineRedaction struct {
+    redactor *Redactor
+    count    int
+
+    // aheadPlan and ahead are the redacted results of the fields that were formatted ahead of a redaction count field
+    // of the plan, by index, so that the count covers every field of the line whatever its position.
+    aheadPlan *fieldPlan
+    ahead     map[int][]FieldResult
+}
+
+// resultsAhead returns the results of the i-th field of the plan if they were formatted ahead, or nil if the field must
+// be formatted now. If the field is a redaction count field, the fields that follow it are formatted ahead with format
+// first, so that their redactions are counted. A nil lineRedaction returns nil.
+func (r *lineRedaction) resultsAhead(
+    p *fieldPlan,
+    i int,
+    format func(j int) ([]FieldResult, error),
+) ([]FieldResult, error) {
+    if r == nil {
+        return nil, nil
+    }
+
+    if r.aheadPlan == p {
+        if results, ok := r.ahead[i]; ok {
+            delete(r.ahead, i)
+            return results, nil
+        }
+    }
+
+    if _, ok := p.fields[i].(*redactionCountField); !ok || r.aheadPlan == p {
+        return nil, nil
+    }
+
+    r.aheadPlan = p
+    r.ahead = map[int][]FieldResult{}
+    for j := i + 1; j < len(p.fields); j++ {
+        if _, ok := p.fields[j].(*redactionCountField); ok {
+            continue
+        }
+
+        results, err := format(j)
+        if err != nil {
+            return nil, err
+        }
+        if results == nil {
+            results = []FieldResult{}
+        }
+        r.ahead[j] = results
+    }
+    return nil, nil
+}
+
+// redactResult redacts the field result, and returns nil if it is dropped. A nil lineRedaction returns the result as
+// is.
+func (r *lineRedaction) redactResult(result *FieldResult) *FieldResult {
+    if r == nil || result == nil || result.Data == nil {
+        return result
+    }
+
+    data, keep := r.redact(result.Name, result.Name, result.Data, 0)
+    if !keep {
+        return nil
+    }
+    return &FieldResult{Name: result.Name, Data: data}
+}
+
+// redactResults redacts the results from the index on, and removes the ones that are dropped.
+func (r *lineRedaction) redactResults(results []FieldResult, from int) []FieldResult {
+    if r == nil {
+        return results
+    }
+
+    kept := results[:from]
+    for i := from; i < len(results); i++ {
+        if redacted := r.redactResult(&results[i]); redacted != nil {
+            kept = append(kept, *redacted)
+        }
+    }
+    return kept
+}
+
+// redact returns the redacted value at the path, with the name of its key, and false if it is dropped. Elements of
+// arrays have an empty name.
+func (r *lineRedaction) redact(path, name string, v any, depth int) (any, bool) {
+    if v == nil || depth > maxRedactionDepth {
+        return v, true
+    }
+
+    for _, rule := range r.redactor.rules {
+        switch {
+        case rule.Name != "" && name != "" && strings.EqualFold(rule.Name, name),
+            rule.Path != "" && name != "" && strings.EqualFold(rule.Path, path):
+            r.count++
+            return redactValue(v, rule.Action)
+        }
+    }
+
+    switch data := v.(type) {
+    case string:
+        return r.redactString(data)
+    case structObject:
+        return r.redactObject(path, data, depth)
+    case logObjectMarshaler:
+        return r.redactCopy(v, func() (any, bool) { return r.redactObject(path, data.logObject(), depth) })
+    case map[string]any:
+        return r.redactMap(path, data, depth)
+    case []any:
+        return r.redactArray(path, data, depth)
+    case []string:
+        values := make([]any, len(data))
+        for i, s := range data {
+            values[i] = s
+        }
+        return r.redactCopy(v, func() (any, bool) { return r.redactArray(path, values, depth) })
+    case ErrorLogEntry:
+        return r.redactError(path, data, depth)
+    case time.Time, time.Duration:
+        return v, true
+    }
+
+    switch reflect.ValueOf(v).Kind() {
+    case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array, reflect.Pointer:
+        return r.redactCopy(v, func() (any, bool) { return r.redact(path, name, jsonGeneric(v), depth) })
+    }
+
+    return v, true
+}
+
+// redactCopy redacts a generic copy of the value, and returns the value as is if nothing in it was redacted.
+func (r *lineRedaction) redactCopy(v any, redact func() (any, bool)) (any, bool) {
+    count := r.count
+
+    redacted, keep := redact()
+    if r.count == count {
+        return v, true
+    }
+    return redacted, keep
+}
+
+func (r *lineRedaction) redactString(s string) (any, bool) {
+    for _, rule := range r.redactor.rules {
+        if rule.Pattern == nil {
+            continue
+        }
+
+        switch rule.Action {
+        case RedactDrop:
+            if rule.Pattern.MatchString(s) {
+                r.count++
+                return nil, false
+            }
+        default:
+            s = rule.Pattern.ReplaceAllStringFunc(s, func(match string) string {
+                r.count++
+                redacted, _ := redactValue(match, rule.Action)
+                return redacted.(string)
+            })
+        }
+    }
+    return s, true
+}
+
+func (r *lineRedaction) redactObject(path string, obj structObject, depth int) (any, bool) {
+    redacted := make(structObject, 0, len(obj))
+    for _, result := range obj {
+        if data, keep := r.redact(path+"."+result.Name, result.Name, result.Data, depth+1); keep {
+            redacted = append(redacted, FieldResult{Name: result.Name, Data: data})
+        }
+    }
+    return redacted, true
+}
+
+// redactError redacts the message, the chain, the joined errors and the fields of the entry, with the keys they have
+// in JSON. The type of the entry, and of the errors of its chain, are kept.
+func (r *lineRedaction) redactError(path string, entry ErrorLogEntry, depth int) (any, bool) {
+    redacted := entry
+
+    message, keep := r.redact(path+".message", "message", entry.Message, depth+1)
+    redacted.Message = ""
+    if keep {
+        redacted.Message, _ = message.(string)
+    }
+
+    redacted.Chain = r.redactErrors(path+".chain", entry.Chain, depth+1)
+    redacted.Errors = r.redactErrors(path+".errors", entry.Errors, depth+1)
+
+    if entry.Fields != nil {
+        fields, _ := r.redactMap(path+".fields", entry.Fields, depth+1)
+        redacted.Fields = fields.(map[string]any)
+    }
+    return redacted, true
+}
+
+func (r *lineRedaction) redactErrors(path string, entries []ErrorLogEntry, depth int) []ErrorLogEntry {
+    if entries == nil {
+        return nil
+    }
+
+    redacted := make([]ErrorLogEntry, 0, len(entries))
+    for _, entry := range entries {
+        if data, keep := r.redact(path, "", entry, depth+1); keep {
+            redacted = append(redacted, data.(ErrorLogEntry))
+        }
+    }
+    return redacted
+}
+
+func (r *lineRedaction) redactMap(path string, m map[string]any, depth int) (any, bool) {
+    redacted := make(map[string]any, len(m))
+    for key, value := range m {
+        if data, keep := r.redact(path+"."+key, key, value, depth+1); keep {
+            redacted[key] = data
+        }
+    }
+    return redacted, true
+}
+
+func (r *lineRedaction) redactArray(path string, values []any, depth int) (any, bool) {
+    redacted := make([]any, 0, len(values))
+    for _, value := range values {
+        if data, keep := r.redact(path, "", value, depth+1); keep {
+            redacted = append(redacted, data)
+        }
+    }
+    return redacted, true
+}
+
+// redactValue applies the action to the value, and returns false if it is dropped.
+func redactValue(v any, action RedactAction) (any, bool) {
+    switch action {
+    case RedactDrop:
+        return nil, false
+    case RedactPartial:
+        s, ok := v.(string)
+        if !ok {
+            s = fmt.Sprint(v)
+        }
+
+        n := utf8.RuneCountInString(s)
+        if n <= redactionKeep {
+            return strings.Repeat("*", n), true
+        }
+
+        keepFrom := len(s)
+        for range redactionKeep {
+            _, size := utf8.DecodeLastRuneInString(s[:keepFrom])
+            keepFrom -= size
+        }
+        return strings.Repeat("*", n-redactionKeep) + s[keepFrom:], true
+    default:
+        return redactedPlaceholder, true
+    }
+}
@@ -0,0 +1,275 @@
+package ultralogger
+
+import (
+    "bytes"
+    "errors"
+    "fmt"
+    "os"
+    "regexp"
+    "testing"
+)
+
+func ExampleWithRedactor() {
+    type User struct {
+        Name  string `ulog:"name"`
+        Email string `ulog:"email"`
+        Card  string `ulog:"card"`
+    }
+
+    userField, _ := NewStructField[User]("user")
+    redactionsField, _ := NewRedactionCountField("redactions")
+
+    redactor, _ := NewRedactor(
+        RedactionRule{Path: "user.card", Action: RedactPartial},
+        RedactionRule{Pattern: regexp.MustCompile(`[\w.]+@[\w.]+`), Action: RedactMask},
+    )
+
+    formatter, _ := NewFormatter(OutputFormatJSON, []Field{userField, redactionsField}, WithRedactor(redactor))
+
+    logger, _ := NewLoggerWithOptions(WithDestination(os.Stdout, formatter), WithAsync(false))
+
+    logger.Info(User{Name: "john", Email: "john@example.com", Card: "4111111111111111"})
+    // Output: {"user":{"name":"john","email":"[REDACTED]","card":"************1111"},"redactions":2}
+}
+
+func TestRedactingFormatter(t *testing.T) {
+    type credentials struct {
+        User     string `json:"user"`
+        Password string `json:"password"`
+    }
+    type account struct {
+        ID    int64   `json:"id"`
+        Email string  `json:"email"`
+        Score float64 `json:"score"`
+    }
+
+    tokenField, _ := NewStringField("token")
+    messageField := NewMessageField()
+    mapField, _ := NewObjectField[map[string]any]("request", func(args LogLineArgs, data map[string]any) any {
+        return data
+    })
+    listField, _ := NewObjectField[[]any]("users", func(args LogLineArgs, data []any) any {
+        return data
+    })
+    credentialsField, _ := NewObjectField[credentials]("login", func(args LogLineArgs, data credentials) any {
+        return data
+    })
+    accountField, _ := NewObjectField[account]("account", func(args LogLineArgs, data account) any {
+        return data
+    })
+    errField, _ := NewErrorField("error")
+    redactionsField, _ := NewRedactionCountField("redactions")
+
+    email := regexp.MustCompile(`[\w.]+@[\w.]+`)
+
+    tests := []struct {
+        name   string
+        rules  []RedactionRule
+        fields []Field
+        data   any
+        want   string
+    }{
+        {
+            name:   "Name mask",
+            rules:  []RedactionRule{{Name: "TOKEN", Action: RedactMask}},
+            fields: []Field{tokenField, messageField, redactionsField},
+            data:   "abc",
+            want:   `{"message":"abc","token":"[REDACTED]","redactions":1}`,
+        },
+        {
+            name:   "Name drop",
+            rules:  []RedactionRule{{Name: "token", Action: RedactDrop}},
+            fields: []Field{tokenField, messageField, redactionsField},
+            data:   "abc",
+            want:   `{"message":"abc","redactions":1}`,
+        },
+        {
+            name:   "Nested name",
+            rules:  []RedactionRule{{Name: "authorization", Action: RedactMask}},
+            fields: []Field{mapField, redactionsField},
+            data:   map[string]any{"headers": map[string]any{"Authorization": "Bearer x", "Accept": "*/*"}},
+            want:   `{"request":{"headers":{"Accept":"*/*","Authorization":"[REDACTED]"}},"redactions":1}`,
+        },
+        {
+            name:   "Path",
+            rules:  []RedactionRule{{Path: "request.headers.accept", Action: RedactDrop}},
+            fields: []Field{mapField, redactionsField},
+            data:   map[string]any{"headers": map[string]any{"Accept": "*/*"}, "accept": "*/*"},
+            want:   `{"request":{"accept":"*/*","headers":{}},"redactions":1}`,
+        },
+        {
+            name:   "Path through arrays",
+            rules:  []RedactionRule{{Path: "users.card", Action: RedactPartial}},
+            fields: []Field{listField, redactionsField},
+            data:   []any{map[string]any{"card": "4111111111111111"}, map[string]any{"card": "123"}},
+            want:   `{"users":[{"card":"************1111"},{"card":"***"}],"redactions":2}`,
+        },
+        {
+            name:   "Pattern",
+            rules:  []RedactionRule{{Pattern: email, Action: RedactMask}},
+            fields: []Field{messageField, redactionsField},
+            data:   "mail a@example.com and b@example.com",
+            want:   `{"message":"mail [REDACTED] and [REDACTED]","redactions":2}`,
+        },
+        {
+            name:   "Pattern drop from array",
+            rules:  []RedactionRule{{Pattern: email, Action: RedactDrop}},
+            fields: []Field{listField, redactionsField},
+            data:   []any{"a@example.com", "john"},
+            want:   `{"users":["john"],"redactions":1}`,
+        },
+        {
+            name:   "Struct",
+            rules:  []RedactionRule{{Name: "password", Action: RedactMask}},
+            fields: []Field{credentialsField, redactionsField},
+            data:   credentials{User: "john", Password: "hunter2"},
+            want:   `{"login":{"password":"[REDACTED]","user":"john"},"redactions":1}`,
+        },
+        {
+            name:   "Struct large integer",
+            rules:  []RedactionRule{{Name: "email", Action: RedactMask}},
+            fields: []Field{accountField},
+            data:   account{ID: 1234567890123456789, Email: "john@example.com", Score: 0.1},
+            want:   `{"account":{"email":"[REDACTED]","id":1234567890123456789,"score":0.1}}`,
+        },
+        {
+            name:   "Error",
+            rules:  []RedactionRule{{Pattern: email, Action: RedactMask}},
+            fields: []Field{errField, redactionsField},
+            data:   fmt.Errorf("lookup: %w", errors.New("user john@example.com not found")),
+            want: `{"error":{"message":"lookup: user [REDACTED] not found","type":"*fmt.wrapError",` +
+                `"chain":[{"message":"user [REDACTED] not found","type":"*errors.errorString"}]},"redactions":2}`,
+        },
+        {
+            name:   "Error fields",
+            rules:  []RedactionRule{{Path: "error.fields.status", Action: RedactMask}},
+            fields: []Field{errField, redactionsField},
+            data:   statusError{status: 503},
+            want: `{"error":{"message":"status 503","type":"ultralogger.statusError",` +
+                `"fields":{"status":"[REDACTED]"}},"redactions":1}`,
+        },
+        {
+            name:   "Count declared first",
+            rules:  []RedactionRule{{Name: "token", Action: RedactMask}},
+            fields: []Field{redactionsField, tokenField, messageField},
+            data:   "abc",
+            want:   `{"message":"abc","redactions":1,"token":"[REDACTED]"}`,
+        },
+        {
+            name:   "Struct unchanged",
+            rules:  []RedactionRule{{Name: "token", Action: RedactMask}},
+            fields: []Field{credentialsField, redactionsField},
+            data:   credentials{User: "john", Password: "hunter2"},
+            want:   `{"login":{"user":"john","password":"hunter2"},"redactions":0}`,
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            redactor, err := NewRedactor(tt.rules...)
+            if err != nil {
+                t.Fatalf("NewRedactor() error = %v", err)
+            }
+
+            f, _ := NewFormatter(OutputFormatJSON, tt.fields, WithRedactor(redactor))
+
+            res := f.FormatLogLine(LogLineArgs{Level: Info}, tt.data)
+            if res.err != nil {
+                t.Fatalf("FormatLogLine() error = %v", res.err)
+            }
+
+            if string(res.bytes) != tt.want {
+                t.Errorf("FormatLogLine() = %s, want %s", res.bytes, tt.want)
+            }
+        })
+    }
+}
+
+func TestRedactingFormatter_formatters(t *testing.T) {
+    tokenField, _ := NewStringField("token")
+    redactionsField, _ := NewRedactionCountField("redactions")
+    redactor, _ := NewRedactor(RedactionRule{Name: "token", Action: RedactPartial})
+
+    t.Run("Text", func(t *testing.T) {
+        f, _ := NewFormatter(
+            OutputFormatText,
+            []Field{redactionsField, tokenField},
+            WithRedactor(redactor),
+            WithKeyValue(),
+        )
+
+        res := f.FormatLogLine(LogLineArgs{Level: Info}, "secret-token")
+        if want := "redactions=1 token=********oken"; string(res.bytes) != want {
+            t.Errorf("FormatLogLine() = %s, want %s", res.bytes, want)
+        }
+    })
+
+    t.Run("ECS", func(t *testing.T) {
+        ecsFormatter, _ := NewECSFormatter([]Field{redactionsField, tokenField})
+        f := NewRedactingFormatter(ecsFormatter, redactor)
+
+        res := f.FormatLogLine(LogLineArgs{Level: Info}, "secret-token")
+        if !bytes.Contains(res.bytes, []byte(`"token":"********oken"`)) {
+            t.Errorf("FormatLogLine() = %s, want the token redacted", res.bytes)
+        }
+        if !bytes.Contains(res.bytes, []byte(`"redactions":1`)) {
+            t.Errorf("FormatLogLine() = %s, want the redaction counted", res.bytes)
+        }
+    })
+
+    t.Run("Pattern", func(t *testing.T) {
+        patternFormatter, _ := NewPatternFormatter("%redactions %token", []Field{tokenField, redactionsField})
+        f := NewRedactingFormatter(patternFormatter, redactor)
+
+        res := f.FormatLogLine(LogLineArgs{Level: Info}, "secret-token")
+        if want := "1 ********oken"; string(res.bytes) != want {
+            t.Errorf("FormatLogLine() = %s, want %s", res.bytes, want)
+        }
+    })
+
+    t.Run("CBOR", func(t *testing.T) {
+        f, _ := NewFormatter(OutputFormatCBOR, []Field{tokenField}, WithRedactor(redactor))
+
+        buf := &bytes.Buffer{}
+        logger, _ := NewLoggerWithOptions(WithDestination(buf, f), WithAsync(false))
+        logger.Info("secret-token")
+
+        line, err := NewCBORDecoder(buf).Decode()
+        if err != nil {
+            t.Fatalf("Decode() error = %v", err)
+        }
+        if line["token"] != "********oken" {
+            t.Errorf("Decode() = %v, want the token redacted", line)
+        }
+        if buf.Len() != 0 {
+            t.Errorf("the line is followed by %d bytes, want none", buf.Len())
+        }
+    })
+
+    t.Run("Without redactor", func(t *testing.T) {
+        f := &JSONFormatter{Fields: []Field{tokenField, redactionsField}}
+
+        res := f.FormatLogLine(LogLineArgs{Level: Info}, "secret-token")
+        if want := `{"token":"secret-token"}`; string(res.bytes) != want {
+            t.Errorf("FormatLogLine() = %s, want %s", res.bytes, want)
+        }
+    })
+}
+
+func TestNewRedactor_Error(t *testing.T) {
+    tests := []struct {
+        name string
+        rule RedactionRule
+    }{
+        {name: "Empty", rule: RedactionRule{}},
+        {name: "Name and path", rule: RedactionRule{Name: "a", Path: "a.b"}},
+        {name: "Unknown action", rule: RedactionRule{Name: "a", Action: RedactDrop + 1}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            var invalidRule *ErrorInvalidRedactionRule
+            if _, err := NewRedactor(tt.rule); !errors.As(err, &invalidRule) {
+                t.Errorf("NewRedactor() error = %v, want ErrorInvalidRedactionRule", err)
+            }
+        })
+    }
+}
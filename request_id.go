@@ -0,0 +1,200 @@
+package ultralogger
+
+import (
+    "context"
+    "crypto/rand"
+    "encoding/hex"
+    "net/http"
+    "strconv"
+    "strings"
+)
+
+const (
+    // RequestIDHeader is the header that carries the request ID of [RequestIDMiddleware] and [NewRequestIDTransport].
+    RequestIDHeader = "X-Request-ID"
+    // traceparentHeader is the W3C Trace Context header.
+    traceparentHeader = "traceparent"
+    // maxRequestIDLength is the longest request ID that is read from a request.
+    maxRequestIDLength = 128
+)
+
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a copy of the context that carries the request ID.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+    return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID carried by the context, if any. It is safe to call with a nil context.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+    if ctx == nil {
+        return "", false
+    }
+
+    id, ok := ctx.Value(requestIDKey{}).(string)
+    if !ok || id == "" {
+        return "", false
+    }
+    return id, true
+}
+
+// RequestIDMiddleware returns a net/http middleware that correlates the requests with a request ID. The ID is read from
+// the X-Request-ID header of the request, or else from the trace id of its W3C traceparent header, or else generated.
+// It is stored in the context of the request, see [RequestIDFromContext], and echoed in the X-Request-ID header of the
+// response.
+//
+// A valid traceparent header is also stored as the [SpanContext] of the request, unless the context already carries
+// one, so that formatters that support trace correlation pick it up.
+//
+// Wrap the handler of [HTTPMiddleware] with it, so that the access-log lines carry the ID. See
+// https://www.w3.org/TR/trace-context/#traceparent-header for more information.
+func RequestIDMiddleware() func(http.Handler) http.Handler {
+    return func(next http.Handler) http.Handler {
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            ctx := r.Context()
+
+            sc, traced := parseTraceparent(r.Header.Get(traceparentHeader))
+            if _, ok := SpanContextFromContext(ctx); traced && !ok {
+                ctx = ContextWithSpanContext(ctx, sc)
+            }
+
+            id := r.Header.Get(RequestIDHeader)
+            switch {
+            case validRequestID(id):
+            case traced:
+                id = sc.TraceID
+            default:
+                id = newRequestID()
+            }
+
+            w.Header().Set(RequestIDHeader, id)
+            next.ServeHTTP(w, r.WithContext(ContextWithRequestID(ctx, id)))
+        })
+    }
+}
+
+// validRequestID reports whether the request ID can be used as is: it is not empty, not longer than 128 characters,
+// and contains only printable ASCII characters other than spaces, so that it can't forge log lines.
+func validRequestID(id string) bool {
+    if id == "" || len(id) > maxRequestIDLength {
+        return false
+    }
+    for i := 0; i < len(id); i++ {
+        if id[i] <= ' ' || id[i] > '~' {
+            return false
+        }
+    }
+    return true
+}
+
+// newRequestID returns a random 16 byte request ID, hex encoded.
+func newRequestID() string {
+    id := make([]byte, 16)
+    _, _ = rand.Read(id)
+    return hex.EncodeToString(id)
+}
+
+// parseTraceparent returns the SpanContext of a traceparent header, e.g.
+// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", and false if it is invalid.
+func parseTraceparent(traceparent string) (SpanContext, bool) {
+    parts := strings.Split(traceparent, "-")
+    if len(parts) < 4 {
+        return SpanContext{}, false
+    }
+
+    version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+    switch {
+    case !lowerHex(version, 2) || version == "ff" || (version == "00" && len(parts) != 4):
+        return SpanContext{}, false
+    case !lowerHex(traceID, 32) || traceID == strings.Repeat("0", 32):
+        return SpanContext{}, false
+    case !lowerHex(spanID, 16) || spanID == strings.Repeat("0", 16):
+        return SpanContext{}, false
+    case !lowerHex(flags, 2):
+        return SpanContext{}, false
+    }
+
+    flagBits, _ := strconv.ParseUint(flags, 16, 8)
+    return SpanContext{TraceID: traceID, SpanID: spanID, Sampled: flagBits&1 == 1}, true
+}
+
+// lowerHex reports whether s is n lowercase hex characters.
+func lowerHex(s string, n int) bool {
+    if len(s) != n {
+        return false
+    }
+    for i := 0; i < len(s); i++ {
+        if !('0' <= s[i] && s[i] <= '9' || 'a' <= s[i] && s[i] <= 'f') {
+            return false
+        }
+    }
+    return true
+}
+
+// NewRequestIDTransport returns an http.RoundTripper that sets the X-Request-ID header of the outgoing requests to the
+// request ID carried by their context, unless they already have one, and makes the calls with the base
+// http.RoundTripper. If the base is nil, http.DefaultTransport is used.
+//
+// It can be combined with [NewLoggingTransport] in either order.
+func NewRequestIDTransport(base http.RoundTripper) http.RoundTripper {
+    if base == nil {
+        base = http.DefaultTransport
+    }
+    return &requestIDTransport{base: base}
+}
+
+type requestIDTransport struct {
+    base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *requestIDTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+    id, ok := RequestIDFromContext(req.Context())
+    if !ok || req.Header.Get(RequestIDHeader) != "" {
+        return t.base.RoundTrip(req)
+    }
+
+    // The request must not be modified, so the header is set on a copy of the request.
+    req = req.Clone(req.Context())
+    req.Header.Set(RequestIDHeader, id)
+    return t.base.RoundTrip(req)
+}
+
+// NewRequestIDField returns a new Field that formats the request ID carried by the context of the log line, see
+// [ContextLogger.LogContext]. The data of the line is ignored.
+//
+// OutputFormats:
+//  - OutputFormatText => request ID is formatted as a string. Lines without a request ID have an empty string, which
+//    the TextFormatter skips.
+//  - OutputFormatJSON => request ID is formatted as a string. Lines without a request ID have no data.
+func NewRequestIDField(name string) (Field, error) {
+    if name == "" {
+        return &requestIDField{}, ErrorEmptyFieldName
+    }
+    return &requestIDField{name: name}, nil
+}
+
+type requestIDField struct {
+    name string
+}
+
+func (f *requestIDField) NewFieldFormatter() (FieldFormatter, error) {
+    return f.format, nil
+}
+
+func (f *requestIDField) FieldName() string {
+    return f.name
+}
+
+func (f *requestIDField) format(args LogLineArgs, _ any) (FieldResult, error) {
+    result := FieldResult{
+        Name: f.name,
+    }
+
+    if id, ok := RequestIDFromContext(args.Context); ok {
+        result.Data = id
+    } else if args.OutputFormat == OutputFormatText {
+        result.Data = ""
+    }
+    return result, nil
+}
@@ -0,0 +1,244 @@
+package ultralogger
+
+import (
+    "bytes"
+    "context"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "testing"
+)
+
+func ExampleRequestIDMiddleware() {
+    requestIDField, _ := NewRequestIDField("request_id")
+    responseField, _ := NewResponseField("response", ResponseFieldSettings{LogStatusCode: true})
+
+    formatter, _ := NewFormatter(OutputFormatJSON, []Field{requestIDField, responseField})
+
+    logger, _ := NewLoggerWithOptions(WithDestination(os.Stdout, formatter), WithAsync(false))
+
+    handler := RequestIDMiddleware()(HTTPMiddleware(logger)(http.HandlerFunc(
+        func(w http.ResponseWriter, r *http.Request) {
+            w.WriteHeader(http.StatusAccepted)
+        },
+    )))
+
+    request := httptest.NewRequest(http.MethodPost, "/orders", nil)
+    request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+    handler.ServeHTTP(httptest.NewRecorder(), request)
+    // Output: {"request_id":"4bf92f3577b34da6a3ce929d0e0e4736","response":{"status_code":202}}
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+    const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+    tests := []struct {
+        name        string
+        headers     map[string]string
+        want        string
+        wantTraceID string
+    }{
+        {
+            name:    "X-Request-ID",
+            headers: map[string]string{"X-Request-ID": "abc-123", "traceparent": traceparent},
+            want:    "abc-123",
+            // The traceparent is stored as the span context, also when the request ID is read from X-Request-ID.
+            wantTraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+        },
+        {
+            name:        "traceparent",
+            headers:     map[string]string{"traceparent": traceparent},
+            want:        "4bf92f3577b34da6a3ce929d0e0e4736",
+            wantTraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+        },
+        {
+            name:    "Invalid X-Request-ID",
+            headers: map[string]string{"X-Request-ID": "forged\nline"},
+        },
+        {
+            name:    "Invalid traceparent",
+            headers: map[string]string{"traceparent": "00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+        },
+        {
+            name: "Generated",
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            var got, gotTraceID string
+            handler := RequestIDMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+                got, _ = RequestIDFromContext(r.Context())
+                sc, _ := SpanContextFromContext(r.Context())
+                gotTraceID = sc.TraceID
+            }))
+
+            request := httptest.NewRequest(http.MethodGet, "/", nil)
+            for name, value := range tt.headers {
+                request.Header.Set(name, value)
+            }
+
+            recorder := httptest.NewRecorder()
+            handler.ServeHTTP(recorder, request)
+
+            if tt.want != "" && got != tt.want {
+                t.Errorf("RequestIDFromContext() = %s, want %s", got, tt.want)
+            }
+            if tt.want == "" && (len(got) != 32 || !lowerHex(got, 32)) {
+                t.Errorf("RequestIDFromContext() = %q, want a generated ID", got)
+            }
+            if echoed := recorder.Header().Get(RequestIDHeader); echoed != got {
+                t.Errorf("X-Request-ID = %s, want %s", echoed, got)
+            }
+            if gotTraceID != tt.wantTraceID {
+                t.Errorf("SpanContextFromContext() trace id = %s, want %s", gotTraceID, tt.wantTraceID)
+            }
+        })
+    }
+}
+
+func TestParseTraceparent(t *testing.T) {
+    tests := []struct {
+        name        string
+        traceparent string
+        want        SpanContext
+        wantOK      bool
+    }{
+        {
+            name:        "Sampled",
+            traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+            want: SpanContext{
+                TraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+                SpanID:  "00f067aa0ba902b7",
+                Sampled: true,
+            },
+            wantOK: true,
+        },
+        {
+            name:        "Future version",
+            traceparent: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra",
+            want:        SpanContext{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "00f067aa0ba902b7"},
+            wantOK:      true,
+        },
+        {name: "Empty", traceparent: ""},
+        {name: "Invalid version", traceparent: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+        {name: "Version 00 with extra", traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-x"},
+        {name: "Uppercase", traceparent: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01"},
+        {name: "Zero span", traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01"},
+        {name: "Short flags", traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-1"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, ok := parseTraceparent(tt.traceparent)
+            if got != tt.want || ok != tt.wantOK {
+                t.Errorf("parseTraceparent() = %+v, %v, want %+v, %v", got, ok, tt.want, tt.wantOK)
+            }
+        })
+    }
+}
+
+func TestRequestIDTransport(t *testing.T) {
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        _, _ = w.Write([]byte(r.Header.Get(RequestIDHeader)))
+    }))
+    defer server.Close()
+
+    client := &http.Client{Transport: NewRequestIDTransport(nil)}
+
+    tests := []struct {
+        name   string
+        ctx    context.Context
+        header string
+        want   string
+    }{
+        {name: "From context", ctx: ContextWithRequestID(context.Background(), "abc-123"), want: "abc-123"},
+        {name: "Existing header", ctx: ContextWithRequestID(context.Background(), "abc-123"), header: "x", want: "x"},
+        {name: "Without request ID", ctx: context.Background()},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            req, _ := http.NewRequestWithContext(tt.ctx, http.MethodGet, server.URL, nil)
+            if tt.header != "" {
+                req.Header.Set(RequestIDHeader, tt.header)
+            }
+
+            resp, err := client.Do(req)
+            if err != nil {
+                t.Fatalf("Do() error = %v", err)
+            }
+            defer resp.Body.Close()
+
+            got := new(bytes.Buffer)
+            _, _ = got.ReadFrom(resp.Body)
+            if got.String() != tt.want {
+                t.Errorf("X-Request-ID = %s, want %s", got, tt.want)
+            }
+            if tt.header == "" && req.Header.Get(RequestIDHeader) != "" {
+                t.Errorf("RoundTrip() modified the request")
+            }
+        })
+    }
+}
+
+func TestRequestIDField(t *testing.T) {
+    requestIDField, _ := NewRequestIDField("request_id")
+    requestField, _ := NewRequestField("request", RequestFieldSettings{LogRequestID: true, LogMethod: true})
+
+    request := httptest.NewRequest(http.MethodGet, "/", nil)
+    request.Header.Set(RequestIDHeader, "from-header")
+
+    ctx := ContextWithRequestID(context.Background(), "abc-123")
+
+    tests := []struct {
+        name         string
+        fields       []Field
+        outputFormat OutputFormat
+        ctx          context.Context
+        data         any
+        want         string
+    }{
+        {
+            name:         "JSON",
+            fields:       []Field{requestIDField, NewMessageField()},
+            outputFormat: OutputFormatJSON,
+            ctx:          ctx,
+            data:         "hello",
+            want:         `{"message":"hello","request_id":"abc-123"}`,
+        },
+        {
+            name:         "Text without request ID",
+            fields:       []Field{requestIDField, NewMessageField()},
+            outputFormat: OutputFormatText,
+            data:         "hello",
+            want:         `hello`,
+        },
+        {
+            name:         "Request context",
+            fields:       []Field{requestField},
+            outputFormat: OutputFormatJSON,
+            data:         request.WithContext(ctx),
+            want:         `{"request":{"request_id":"abc-123","method":"GET"}}`,
+        },
+        {
+            name:         "Request header",
+            fields:       []Field{requestField},
+            outputFormat: OutputFormatText,
+            data:         request,
+            want:         `from-header GET`,
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            f, _ := NewFormatter(tt.outputFormat, tt.fields)
+
+            res := f.FormatLogLine(LogLineArgs{Level: Info, Context: tt.ctx}, tt.data)
+            if res.err != nil {
+                t.Fatalf("FormatLogLine() error = %v", res.err)
+            }
+
+            if string(res.bytes) != tt.want {
+                t.Errorf("FormatLogLine() = %s, want %s", res.bytes, tt.want)
+            }
+        })
+    }
+}
@@ -0,0 +1,40 @@
+package ultralogger
+
+import "context"
+
+// SpanContext identifies the trace and span that a log line belongs to. Formatters that support trace correlation
+// read the SpanContext from the context passed to [ContextLogger.LogContext].
+type SpanContext struct {
+    // TraceID is the hex encoded 16 byte trace id.
+    TraceID string
+    // SpanID is the hex encoded 8 byte span id.
+    SpanID string
+    // Sampled reports whether the trace is sampled.
+    Sampled bool
+}
+
+// IsValid reports whether the SpanContext has a trace id.
+func (sc SpanContext) IsValid() bool {
+    return sc.TraceID != ""
+}
+
+type spanContextKey struct{}
+
+// ContextWithSpanContext returns a copy of the context that carries the SpanContext.
+func ContextWithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+    return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+// SpanContextFromContext returns the SpanContext carried by the context, if any. It is safe to call with a nil
+// context.
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+    if ctx == nil {
+        return SpanContext{}, false
+    }
+
+    sc, ok := ctx.Value(spanContextKey{}).(SpanContext)
+    if !ok || !sc.IsValid() {
+        return SpanContext{}, false
+    }
+    return sc, true
+}
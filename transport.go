@@ -0,0 +1,269 @@
+package ultralogger
+
+import (
+    "net/http"
+    "net/url"
+    "slices"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// defaultRedactedQueryParams are the query parameters whose values [NewLoggingTransport] redacts in the logged URLs.
+var defaultRedactedQueryParams = []string{
+    "access_token",
+    "api_key",
+    "apikey",
+    "client_secret",
+    "code",
+    "key",
+    "password",
+    "secret",
+    "sig",
+    "signature",
+    "token",
+}
+
+// TransportOption is an option of [NewLoggingTransport].
+type TransportOption func(t *loggingTransport)
+
+// WithBodyCapture logs up to maxSize bytes of the request and response bodies of the calls. The bodies are replayed,
+// so that the caller still reads the whole response body.
+func WithBodyCapture(maxSize int) TransportOption {
+    return func(t *loggingTransport) {
+        t.maxBodySize = maxSize
+    }
+}
+
+// WithRedactedQueryParams redacts the values of the query parameters with the names, ignoring case, in the logged URLs,
+// in addition to the default ones, e.g. "token", "api_key" and "signature".
+func WithRedactedQueryParams(names ...string) TransportOption {
+    return func(t *loggingTransport) {
+        t.redactedQueryParams = append(t.redactedQueryParams, names...)
+    }
+}
+
+// NewLoggingTransport returns an http.RoundTripper that makes the calls with the base http.RoundTripper, and logs one
+// line per call with the logger. If the base is nil, http.DefaultTransport is used.
+//
+// The data of the line is a [TransportLogEntry]; log it with a [NewTransportField], or with the message field. The
+// line is logged at Error for calls that fail with an error or a 5xx status code, at Warn for 4xx status codes, and at
+// Info otherwise. If the logger is a [ContextLogger], the line is logged with the context of the request.
+//
+// Wrap it with [NewRetryingTransport] to log one line per attempt; the retries are counted in the entry.
+func NewLoggingTransport(base http.RoundTripper, logger Logger, opts ...TransportOption) http.RoundTripper {
+    if base == nil {
+        base = http.DefaultTransport
+    }
+
+    t := &loggingTransport{
+        base:                base,
+        logger:              logger,
+        redactedQueryParams: slices.Clone(defaultRedactedQueryParams),
+        clock:               &realClock{},
+    }
+    for _, opt := range opts {
+        opt(t)
+    }
+    return t
+}
+
+type loggingTransport struct {
+    base                http.RoundTripper
+    logger              Logger
+    maxBodySize         int
+    redactedQueryParams []string
+
+    clock clock
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+    start := t.clock.Now()
+
+    entry := TransportLogEntry{
+        Method: req.Method,
+        URL:    t.redactURL(req.URL),
+    }
+    if retry, ok := RetryAttemptFromContext(req.Context()); ok {
+        entry.Retries = retry
+    }
+
+    if t.maxBodySize > 0 {
+        // The request must not be modified, so a body without GetBody is captured from a copy of the request.
+        if req.GetBody == nil && req.Body != nil && req.Body != http.NoBody {
+            req = req.Clone(req.Context())
+        }
+        entry.RequestBody, entry.RequestBodyTruncated = t.captureRequestBody(req)
+    }
+
+    resp, err := t.base.RoundTrip(req)
+
+    entry.Duration = t.clock.Now().Sub(start)
+
+    var level Level
+    switch {
+    case err != nil:
+        entry.Error = err.Error()
+        level = Error
+    default:
+        entry.StatusCode = resp.StatusCode
+        level = defaultStatusLevel(resp.StatusCode)
+        if t.maxBodySize > 0 {
+            entry.ResponseBody, entry.ResponseBodyTruncated = t.captureResponseBody(resp)
+        }
+    }
+
+    logContext(t.logger, req.Context(), level, entry)
+    return resp, err
+}
+
+// captureRequestBody captures the body of the request. A body without GetBody is replaced, so the request must be a
+// copy of the request of the caller.
+func (t *loggingTransport) captureRequestBody(req *http.Request) (string, bool) {
+    if req.Body == nil || req.Body == http.NoBody {
+        return "", false
+    }
+
+    if req.GetBody == nil {
+        var captured string
+        var truncated bool
+        req.Body, captured, truncated = captureBody(req.Body, t.maxBodySize)
+        return captured, truncated
+    }
+
+    body, err := req.GetBody()
+    if err != nil {
+        return "", false
+    }
+    defer body.Close()
+
+    _, captured, truncated := captureBody(body, t.maxBodySize)
+    return captured, truncated
+}
+
+func (t *loggingTransport) captureResponseBody(resp *http.Response) (string, bool) {
+    if resp.Body == nil || resp.Body == http.NoBody {
+        return "", false
+    }
+
+    var captured string
+    var truncated bool
+    resp.Body, captured, truncated = captureBody(resp.Body, t.maxBodySize)
+    return captured, truncated
+}
+
+// redactURL returns the URL with its password, and the values of the redacted query parameters, redacted. The order
+// of the query parameters is kept.
+func (t *loggingTransport) redactURL(u *url.URL) string {
+    if u == nil {
+        return ""
+    }
+    if u.RawQuery == "" {
+        return u.Redacted()
+    }
+
+    params := strings.Split(u.RawQuery, "&")
+    for i, param := range params {
+        rawName, _, ok := strings.Cut(param, "=")
+        if !ok {
+            continue
+        }
+
+        name := rawName
+        if unescaped, err := url.QueryUnescape(rawName); err == nil {
+            name = unescaped
+        }
+        if headerListed(t.redactedQueryParams, name, false) {
+            params[i] = rawName + "=" + redactedPlaceholder
+        }
+    }
+
+    redacted := *u
+    redacted.RawQuery = strings.Join(params, "&")
+    return redacted.Redacted()
+}
+
+// NewTransportField returns a new Field that formats the [TransportLogEntry] of the lines of [NewLoggingTransport].
+//
+// OutputFormats:
+//  - OutputFormatText => entry is formatted as a space separated string. See [TransportLogEntry.String].
+//  - OutputFormatJSON => [TransportLogEntry].
+func NewTransportField(name string) (Field, error) {
+    return NewObjectField[TransportLogEntry](
+        name,
+        func(args LogLineArgs, data TransportLogEntry) any {
+            if args.OutputFormat == OutputFormatText {
+                return data.String()
+            }
+            return data
+        },
+    )
+}
+
+// TransportLogEntry is a struct that represents an outgoing call made through [NewLoggingTransport].
+type TransportLogEntry struct {
+    Method                string        `json:"method"`
+    URL                   string        `json:"url"`
+    StatusCode            int           `json:"status_code,omitempty"`
+    Duration              time.Duration `json:"duration"`
+    Retries               int           `json:"retries,omitempty"`
+    Error                 string        `json:"error,omitempty"`
+    RequestBody           string        `json:"request_body,omitempty"`
+    RequestBodyTruncated  bool          `json:"request_body_truncated,omitempty"`
+    ResponseBody          string        `json:"response_body,omitempty"`
+    ResponseBodyTruncated bool          `json:"response_body_truncated,omitempty"`
+}
+
+// logObject returns the fields of the entry that encoding/json writes, in the same order.
+func (e TransportLogEntry) logObject() structObject {
+    obj := structObject{
+        {Name: "method", Data: e.Method},
+        {Name: "url", Data: e.URL},
+    }
+    if e.StatusCode != 0 {
+        obj = obj.append("status_code", e.StatusCode)
+    }
+    obj = obj.append("duration", e.Duration)
+    if e.Retries != 0 {
+        obj = obj.append("retries", e.Retries)
+    }
+    if e.Error != "" {
+        obj = obj.append("error", e.Error)
+    }
+    if e.RequestBody != "" {
+        obj = obj.append("request_body", e.RequestBody)
+    }
+    if e.RequestBodyTruncated {
+        obj = obj.append("request_body_truncated", e.RequestBodyTruncated)
+    }
+    if e.ResponseBody != "" {
+        obj = obj.append("response_body", e.ResponseBody)
+    }
+    if e.ResponseBodyTruncated {
+        obj = obj.append("response_body_truncated", e.ResponseBodyTruncated)
+    }
+    return obj
+}
+
+// String returns the entry as a space separated string, e.g. `GET https://example.com/orders 200 12ms retries=1`.
+func (e TransportLogEntry) String() string {
+    parts := []string{e.Method, e.URL}
+    if e.StatusCode != 0 {
+        parts = append(parts, strconv.Itoa(e.StatusCode))
+    }
+    parts = append(parts, e.Duration.String())
+    if e.Retries != 0 {
+        parts = append(parts, "retries="+strconv.Itoa(e.Retries))
+    }
+    if e.Error != "" {
+        parts = append(parts, "error="+strconv.Quote(e.Error))
+    }
+    if e.RequestBody != "" {
+        parts = append(parts, "request_body="+strconv.Quote(e.RequestBody))
+    }
+    if e.ResponseBody != "" {
+        parts = append(parts, "response_body="+strconv.Quote(e.ResponseBody))
+    }
+    return strings.Join(parts, " ")
+}
@@ -0,0 +1,126 @@
+package ultralogger
+
+import (
+    "context"
+    "io"
+    "net/http"
+    "time"
+)
+
+type retryAttemptKey struct{}
+
+// ContextWithRetryAttempt returns a copy of the context that carries the number of the retry of a call, 1 for the
+// first retry. [NewLoggingTransport] reads it from the context of the request to count the retries in the entry.
+func ContextWithRetryAttempt(ctx context.Context, retry int) context.Context {
+    return context.WithValue(ctx, retryAttemptKey{}, retry)
+}
+
+// RetryAttemptFromContext returns the number of the retry carried by the context, if any. It is safe to call with a
+// nil context.
+func RetryAttemptFromContext(ctx context.Context) (int, bool) {
+    if ctx == nil {
+        return 0, false
+    }
+
+    retry, ok := ctx.Value(retryAttemptKey{}).(int)
+    if !ok || retry <= 0 {
+        return 0, false
+    }
+    return retry, true
+}
+
+// NewRetryingTransport returns an http.RoundTripper that makes the calls with the base http.RoundTripper, and retries
+// the calls that fail with an error, or with a 502, 503 or 504 status code, up to maxRetries times, waiting backoff
+// times the number of the retry before each retry. Only calls with an idempotent method whose body can be replayed
+// with http.Request.GetBody are retried. If the base is nil, http.DefaultTransport is used.
+//
+// The context of each retry carries the number of the retry, see [RetryAttemptFromContext], so a base
+// [NewLoggingTransport] logs every attempt with its number of retries.
+func NewRetryingTransport(base http.RoundTripper, maxRetries int, backoff time.Duration) http.RoundTripper {
+    if base == nil {
+        base = http.DefaultTransport
+    }
+    return &retryingTransport{
+        base:       base,
+        maxRetries: maxRetries,
+        backoff:    backoff,
+    }
+}
+
+type retryingTransport struct {
+    base       http.RoundTripper
+    maxRetries int
+    backoff    time.Duration
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *retryingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+    resp, err := t.base.RoundTrip(req)
+    for retry := 1; retry <= t.maxRetries && retryableCall(req, resp, err); retry++ {
+        retryReq, retryErr := retryRequest(req, retry)
+        if retryErr != nil {
+            return resp, err
+        }
+
+        if resp != nil {
+            _, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+            _ = resp.Body.Close()
+        }
+
+        if waitErr := sleepContext(req.Context(), t.backoff*time.Duration(retry)); waitErr != nil {
+            return nil, waitErr
+        }
+        resp, err = t.base.RoundTrip(retryReq)
+    }
+    return resp, err
+}
+
+// retryableCall reports whether the call failed in a way that may succeed when it is retried, and can be retried.
+func retryableCall(req *http.Request, resp *http.Response, err error) bool {
+    switch req.Method {
+    case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+    default:
+        return false
+    }
+
+    if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+        return false
+    }
+
+    if err != nil {
+        return req.Context().Err() == nil
+    }
+
+    switch resp.StatusCode {
+    case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+        return true
+    }
+    return false
+}
+
+// retryRequest returns a copy of the request with a new body, whose context carries the number of the retry.
+func retryRequest(req *http.Request, retry int) (*http.Request, error) {
+    retryReq := req.Clone(ContextWithRetryAttempt(req.Context(), retry))
+    if req.GetBody == nil {
+        return retryReq, nil
+    }
+
+    body, err := req.GetBody()
+    if err != nil {
+        return nil, err
+    }
+    retryReq.Body = body
+    return retryReq, nil
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+    timer := time.NewTimer(d)
+    defer timer.Stop()
+
+    select {
+    case <-ctx.Done():
+        return ctx.Err()
+    case <-timer.C:
+        return nil
+    }
+}
@@ -0,0 +1,133 @@
+package ultralogger
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "slices"
+    "strings"
+    "sync/atomic"
+    "testing"
+)
+
+func TestRetryingTransport(t *testing.T) {
+    var failures atomic.Int32
+    var received atomic.Value
+
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if failures.Add(-1) >= 0 {
+            w.WriteHeader(http.StatusServiceUnavailable)
+            return
+        }
+        body, _ := io.ReadAll(r.Body)
+        received.Store(string(body))
+    }))
+    defer server.Close()
+
+    tests := []struct {
+        name        string
+        maxRetries  int
+        failures    int32
+        method      string
+        body        io.Reader
+        wantStatus  int
+        wantRetries []int
+        wantBody    string
+    }{
+        {
+            name:        "Retries",
+            maxRetries:  3,
+            failures:    2,
+            method:      http.MethodPut,
+            body:        strings.NewReader("payload"),
+            wantStatus:  http.StatusOK,
+            wantRetries: []int{0, 1, 2},
+            wantBody:    "payload",
+        },
+        {
+            name:        "Retries exhausted",
+            maxRetries:  1,
+            failures:    2,
+            method:      http.MethodGet,
+            wantStatus:  http.StatusServiceUnavailable,
+            wantRetries: []int{0, 1},
+        },
+        {
+            name:        "Not idempotent",
+            maxRetries:  3,
+            failures:    1,
+            method:      http.MethodPost,
+            wantStatus:  http.StatusServiceUnavailable,
+            wantRetries: []int{0},
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            failures.Store(tt.failures)
+            received.Store("")
+
+            transportField, _ := NewTransportField("call")
+            formatter, _ := NewFormatter(OutputFormatJSON, []Field{transportField})
+
+            buf := &bytes.Buffer{}
+            logger, _ := NewLoggerWithOptions(WithDestination(buf, formatter), WithAsync(false))
+
+            transport := NewRetryingTransport(NewLoggingTransport(nil, logger), tt.maxRetries, 0)
+            client := &http.Client{Transport: transport}
+
+            req, _ := http.NewRequest(tt.method, server.URL, tt.body)
+            resp, err := client.Do(req)
+            if err != nil {
+                t.Fatalf("Do() error = %v", err)
+            }
+            _ = resp.Body.Close()
+
+            if resp.StatusCode != tt.wantStatus {
+                t.Errorf("Do() status = %d, want %d", resp.StatusCode, tt.wantStatus)
+            }
+            if got := received.Load(); got != tt.wantBody {
+                t.Errorf("retried request body = %v, want %s", got, tt.wantBody)
+            }
+
+            var retries []int
+            decoder := json.NewDecoder(buf)
+            for decoder.More() {
+                var line struct {
+                    Call TransportLogEntry `json:"call"`
+                }
+                if err := decoder.Decode(&line); err != nil {
+                    t.Fatalf("Decode() error = %v", err)
+                }
+                retries = append(retries, line.Call.Retries)
+            }
+            if !slices.Equal(retries, tt.wantRetries) {
+                t.Errorf("NewLoggingTransport() logged retries %v, want %v", retries, tt.wantRetries)
+            }
+        })
+    }
+}
+
+func TestRetryAttemptFromContext(t *testing.T) {
+    tests := []struct {
+        name   string
+        ctx    context.Context
+        want   int
+        wantOk bool
+    }{
+        {name: "Retry", ctx: ContextWithRetryAttempt(context.Background(), 2), want: 2, wantOk: true},
+        {name: "First attempt", ctx: ContextWithRetryAttempt(context.Background(), 0)},
+        {name: "Missing", ctx: context.Background()},
+        {name: "Nil", ctx: nil},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, ok := RetryAttemptFromContext(tt.ctx)
+            if got != tt.want || ok != tt.wantOk {
+                t.Errorf("RetryAttemptFromContext() = %d, %v, want %d, %v", got, ok, tt.want, tt.wantOk)
+            }
+        })
+    }
+}
@@ -5,6 +5,7 @@ import (
     "fmt"
     "io"
     "os"
+    "runtime"
     "time"
 )
 
@@ -18,6 +19,7 @@ type ultraLogger struct {
     fallback          bool
     panicOnPanicLevel bool
     async             bool
+    caller            bool
 }
 
 func newUltraLogger() *ultraLogger {
@@ -33,13 +35,29 @@ func newUltraLogger() *ultraLogger {
 
 // Log logs a message with the given level and message.
 func (l *ultraLogger) Log(level Level, data any) {
+    l.log(nil, level, data)
+}
+
+// LogContext logs a message with the given level and message. The context is passed to the fields through
+// LogLineArgs.
+func (l *ultraLogger) LogContext(ctx context.Context, level Level, data any) {
+    l.log(ctx, level, data)
+}
+
+// log must be called directly by the exported logging methods, so that the caller is always two frames up.
+func (l *ultraLogger) log(ctx context.Context, level Level, data any) {
     if l.silent || level < l.minLevel {
         return
     }
 
     args := LogLineArgs{
-        Level: level,
-        Tag:   l.tag,
+        Level:   level,
+        Tag:     l.tag,
+        Context: ctx,
+    }
+
+    if l.caller {
+        args.Caller = callerAt(2)
     }
 
     for w, f := range l.destinations {
@@ -58,27 +76,27 @@ func (l *ultraLogger) Log(level Level, data any) {
 
 // Debug logs a message with the Debug level and message.
 func (l *ultraLogger) Debug(data any) {
-    l.Log(Debug, data)
+    l.log(nil, Debug, data)
 }
 
 // Info logs a message with the Info level and message.
 func (l *ultraLogger) Info(data any) {
-    l.Log(Info, data)
+    l.log(nil, Info, data)
 }
 
 // Warn logs a message with the Warn level and message.
 func (l *ultraLogger) Warn(data any) {
-    l.Log(Warn, data)
+    l.log(nil, Warn, data)
 }
 
 // Error logs a message with the Error level and message.
 func (l *ultraLogger) Error(data any) {
-    l.Log(Error, data)
+    l.log(nil, Error, data)
 }
 
 // Panic logs a message with the Panic level and message. If panicOnPanicLevel is true, it panics.
 func (l *ultraLogger) Panic(data any) {
-    l.Log(Panic, data)
+    l.log(nil, Panic, data)
 
     if l.panicOnPanicLevel {
         panic(data)
@@ -123,7 +141,7 @@ func (l *ultraLogger) writeLogLine(
         return
     }
 
-    writeResult := write(w, formatResult.bytes)
+    writeResult := write(w, formatResult.bytes, !isFramed(f))
     if writeResult != nil {
         l.handleLogWriterError(w, args.Level, data, writeResult)
     }
@@ -160,7 +178,7 @@ func (l *ultraLogger) writeLogLineAsync(
     }
 
     writeChan := make(chan error, 1)
-    go writeLogLineAsync(ctx, writeChan, w, logBytes)
+    go writeLogLineAsync(ctx, writeChan, w, logBytes, !isFramed(f))
 
     select {
     case err := <-writeChan:
@@ -193,17 +211,38 @@ func writeLogLineAsync(
     resultChan chan error,
     w io.Writer,
     b []byte,
+    newline bool,
 ) {
     defer close(resultChan)
 
     select {
     case <-ctx.Done():
         return
-    case resultChan <- write(w, b):
+    case resultChan <- write(w, b, newline):
     }
 }
 
-func write(w io.Writer, b []byte) error {
-    _, err := w.Write(append(b, '\n'))
+// callerAt returns the Caller skip frames above the function that calls callerAt, or nil if the frame is not
+// available. As with runtime.Caller, a skip of 0 identifies the function that calls callerAt.
+func callerAt(skip int) *Caller {
+    pcs := make([]uintptr, 1)
+    if runtime.Callers(skip+2, pcs) == 0 {
+        return nil
+    }
+
+    frame, _ := runtime.CallersFrames(pcs).Next()
+    return &Caller{
+        Function: frame.Function,
+        File:     frame.File,
+        Line:     frame.Line,
+    }
+}
+
+// write writes the log line, terminated by a newline unless the line is framed by its formatter.
+func write(w io.Writer, b []byte, newline bool) error {
+    if newline {
+        b = append(b, '\n')
+    }
+    _, err := w.Write(b)
     return err
 }
@@ -0,0 +1,162 @@
+package ultralogger
+
+import (
+    "bytes"
+    "compress/gzip"
+    "compress/zlib"
+    "encoding/binary"
+    "math/rand/v2"
+    "net"
+)
+
+// GELFCompression is the compression applied to a GELF message before it is sent over UDP.
+type GELFCompression int
+
+const (
+    GELFCompressionNone GELFCompression = iota
+    GELFCompressionGzip
+    GELFCompressionZlib
+)
+
+const (
+    // GELFChunkSizeWAN is the recommended maximum UDP payload size when sending GELF messages across the internet.
+    GELFChunkSizeWAN = 1420
+    // GELFChunkSizeLAN is the recommended maximum UDP payload size when sending GELF messages within a local network.
+    GELFChunkSizeLAN = 8154
+
+    gelfChunkHeaderSize = 12
+    gelfMaxChunks       = 128
+)
+
+var gelfChunkMagic = []byte{0x1e, 0x0f}
+
+// GELFUDPWriterSettings are the settings for a GELFUDPWriter.
+//
+// If the ChunkSize is 0, GELFChunkSizeWAN is used.
+type GELFUDPWriterSettings struct {
+    // ChunkSize is the maximum size of a single UDP datagram, including the chunk header. Messages larger than the
+    // ChunkSize are split into chunks.
+    ChunkSize int
+    // Compression is the compression applied to each message before it is chunked.
+    Compression GELFCompression
+}
+
+// GELFUDPWriter is an io.Writer that sends each write as a single GELF message to a Graylog UDP input. It is intended
+// to be used as a logger destination together with a GELFFormatter.
+//
+// Messages that do not fit into a single datagram are split into GELF chunks. A message can have at most 128 chunks;
+// larger messages are rejected with an ErrorGELFMessageTooLarge.
+type GELFUDPWriter struct {
+    conn        net.Conn
+    chunkSize   int
+    compression GELFCompression
+}
+
+// NewGELFUDPWriter returns a new GELFUDPWriter that sends messages to the provided address, e.g. "graylog:12201".
+func NewGELFUDPWriter(addr string, settings GELFUDPWriterSettings) (*GELFUDPWriter, error) {
+    if settings.ChunkSize == 0 {
+        settings.ChunkSize = GELFChunkSizeWAN
+    }
+
+    if settings.ChunkSize <= gelfChunkHeaderSize {
+        return nil, &ErrorInvalidGELFChunkSize{chunkSize: settings.ChunkSize}
+    }
+
+    conn, err := net.Dial("udp", addr)
+    if err != nil {
+        return nil, err
+    }
+
+    return &GELFUDPWriter{
+        conn:        conn,
+        chunkSize:   settings.ChunkSize,
+        compression: settings.Compression,
+    }, nil
+}
+
+// Write sends p as a single GELF message. The trailing newline appended by the logger is stripped, since GELF
+// messages are delimited by datagrams rather than by newlines.
+func (w *GELFUDPWriter) Write(p []byte) (int, error) {
+    message, err := w.compress(bytes.TrimRight(p, "\n\x00"))
+    if err != nil {
+        return 0, err
+    }
+
+    if len(message) <= w.chunkSize {
+        if _, err := w.conn.Write(message); err != nil {
+            return 0, err
+        }
+        return len(p), nil
+    }
+
+    chunks, err := gelfChunks(message, w.chunkSize, rand.Uint64())
+    if err != nil {
+        return 0, err
+    }
+
+    for _, chunk := range chunks {
+        if _, err := w.conn.Write(chunk); err != nil {
+            return 0, err
+        }
+    }
+
+    return len(p), nil
+}
+
+// Close closes the underlying UDP connection.
+func (w *GELFUDPWriter) Close() error {
+    return w.conn.Close()
+}
+
+func (w *GELFUDPWriter) compress(message []byte) ([]byte, error) {
+    buf := &bytes.Buffer{}
+
+    switch w.compression {
+    case GELFCompressionGzip:
+        gz := gzip.NewWriter(buf)
+        if _, err := gz.Write(message); err != nil {
+            return nil, err
+        }
+        if err := gz.Close(); err != nil {
+            return nil, err
+        }
+    case GELFCompressionZlib:
+        zw := zlib.NewWriter(buf)
+        if _, err := zw.Write(message); err != nil {
+            return nil, err
+        }
+        if err := zw.Close(); err != nil {
+            return nil, err
+        }
+    default:
+        return message, nil
+    }
+
+    return buf.Bytes(), nil
+}
+
+// gelfChunks splits the message into GELF chunks. Each chunk is prefixed with the chunk magic bytes, the message id,
+// the sequence number and the sequence count.
+func gelfChunks(message []byte, chunkSize int, messageID uint64) ([][]byte, error) {
+    payloadSize := chunkSize - gelfChunkHeaderSize
+    count := (len(message) + payloadSize - 1) / payloadSize
+    if count > gelfMaxChunks {
+        return nil, &ErrorGELFMessageTooLarge{size: len(message), maxSize: payloadSize * gelfMaxChunks}
+    }
+
+    chunks := make([][]byte, 0, count)
+    for seq := 0; seq < count; seq++ {
+        end := min((seq+1)*payloadSize, len(message))
+        payload := message[seq*payloadSize : end]
+
+        chunk := make([]byte, gelfChunkHeaderSize, gelfChunkHeaderSize+len(payload))
+        copy(chunk, gelfChunkMagic)
+        binary.BigEndian.PutUint64(chunk[2:10], messageID)
+        chunk[10] = byte(seq)
+        chunk[11] = byte(count)
+
+        chunks = append(chunks, append(chunk, payload...))
+    }
+
+    return chunks, nil
+}
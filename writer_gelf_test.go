@@ -0,0 +1,156 @@
+package ultralogger
+
+import (
+    "bytes"
+    "compress/gzip"
+    "compress/zlib"
+    "errors"
+    "io"
+    "net"
+    "strings"
+    "testing"
+    "time"
+)
+
+// readGELFMessage reads datagrams from the listener until a complete GELF message has been received, reassembling
+// chunks if necessary.
+func readGELFMessage(t *testing.T, conn net.PacketConn) []byte {
+    t.Helper()
+
+    var chunks [][]byte
+    buf := make([]byte, 65535)
+
+    for {
+        _ = conn.SetReadDeadline(time.Now().Add(time.Second))
+        n, _, err := conn.ReadFrom(buf)
+        if err != nil {
+            t.Fatalf("failed to read datagram: %v", err)
+        }
+        datagram := append([]byte(nil), buf[:n]...)
+
+        if !bytes.HasPrefix(datagram, gelfChunkMagic) {
+            return datagram
+        }
+
+        seq, count := int(datagram[10]), int(datagram[11])
+        if chunks == nil {
+            chunks = make([][]byte, count)
+        }
+        if len(chunks) != count {
+            t.Fatalf("sequence count changed between chunks: %d != %d", len(chunks), count)
+        }
+        chunks[seq] = datagram[gelfChunkHeaderSize:]
+
+        complete := true
+        for _, c := range chunks {
+            complete = complete && c != nil
+        }
+        if complete {
+            return bytes.Join(chunks, nil)
+        }
+    }
+}
+
+func TestGELFUDPWriter_Write(t *testing.T) {
+    longMessage := []byte(`{"short_message":"` + strings.Repeat("x", 5000) + `"}`)
+
+    tests := []struct {
+        name       string
+        settings   GELFUDPWriterSettings
+        message    []byte
+        decompress func(r io.Reader) (io.Reader, error)
+    }{
+        {
+            name:    "Single datagram",
+            message: []byte(`{"short_message":"hello"}` + "\n"),
+        },
+        {
+            name:     "Chunked",
+            settings: GELFUDPWriterSettings{ChunkSize: 512},
+            message:  longMessage,
+        },
+        {
+            name:     "Gzip",
+            settings: GELFUDPWriterSettings{Compression: GELFCompressionGzip},
+            message:  []byte(`{"short_message":"hello"}` + "\n"),
+            decompress: func(r io.Reader) (io.Reader, error) {
+                return gzip.NewReader(r)
+            },
+        },
+        {
+            name:     "Zlib chunked",
+            settings: GELFUDPWriterSettings{ChunkSize: 24, Compression: GELFCompressionZlib},
+            message:  longMessage,
+            decompress: func(r io.Reader) (io.Reader, error) {
+                return zlib.NewReader(r)
+            },
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+            if err != nil {
+                t.Fatalf("failed to listen: %v", err)
+            }
+            defer listener.Close()
+
+            w, err := NewGELFUDPWriter(listener.LocalAddr().String(), tt.settings)
+            if err != nil {
+                t.Fatalf("NewGELFUDPWriter() error = %v", err)
+            }
+            defer w.Close()
+
+            n, err := w.Write(tt.message)
+            if err != nil {
+                t.Fatalf("Write() error = %v", err)
+            }
+            if n != len(tt.message) {
+                t.Errorf("Write() n = %d, want %d", n, len(tt.message))
+            }
+
+            got := readGELFMessage(t, listener)
+            if tt.decompress != nil {
+                r, err := tt.decompress(bytes.NewReader(got))
+                if err != nil {
+                    t.Fatalf("failed to decompress message: %v", err)
+                }
+                got, _ = io.ReadAll(r)
+            }
+
+            want := bytes.TrimSuffix(tt.message, []byte("\n"))
+            if !bytes.Equal(got, want) {
+                t.Errorf("received message = %q, want %q", got, want)
+            }
+        })
+    }
+}
+
+func TestGELFUDPWriter_TooLarge(t *testing.T) {
+    listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatalf("failed to listen: %v", err)
+    }
+    defer listener.Close()
+
+    w, err := NewGELFUDPWriter(listener.LocalAddr().String(), GELFUDPWriterSettings{ChunkSize: 16})
+    if err != nil {
+        t.Fatalf("NewGELFUDPWriter() error = %v", err)
+    }
+    defer w.Close()
+
+    _, err = w.Write(bytes.Repeat([]byte("x"), 4*gelfMaxChunks+1))
+
+    var tooLarge *ErrorGELFMessageTooLarge
+    if !errors.As(err, &tooLarge) {
+        t.Errorf("Write() error = %v, want ErrorGELFMessageTooLarge", err)
+    }
+}
+
+func TestNewGELFUDPWriter_InvalidChunkSize(t *testing.T) {
+    _, err := NewGELFUDPWriter("127.0.0.1:12201", GELFUDPWriterSettings{ChunkSize: gelfChunkHeaderSize})
+
+    var invalidChunkSize *ErrorInvalidGELFChunkSize
+    if !errors.As(err, &invalidChunkSize) {
+        t.Errorf("NewGELFUDPWriter() error = %v, want ErrorInvalidGELFChunkSize", err)
+    }
+}